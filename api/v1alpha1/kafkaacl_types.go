@@ -0,0 +1,120 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KafkaACLSpec defines the desired state of KafkaACL, it is ACL binding for each of operations
+type KafkaACLSpec struct {
+	// Principal bindings are for, i.e. User:orders-service
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z]+:.+$`
+	Principal string `json:"principal"`
+
+	// Host principal connects from, any host if not set
+	// +optional
+	// +kubebuilder:default="*"
+	Host string `json:"host,omitempty"`
+
+	// ResourceType is type of resource bindings are for
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=TOPIC;GROUP;TRANSACTIONAL_ID;CLUSTER
+	ResourceType string `json:"resourceType"`
+
+	// ResourceName is name or prefix of resources, * is any resource. It is required unless resource is CLUSTER.
+	// +optional
+	// +kubebuilder:validation:MaxLength=255
+	ResourceName string `json:"resourceName,omitempty"`
+
+	// PatternType is how ResourceName matches resources
+	// +optional
+	// +kubebuilder:validation:Enum=LITERAL;PREFIXED
+	// +kubebuilder:default=LITERAL
+	PatternType string `json:"patternType,omitempty"`
+
+	// Operations principal is allowed or denied
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Enum=ALL;READ;WRITE;CREATE;DELETE;ALTER;DESCRIBE;CLUSTER_ACTION;DESCRIBE_CONFIGS;ALTER_CONFIGS;IDEMPOTENT_WRITE
+	Operations []string `json:"operations"`
+
+	// Permission is whether operations are allowed or denied
+	// +optional
+	// +kubebuilder:validation:Enum=ALLOW;DENY
+	// +kubebuilder:default=ALLOW
+	Permission string `json:"permission,omitempty"`
+}
+
+// ACLBinding is ACL binding in Kafka cluster
+type ACLBinding struct {
+	Principal    string `json:"principal"`
+	Host         string `json:"host"`
+	ResourceType string `json:"resourceType"`
+	ResourceName string `json:"resourceName"`
+	PatternType  string `json:"patternType"`
+	Operation    string `json:"operation"`
+	Permission   string `json:"permission"`
+}
+
+// KafkaACLStatus defines the observed state of KafkaACL
+type KafkaACLStatus struct {
+	// Conditions store the status conditions of the KafkaACL instances
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// Bindings are ACL bindings KafkaACL created and owns, bindings which already existed aren't owned.
+	// They are deleted when they aren't in spec anymore or KafkaACL is deleted
+	// +optional
+	Bindings []ACLBinding `json:"bindings,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Principal",type=string,JSONPath=`.spec.principal`
+// +kubebuilder:printcolumn:name="Permission",type=string,JSONPath=`.spec.permission`
+// +kubebuilder:printcolumn:name="Resource Type",type=string,JSONPath=`.spec.resourceType`
+// +kubebuilder:printcolumn:name="Resource",type=string,JSONPath=`.spec.resourceName`
+// +kubebuilder:printcolumn:name="Pattern",type=string,JSONPath=`.spec.patternType`,priority=1
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// KafkaACL is the Schema for the kafkaacls API
+type KafkaACL struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KafkaACLSpec   `json:"spec,omitempty"`
+	Status KafkaACLStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KafkaACLList contains a list of KafkaACL
+type KafkaACLList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KafkaACL `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KafkaACL{}, &KafkaACLList{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -28,24 +29,282 @@ type KafkaSchemaSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// +required
-	// +kubebuilder:validation:Required
+	// Name is subject of schema, either Name or SubjectStrategy must be set
+	// +optional
 	// +kubebuilder:validation:MinLength=3
 	// +kubebuilder:validation:MaxLength=255
 	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9\\._\\-]{1,255}$`
+	Name string `json:"name,omitempty"`
+
+	// SubjectStrategy derives subject from topic and record name: TopicName is <topic>-<keyOrValue>,
+	// RecordName is fully qualified record name and TopicRecordName is <topic>-<record name>
+	// +optional
+	// +kubebuilder:validation:Enum=TopicName;RecordName;TopicRecordName
+	SubjectStrategy string `json:"subjectStrategy,omitempty"`
+
+	// Context is Schema Registry context subject is registered in, i.e. tenant of multi-tenant registry.
+	// Subject is qualified with it as :.<context>:<subject>, default context is used if not set.
+	// +optional
+	// +kubebuilder:validation:MaxLength=255
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_\\-]{1,255}$`
+	Context string `json:"context,omitempty"`
+
+	// RegistryRef is KafkaSchemaRegistry describing Schema Registry schema is registered in,
+	// registry operator is configured with is used if not set
+	// +optional
+	RegistryRef *KafkaSchemaRegistryRef `json:"registryRef,omitempty"`
+
+	// TopicRef is KafkaTopic which messages schema describes, it is required by TopicName and
+	// TopicRecordName strategies. Schema is registered only when KafkaTopic is Ready.
+	// +optional
+	TopicRef *KafkaTopicRef `json:"topicRef,omitempty"`
+
+	// KeyOrValue is part of Kafka message schema describes
+	// +optional
+	// +kubebuilder:validation:Enum=key;value
+	// +kubebuilder:default=value
+	KeyOrValue string `json:"keyOrValue,omitempty"`
+
+	// Schema is inline schema, either Schema or SchemaFrom must be set
+	// +optional
+	// +kubebuilder:validation:MinLength=2
+	Schema string `json:"schema,omitempty"`
+
+	// SchemaFrom is source of schema which is too big to be inline
+	// +optional
+	SchemaFrom *SchemaSource `json:"schemaFrom,omitempty"`
+
+	// Compatibility level of subject, global level of Schema Registry is used if not set
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(backward|backward_transitive|forward|forward_transitive|full|full_transitive|none|BACKWARD|BACKWARD_TRANSITIVE|FORWARD|FORWARD_TRANSITIVE|FULL|FULL_TRANSITIVE|NONE)$`
+	Compatibility string `json:"compatibility,omitempty"`
+
+	// Mode of subject, i.e. READONLY to freeze it after schema is registered, global mode of
+	// Schema Registry is used if not set
+	// +optional
+	// +kubebuilder:validation:Enum=READWRITE;READONLY;READONLY_OVERRIDE;IMPORT
+	Mode string `json:"mode,omitempty"`
+
+	// SchemaType is format of schema
+	// +optional
+	// +kubebuilder:validation:Enum=AVRO;PROTOBUF;JSON
+	// +kubebuilder:default=AVRO
+	SchemaType string `json:"schemaType,omitempty"`
+
+	// References are schemas this schema depends on, i.e. shared Avro types or imported .proto files
+	// +optional
+	References []SchemaReference `json:"references,omitempty"`
+
+	// Metadata is data contract metadata registered with schema, i.e. owner of schema in its properties
+	// +optional
+	Metadata *SchemaMetadata `json:"metadata,omitempty"`
+
+	// RuleSet is data contract rules registered with schema
+	// +optional
+	RuleSet *SchemaRuleSet `json:"ruleSet,omitempty"`
+
+	// ValidateOnly would only check compatibility of schema with latest registered version,
+	// without registering it. Result is in Compatible condition.
+	// +optional
+	ValidateOnly bool `json:"validateOnly,omitempty"`
+
+	// DeletionPolicy is what happens with subject when KafkaSchema is deleted,
+	// subject is never deleted when schema wasn't registered under it
+	// +optional
+	// +kubebuilder:validation:Enum=Retain;SoftDelete;HardDelete
+	// +kubebuilder:default=Retain
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+
+	// VersionRetention is number of latest versions kept in subject, older versions are soft deleted
+	// after registration unless they are referenced by other schemas. All versions are kept if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	VersionRetention int `json:"versionRetention,omitempty"`
+
+	// ID pins ID of schema, i.e. when schemas are migrated from another registry. Schema is registered
+	// with it only while subject is in IMPORT mode, registered schema with other ID is reported.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	ID int `json:"id,omitempty"`
+
+	// Version pins version schema is registered as in IMPORT mode, it can be set only with ID
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Version int `json:"version,omitempty"`
+}
+
+// SchemaSource is ConfigMap key with schema
+type SchemaSource struct {
+	// ConfigMapKeyRef selects key of ConfigMap in namespace of KafkaSchema
+	// +required
+	// +kubebuilder:validation:Required
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef"`
+
+	// Imports are .proto files imported by Protobuf schema, stored under other keys of the same
+	// ConfigMap. Each of them is registered under subject <namespace>.<name>/<import path> of KafkaSchema
+	// and referenced by schema, it is deleted with subject of schema according to deletion policy.
+	// When schema is validate only, imports aren't registered, they must be registered already.
+	// +optional
+	Imports []SchemaImport `json:"imports,omitempty"`
+}
+
+// SchemaImport is .proto file imported by Protobuf schema
+type SchemaImport struct {
+	// Name is import path of file, i.e. common/money.proto
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
 	Name string `json:"name"`
 
+	// Key of ConfigMap with file
 	// +required
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:MinLength=2
-	// +kubebuilder:default={}
-	Schema string `json:"schema"`
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+}
+
+// SchemaReference is a reference to schema registered in Schema Registry.
+// Either Subject or SchemaRef must be set.
+type SchemaReference struct {
+	// Name is Avro full name of type, Protobuf import path or JSON Schema $ref
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Subject of referenced schema in Schema Registry
+	// +optional
+	Subject string `json:"subject,omitempty"`
 
+	// Version of referenced schema, latest version is used if not set
 	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Version int `json:"version,omitempty"`
+
+	// SchemaRef is KafkaSchema object registering referenced schema
+	// +optional
+	SchemaRef *KafkaSchemaRef `json:"schemaRef,omitempty"`
+}
+
+// KafkaSchemaRef is a reference to KafkaSchema object
+type KafkaSchemaRef struct {
+	// Name of KafkaSchema object
+	// +required
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Pattern=`^(backward|backward_transitive|forward|forward_transitive|full|full_transitive|none)$`
-	// +kubebuilder:default=backward
-	Compatibility string `json:"compatibility,omitempty"`
+	Name string `json:"name"`
+
+	// Namespace of KafkaSchema object, namespace of referencing object if not set
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// KafkaSchemaRegistryRef is a reference to KafkaSchemaRegistry object
+type KafkaSchemaRegistryRef struct {
+	// Name of KafkaSchemaRegistry object
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// KafkaTopicRef is a reference to KafkaTopic object
+type KafkaTopicRef struct {
+	// Name of KafkaTopic object
+	// +required
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Namespace of KafkaTopic object, namespace of KafkaSchema if not set
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ResolvedReference is a reference as it was registered in Schema Registry
+type ResolvedReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// SchemaMetadata is metadata of schema version, it is sent to Schema Registry as it is
+type SchemaMetadata struct {
+	// Tags are tags of fields of schema keyed by their path, i.e. Order.customer
+	// +optional
+	Tags map[string][]string `json:"tags,omitempty"`
+
+	// Properties are arbitrary properties of schema, i.e. owner and owner_email
+	// +optional
+	Properties map[string]string `json:"properties,omitempty"`
+
+	// Sensitive are names of properties which values are sensitive
+	// +optional
+	Sensitive []string `json:"sensitive,omitempty"`
+}
+
+// SchemaRuleSet is rules of schema version, it is sent to Schema Registry as it is
+type SchemaRuleSet struct {
+	// DomainRules are applied to messages, i.e. validation or encryption of fields
+	// +optional
+	DomainRules []SchemaRule `json:"domainRules,omitempty"`
+
+	// MigrationRules transform messages between versions of schema
+	// +optional
+	MigrationRules []SchemaRule `json:"migrationRules,omitempty"`
+}
+
+// SchemaRule is data contract rule
+type SchemaRule struct {
+	// Name of rule, it must be unique in rule set
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Doc is description of rule
+	// +optional
+	Doc string `json:"doc,omitempty"`
+
+	// Kind of rule
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=CONDITION;TRANSFORM
+	Kind string `json:"kind"`
+
+	// Mode is when rule is applied, migration rules are applied on UPGRADE or DOWNGRADE
+	// and domain rules on WRITE or READ
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=UPGRADE;DOWNGRADE;UPDOWN;WRITE;READ;WRITEREAD
+	Mode string `json:"mode"`
+
+	// Type of rule executor, i.e. CEL, CEL_FIELD, JSONATA or ENCRYPT
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+
+	// Tags of fields rule is applied to
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// Params of rule executor
+	// +optional
+	Params map[string]string `json:"params,omitempty"`
+
+	// Expr is expression of rule
+	// +optional
+	Expr string `json:"expr,omitempty"`
+
+	// OnSuccess is action taken when rule succeeds
+	// +optional
+	OnSuccess string `json:"onSuccess,omitempty"`
+
+	// OnFailure is action taken when rule fails, i.e. ERROR or DLQ
+	// +optional
+	OnFailure string `json:"onFailure,omitempty"`
+
+	// Disabled rule isn't applied
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 // KafkaSchemaStatus defines the observed state of KafkaSchema
@@ -62,10 +321,64 @@ type KafkaSchemaStatus struct {
 	// Conditions store the status conditions of the KafkaSchema instances
 	// +operator-sdk:csv:customresourcedefinitions:type=status
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// Subject schema is registered under
+	// +optional
+	Subject string `json:"subject,omitempty"`
+
+	// ID of registered schema
+	// +optional
+	ID int `json:"id,omitempty"`
+
+	// Version of registered schema in subject
+	// +optional
+	Version int `json:"version,omitempty"`
+
+	// SchemaType is format of schema registered in Schema Registry
+	// +optional
+	SchemaType string `json:"schemaType,omitempty"`
+
+	// Fingerprint is SHA-256 of canonical form of registered schema
+	// +optional
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// CompatibilityLevel is effective compatibility level of subject
+	// +optional
+	CompatibilityLevel string `json:"compatibilityLevel,omitempty"`
+
+	// Mode is effective mode of subject
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// SchemaChanges are structural changes of registered version from previous one,
+	// they are known for Avro and JSON schemas only
+	// +optional
+	SchemaChanges []string `json:"schemaChanges,omitempty"`
+
+	// References are resolved versions of references schema was registered with
+	// +optional
+	References []ResolvedReference `json:"references,omitempty"`
+
+	// ReferencedBy are subjects and versions referencing this schema, which prevent its deletion
+	// +optional
+	ReferencedBy []string `json:"referencedBy,omitempty"`
+
+	// PrunedVersions are versions soft deleted by last pruning of subject by version retention
+	// +optional
+	PrunedVersions []int `json:"prunedVersions,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Subject",type=string,JSONPath=`.status.subject`
+// +kubebuilder:printcolumn:name="ID",type=integer,JSONPath=`.status.id`
+// +kubebuilder:printcolumn:name="Version",type=integer,JSONPath=`.status.version`
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.status.schemaType`
+// +kubebuilder:printcolumn:name="Compatibility",type=string,JSONPath=`.status.compatibilityLevel`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.status.mode`,priority=1
+// +kubebuilder:printcolumn:name="Fingerprint",type=string,JSONPath=`.status.fingerprint`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // KafkaSchema is the Schema for the kafkaschemas API
 type KafkaSchema struct {
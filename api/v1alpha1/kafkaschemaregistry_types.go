@@ -0,0 +1,96 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KafkaSchemaRegistrySpec defines the desired global config of Schema Registry
+type KafkaSchemaRegistrySpec struct {
+	// URL of Schema Registry, registry operator is configured with is used if not set.
+	// KafkaSchemas are registered in it when they reference this object.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url,omitempty"`
+
+	// CredentialsSecretRef is Secret with username and password keys for basic auth or token key with bearer token,
+	// optional ca.crt key with CA certificates of Schema Registry and tls.crt and tls.key keys with client certificate.
+	// It is used only with URL.
+	// +optional
+	CredentialsSecretRef *corev1.SecretReference `json:"credentialsSecretRef,omitempty"`
+
+	// Mode is global mode of Schema Registry, i.e. READONLY during freeze or IMPORT during migration.
+	// Mode is left as is if not set.
+	// +optional
+	// +kubebuilder:validation:Enum=READWRITE;READONLY;READONLY_OVERRIDE;IMPORT
+	Mode string `json:"mode,omitempty"`
+
+	// Compatibility is global compatibility level of Schema Registry, used by subjects without their own.
+	// Level is left as is if not set.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(backward|backward_transitive|forward|forward_transitive|full|full_transitive|none|BACKWARD|BACKWARD_TRANSITIVE|FORWARD|FORWARD_TRANSITIVE|FULL|FULL_TRANSITIVE|NONE)$`
+	Compatibility string `json:"compatibility,omitempty"`
+}
+
+// KafkaSchemaRegistryStatus defines the observed global config of Schema Registry
+type KafkaSchemaRegistryStatus struct {
+	// Conditions store the status conditions of the KafkaSchemaRegistry instances
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// Mode is global mode of Schema Registry
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// CompatibilityLevel is global compatibility level of Schema Registry
+	// +optional
+	CompatibilityLevel string `json:"compatibilityLevel,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="URL",type=string,JSONPath=`.spec.url`
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.status.mode`
+// +kubebuilder:printcolumn:name="Compatibility",type=string,JSONPath=`.status.compatibilityLevel`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// KafkaSchemaRegistry is global config of Schema Registry operator is connected to, or of other registry
+// KafkaSchemas reference. There should be only one of them per registry, otherwise they would override each other.
+type KafkaSchemaRegistry struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KafkaSchemaRegistrySpec   `json:"spec,omitempty"`
+	Status KafkaSchemaRegistryStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KafkaSchemaRegistryList contains a list of KafkaSchemaRegistry
+type KafkaSchemaRegistryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KafkaSchemaRegistry `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KafkaSchemaRegistry{}, &KafkaSchemaRegistryList{})
+}
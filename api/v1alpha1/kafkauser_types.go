@@ -0,0 +1,147 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KafkaUserSpec defines the desired state of KafkaUser, it is SCRAM credential of user with its ACL rules
+type KafkaUserSpec struct {
+	// Username of user in Kafka cluster, <namespace>.<name> of KafkaUser if not set.
+	// It must be prefixed by <namespace>. unless operator allows any username.
+	// Credential of user which already exists isn't taken over.
+	// +optional
+	// +kubebuilder:validation:MaxLength=255
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9._-]+$`
+	Username string `json:"username,omitempty"`
+
+	// Mechanism is SCRAM mechanism of user credential
+	// +optional
+	// +kubebuilder:validation:Enum=SCRAM-SHA-256;SCRAM-SHA-512
+	// +kubebuilder:default=SCRAM-SHA-512
+	Mechanism string `json:"mechanism,omitempty"`
+
+	// Iterations is number of SCRAM iterations of user credential
+	// +optional
+	// +kubebuilder:validation:Minimum=4096
+	// +kubebuilder:validation:Maximum=16384
+	// +kubebuilder:default=8192
+	Iterations int32 `json:"iterations,omitempty"`
+
+	// SecretName is name of Secret user credential and connection info are written into,
+	// name of KafkaUser if not set
+	// +optional
+	// +kubebuilder:validation:MaxLength=253
+	SecretName string `json:"secretName,omitempty"`
+
+	// ACLs are ACL rules of user, their principal is User:<username>
+	// +optional
+	ACLs []KafkaUserACL `json:"acls,omitempty"`
+}
+
+// KafkaUserACL is ACL rule of KafkaUser, it is ACL binding for each of operations
+type KafkaUserACL struct {
+	// Host user connects from, any host if not set
+	// +optional
+	// +kubebuilder:default="*"
+	Host string `json:"host,omitempty"`
+
+	// ResourceType is type of resource bindings are for
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=TOPIC;GROUP;TRANSACTIONAL_ID;CLUSTER
+	ResourceType string `json:"resourceType"`
+
+	// ResourceName is name or prefix of resources, * is any resource. It is required unless resource is CLUSTER.
+	// +optional
+	// +kubebuilder:validation:MaxLength=255
+	ResourceName string `json:"resourceName,omitempty"`
+
+	// PatternType is how ResourceName matches resources
+	// +optional
+	// +kubebuilder:validation:Enum=LITERAL;PREFIXED
+	// +kubebuilder:default=LITERAL
+	PatternType string `json:"patternType,omitempty"`
+
+	// Operations user is allowed or denied
+	// +required
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Enum=ALL;READ;WRITE;CREATE;DELETE;ALTER;DESCRIBE;CLUSTER_ACTION;DESCRIBE_CONFIGS;ALTER_CONFIGS;IDEMPOTENT_WRITE
+	Operations []string `json:"operations"`
+
+	// Permission is whether operations are allowed or denied
+	// +optional
+	// +kubebuilder:validation:Enum=ALLOW;DENY
+	// +kubebuilder:default=ALLOW
+	Permission string `json:"permission,omitempty"`
+}
+
+// KafkaUserStatus defines the observed state of KafkaUser
+type KafkaUserStatus struct {
+	// Conditions store the status conditions of the KafkaUser instances
+	// +operator-sdk:csv:customresourcedefinitions:type=status
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+
+	// Username is user SCRAM credential was created for
+	// +optional
+	Username string `json:"username,omitempty"`
+
+	// Mechanism is SCRAM mechanism of credential which was created
+	// +optional
+	Mechanism string `json:"mechanism,omitempty"`
+
+	// SecretName is name of Secret credential was written into
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// Bindings are ACL bindings KafkaUser created and owns, bindings which already existed aren't owned.
+	// They are deleted when they aren't in spec anymore or KafkaUser is deleted
+	// +optional
+	Bindings []ACLBinding `json:"bindings,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Username",type=string,JSONPath=`.status.username`
+// +kubebuilder:printcolumn:name="Mechanism",type=string,JSONPath=`.spec.mechanism`
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.status.secretName`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// KafkaUser is the Schema for the kafkausers API
+type KafkaUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KafkaUserSpec   `json:"spec,omitempty"`
+	Status KafkaUserStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KafkaUserList contains a list of KafkaUser
+type KafkaUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KafkaUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KafkaUser{}, &KafkaUserList{})
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// schema-migrate copies subjects, versions, IDs and configs from source Schema Registry to target one
+// and verifies that target has them afterwards. Passwords are read from SOURCE_SCHEMA_REGISTRY_PASSWORD
+// and TARGET_SCHEMA_REGISTRY_PASSWORD environment variables.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+)
+
+func main() {
+	err := run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "schema-migrate: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var sourceURL, sourceUsername, targetURL, targetUsername, subjects string
+	var force, verifyOnly bool
+	flag.StringVar(&sourceURL, "source-url", os.Getenv("SOURCE_SCHEMA_REGISTRY_URL"), "URL of Schema Registry schemas are copied from.")
+	flag.StringVar(&sourceUsername, "source-username", os.Getenv("SOURCE_SCHEMA_REGISTRY_USERNAME"), "Username of source Schema Registry.")
+	flag.StringVar(&targetURL, "target-url", os.Getenv("TARGET_SCHEMA_REGISTRY_URL"), "URL of Schema Registry schemas are copied to.")
+	flag.StringVar(&targetUsername, "target-username", os.Getenv("TARGET_SCHEMA_REGISTRY_USERNAME"), "Username of target Schema Registry.")
+	flag.StringVar(&subjects, "subjects", "", "Regular expression selecting subjects to copy, all subjects are copied if not set.")
+	flag.BoolVar(&force, "force", false, "Switch target Schema Registry to IMPORT mode even if it has subjects already.")
+	flag.BoolVar(&verifyOnly, "verify-only", false, "Only compare source and target Schema Registry, without copying.")
+	flag.Parse()
+
+	if len(sourceURL) == 0 || len(targetURL) == 0 {
+		return fmt.Errorf("both -source-url and -target-url must be set")
+	}
+	source, err := newClient(sourceURL, sourceUsername, os.Getenv("SOURCE_SCHEMA_REGISTRY_PASSWORD"))
+	if err != nil {
+		return err
+	}
+	target, err := newClient(targetURL, targetUsername, os.Getenv("TARGET_SCHEMA_REGISTRY_PASSWORD"))
+	if err != nil {
+		return err
+	}
+	migration := &schemaregistry.Migration{Source: source, Target: target, Force: force}
+	if len(subjects) != 0 {
+		migration.Subjects, err = regexp.Compile(subjects)
+		if err != nil {
+			return fmt.Errorf("invalid -subjects: %w", err)
+		}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if !verifyOnly {
+		result, rErr := migration.Run(ctx)
+		if result != nil {
+			for _, version := range result.Imported {
+				fmt.Printf("imported %s\n", version)
+			}
+			for _, config := range result.Configs {
+				fmt.Printf("configured %s\n", config)
+			}
+			fmt.Printf("%d versions imported, %d versions were in target already, %d configs changed\n",
+				len(result.Imported), len(result.Skipped), len(result.Configs))
+		}
+		if rErr != nil {
+			return rErr
+		}
+	}
+
+	mismatches, err := migration.Verify(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mismatch := range mismatches {
+		fmt.Printf("mismatch %s\n", mismatch)
+	}
+	if len(mismatches) != 0 {
+		return fmt.Errorf("target differs from source in %d places", len(mismatches))
+	}
+	fmt.Println("verified, target has all subjects of source")
+	return nil
+}
+
+// newClient would make client of Schema Registry, with basic auth if username is set
+func newClient(url, username, password string) (*schemaregistry.Client, error) {
+	options := []schemaregistry.Option{schemaregistry.URL(url)}
+	if len(username) != 0 {
+		options = append(options, schemaregistry.BasicAuth(username, password))
+	}
+	return schemaregistry.NewClient(options...)
+}
@@ -1,18 +1,53 @@
 package controllers
 
 import (
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/90poe/kafkaobjects-operator/internal/reporter"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
 )
 
 const (
-	ConditionsInsert            = "Insert"
-	ConditionsUpdate            = "Update"
-	ConditionReasonCreateTopic  = "CreateTopic"
-	ConditionReasonUpdateTopic  = "UpdateTopic"
-	ConditionReasonCreateSchema = "CreateSchema"
-	ConditionReasonUpdateSchema = "UpdateSchema"
+	ConditionsInsert             = "Insert"
+	ConditionsUpdate             = "Update"
+	ConditionReady               = "Ready"
+	ConditionReasonCreateTopic   = "CreateTopic"
+	ConditionReasonUpdateTopic   = "UpdateTopic"
+	ConditionReasonCreateSchema  = "CreateSchema"
+	ConditionReasonUpdateSchema  = "UpdateSchema"
+	ConditionReasonInvalidSpec   = "InvalidSpec"
+	ConditionCompatible          = "Compatible"
+	ConditionReasonCheckCompat   = "CheckCompatibility"
+	ConditionReasonDeleteSchema  = "DeleteSchema"
+	ConditionReasonRegistryMode  = "RegistryMode"
+	ConditionReasonPruneVersions = "PruneVersions"
+	ConditionReasonImportSchema  = "ImportSchema"
+	ConditionReasonUpdateACL     = "UpdateACL"
+	ConditionReasonDeleteACL     = "DeleteACL"
+	ConditionReasonUpdateUser    = "UpdateUser"
+	ConditionReasonDeleteUser    = "DeleteUser"
+	// ConditionReasonUpdateConfig is reason of KafkaSchemaRegistry conditions
+	ConditionReasonUpdateConfig = "UpdateRegistryConfig"
 	RevisitIntervalSec          = 36000 // 10 hours
+	KindKafkaTopic              = "KafkaTopic"
+	KindKafkaSchema             = "KafkaSchema"
+	KindKafkaSchemaRegistry     = "KafkaSchemaRegistry"
+	KindKafkaACL                = "KafkaACL"
+	KindKafkaUser               = "KafkaUser"
+	MaxConditionMessageLength   = 32768
+	// MaxSchemaChanges is how many structural changes of schema are kept in status
+	MaxSchemaChanges = 50
+	// SchemaFinalizer would keep KafkaSchema until its subject is deleted
+	SchemaFinalizer = "xo.90poe.io/schema-subject"
+	// ACLFinalizer would keep KafkaACL until its ACL bindings are deleted
+	ACLFinalizer = "xo.90poe.io/kafka-acl"
+	// UserFinalizer would keep KafkaUser until its SCRAM credential and ACL bindings are deleted
+	UserFinalizer = "xo.90poe.io/kafka-user"
 )
 
 // ignoreUpdateDeletePredicater is brilliantly useful function, it will prevent multiple reconcile calls
@@ -21,7 +56,9 @@ func ignoreUpdateDeletePredicate() predicate.Predicate {
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			// Ignore updates to CR status in which case metadata.Generation does not change
 			genChanged := e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
-			return genChanged
+			// Deletion of CR with finalizer must be handled
+			deleted := e.ObjectOld.GetDeletionTimestamp().IsZero() && !e.ObjectNew.GetDeletionTimestamp().IsZero()
+			return genChanged || deleted
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			// Evaluates to false if the object has been confirmed deleted.
@@ -29,3 +66,68 @@ func ignoreUpdateDeletePredicate() predicate.Predicate {
 		},
 	}
 }
+
+// configDiff would return changes needed to move current configs to desired ones,
+// only keys of desired configs are compared
+func configDiff(current, desired map[string]string) []reporter.Change {
+	changes := []reporter.Change{}
+	for name, value := range desired {
+		if current[name] != value {
+			changes = append(changes, reporter.Change{
+				Field: name,
+				Old:   current[name],
+				New:   value,
+			})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// observedGeneration would return last generation of object we have reconciled
+func observedGeneration(conditions []metav1.Condition) int64 {
+	observed := int64(0)
+	for _, condition := range conditions {
+		if condition.ObservedGeneration > observed {
+			observed = condition.ObservedGeneration
+		}
+	}
+	return observed
+}
+
+// resultMessageType would return type of Slack message about result of reconcile.
+// Changes we had to make while spec of object wasn't changed are drift and are reported as warnings.
+func resultMessageType(status metav1.ConditionStatus, drifted bool) reporter.MessageType {
+	switch {
+	case status == metav1.ConditionFalse:
+		return reporter.ErrorMessage
+	case drifted:
+		return reporter.WarnMessage
+	}
+	return reporter.OKMessage
+}
+
+// compatibilityCondition would make Compatible condition from Schema Registry verdict
+func compatibilityCondition(compat *schemaregistry.Compatibility) *metav1.Condition {
+	condition := &metav1.Condition{
+		Type:    ConditionCompatible,
+		Status:  metav1.ConditionTrue,
+		Reason:  ConditionReasonCheckCompat,
+		Message: "compatible with latest version",
+	}
+	if compat.IsCompatible {
+		return condition
+	}
+	condition.Status = metav1.ConditionFalse
+	condition.Message = "incompatible with latest version"
+	if len(compat.Messages) != 0 {
+		condition.Message = strings.Join(compat.Messages, "; ")
+	}
+	// condition message is limited by API
+	if len(condition.Message) > MaxConditionMessageLength {
+		condition.Message = condition.Message[:MaxConditionMessageLength-3] + "..."
+	}
+	return condition
+}
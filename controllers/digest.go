@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/cron"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+	"github.com/90poe/kafkaobjects-operator/internal/kafka"
+	"github.com/90poe/kafkaobjects-operator/internal/reporter"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+)
+
+const (
+	DigestTitle = ":bar_chart: Inventory digest"
+	// DigestMaxListItems would keep digest readable on big clusters
+	DigestMaxListItems = 25
+)
+
+type (
+	// DigestReporter sends scheduled inventory digest of managed objects through Messenger
+	DigestReporter struct {
+		client.Client
+		KafkaClientConfig         *kafka.ClusterConfig
+		KafkaSchemaRegistryClient schemaregistry.Backend
+		Messenger                 *reporter.Messenger
+		schedule                  *cron.Schedule
+		labelSelector             labels.Selector
+	}
+	// digest is inventory of managed objects
+	digest struct {
+		// topics and schemas per namespace
+		topics  map[string]int
+		schemas map[string]int
+		// notReady are objects which last reconcile failed
+		notReady []string
+		// drifted are topics which configs differ from spec
+		drifted []string
+		// unmanagedTopics are topics in Kafka cluster without KafkaTopic
+		unmanagedTopics []string
+		// unmanagedSubjects are subjects in Schema Registry without KafkaSchema
+		unmanagedSubjects []string
+		// errors we had collecting digest
+		errors []string
+	}
+)
+
+// Digest is sent only by leader
+var _ manager.LeaderElectionRunnable = &DigestReporter{}
+
+// SetupWithManager adds DigestReporter to the Manager, if digest schedule is configured.
+func (d *DigestReporter) SetupWithManager(mgr ctrl.Manager) error {
+	// init config
+	config, err := env.NewConfig()
+	if err != nil {
+		return err
+	}
+	if len(config.DigestSchedule) == 0 {
+		// digest is disabled
+		return nil
+	}
+	d.schedule, err = cron.Parse(config.DigestSchedule, time.Local)
+	if err != nil {
+		return err
+	}
+	d.labelSelector, err = metav1.LabelSelectorAsSelector(config.LabelSelectors)
+	if err != nil {
+		return err
+	}
+	d.KafkaClientConfig, err = kafka.NewClusterConfig(
+		config.KafkaTopicNameRegexp,
+		kafka.Brokers(config.KafkaBrokers),
+		kafka.MaxPartsPerTopic(config.MaxKafkaTopicsPartitions),
+	)
+	if err != nil {
+		return err
+	}
+	d.KafkaSchemaRegistryClient, err = schemaregistry.NewBackend(config)
+	if err != nil {
+		return err
+	}
+	if d.Messenger == nil {
+		return fmt.Errorf("reporter Messenger must be provided")
+	}
+	return mgr.Add(d)
+}
+
+// NeedLeaderElection is true, only leader replica sends digest
+func (d *DigestReporter) NeedLeaderElection() bool {
+	return true
+}
+
+// Start would send digest on schedule until context is cancelled
+func (d *DigestReporter) Start(ctx context.Context) error {
+	reqLogger := log.FromContext(ctx).WithValues("digest", d.schedule.String())
+	for {
+		next := d.schedule.Next(time.Now())
+		if next.IsZero() {
+			reqLogger.Info("digest schedule would never fire, digest is disabled")
+			<-ctx.Done()
+			return nil
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+			reqLogger.Info("sending inventory digest")
+			d.Messenger.Send(d.collect(ctx).String(), reporter.OKMessage,
+				reporter.Report(DigestTitle))
+		}
+	}
+}
+
+// collect would make digest of managed objects
+func (d *DigestReporter) collect(ctx context.Context) *digest {
+	dg := &digest{
+		topics:  make(map[string]int),
+		schemas: make(map[string]int),
+	}
+	selector := client.MatchingLabelsSelector{Selector: d.labelSelector}
+
+	// Kubernetes objects
+	topics := &xov1alpha1.KafkaTopicList{}
+	if err := d.List(ctx, topics, selector); err != nil {
+		dg.errors = append(dg.errors, fmt.Sprintf("can't list KafkaTopics: %v", err))
+	}
+	schemas := &xov1alpha1.KafkaSchemaList{}
+	if err := d.List(ctx, schemas, selector); err != nil {
+		dg.errors = append(dg.errors, fmt.Sprintf("can't list KafkaSchemas: %v", err))
+	}
+	managedTopics := make(map[string]*xov1alpha1.KafkaTopic, len(topics.Items))
+	for i := range topics.Items {
+		topic := &topics.Items[i]
+		dg.topics[topic.Namespace]++
+		managedTopics[topic.Spec.Name] = topic
+		if !meta.IsStatusConditionTrue(topic.Status.Conditions, ConditionReady) {
+			dg.notReady = append(dg.notReady, notReadyLine(KindKafkaTopic, topic.Namespace, topic.Name, topic.Status.Conditions))
+		}
+	}
+	managedSubjects := make(map[string]bool, len(schemas.Items))
+	for i := range schemas.Items {
+		schema := &schemas.Items[i]
+		dg.schemas[schema.Namespace]++
+		// only subjects of registry operator is configured with are listed
+		if schema.Spec.RegistryRef == nil {
+			managedSubjects[schemaSubject(schema)] = true
+		}
+		if !meta.IsStatusConditionTrue(schema.Status.Conditions, ConditionReady) {
+			dg.notReady = append(dg.notReady, notReadyLine(KindKafkaSchema, schema.Namespace, schema.Name, schema.Status.Conditions))
+		}
+	}
+
+	// Kafka cluster
+	d.collectTopics(dg, managedTopics)
+
+	// Schema Registry
+	subjects, err := d.KafkaSchemaRegistryClient.Subjects(ctx)
+	if err != nil {
+		dg.errors = append(dg.errors, fmt.Sprintf("can't list Schema Registry subjects: %v", err))
+	}
+	for _, subject := range subjects {
+		if !managedSubjects[subject] {
+			dg.unmanagedSubjects = append(dg.unmanagedSubjects, subject)
+		}
+	}
+	return dg
+}
+
+// collectTopics would find drifted and unmanaged topics in Kafka cluster
+func (d *DigestReporter) collectTopics(dg *digest, managedTopics map[string]*xov1alpha1.KafkaTopic) {
+	kClient, err := d.KafkaClientConfig.GetClient()
+	if err != nil {
+		dg.errors = append(dg.errors, fmt.Sprintf("can't get Kafka client: %v", err))
+		return
+	}
+	defer kClient.Close()
+	clusterTopics, err := kClient.Topics()
+	if err != nil {
+		dg.errors = append(dg.errors, fmt.Sprintf("can't list Kafka topics: %v", err))
+		return
+	}
+	existing := []string{}
+	for _, name := range clusterTopics {
+		if _, ok := managedTopics[name]; ok {
+			existing = append(existing, name)
+			continue
+		}
+		// topics starting with underscore belong to Kafka ecosystem, i.e. _schemas
+		if !strings.HasPrefix(name, "_") {
+			dg.unmanagedTopics = append(dg.unmanagedTopics, name)
+		}
+	}
+	if len(existing) == 0 {
+		return
+	}
+	configs, err := kClient.TopicsConfigs(existing...)
+	if err != nil {
+		dg.errors = append(dg.errors, fmt.Sprintf("can't get Kafka topics configs: %v", err))
+		return
+	}
+	for _, name := range existing {
+		topic := managedTopics[name]
+		changes := configDiff(configs[name], kafka.DesiredConfigs(&topic.Spec))
+		if len(changes) == 0 {
+			continue
+		}
+		fields := make([]string, 0, len(changes))
+		for _, change := range changes {
+			fields = append(fields, change.Field)
+		}
+		dg.drifted = append(dg.drifted, fmt.Sprintf("%s/%s (`%s`): %s",
+			topic.Namespace, topic.Name, name, strings.Join(fields, ", ")))
+	}
+}
+
+// notReadyLine would describe object which is not Ready
+func notReadyLine(kind, namespace, name string, conditions []metav1.Condition) string {
+	line := fmt.Sprintf("%s %s/%s", kind, namespace, name)
+	ready := meta.FindStatusCondition(conditions, ConditionReady)
+	if ready == nil {
+		return line + ": never reconciled"
+	}
+	return fmt.Sprintf("%s: %s since %s", line, ready.Message,
+		ready.LastTransitionTime.Format("2006-01-02 15:04"))
+}
+
+// String would format digest as Slack mrkdwn
+func (dg *digest) String() string {
+	lines := []string{"*Managed objects per namespace*"}
+	namespaces := make(map[string]bool)
+	for ns := range dg.topics {
+		namespaces[ns] = true
+	}
+	for ns := range dg.schemas {
+		namespaces[ns] = true
+	}
+	nsList := make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		nsList = append(nsList, ns)
+	}
+	sort.Strings(nsList)
+	for _, ns := range nsList {
+		lines = append(lines, fmt.Sprintf("• %s: %d topic(s), %d schema(s)", ns, dg.topics[ns], dg.schemas[ns]))
+	}
+	if len(nsList) == 0 {
+		lines = append(lines, "• none")
+	}
+	lines = append(lines, digestList("Not Ready objects", dg.notReady)...)
+	lines = append(lines, digestList("Drifted topics", dg.drifted)...)
+	lines = append(lines, digestList("Topics without KafkaTopic", dg.unmanagedTopics)...)
+	lines = append(lines, digestList("Subjects without KafkaSchema", dg.unmanagedSubjects)...)
+	if len(dg.errors) != 0 {
+		lines = append(lines, digestList(":warning: Digest is incomplete", dg.errors)...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// digestList would format titled list of digest, limited to DigestMaxListItems
+func digestList(title string, items []string) []string {
+	sort.Strings(items)
+	lines := []string{"", fmt.Sprintf("*%s: %d*", title, len(items))}
+	for i, item := range items {
+		if i == DigestMaxListItems {
+			lines = append(lines, fmt.Sprintf("• ... and %d more", len(items)-DigestMaxListItems))
+			break
+		}
+		lines = append(lines, "• "+item)
+	}
+	return lines
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+	"github.com/90poe/kafkaobjects-operator/internal/kafka"
+	"github.com/90poe/kafkaobjects-operator/internal/reporter"
+	"github.com/go-logr/logr"
+)
+
+// KafkaACLReconciler reconciles a KafkaACL object
+type KafkaACLReconciler struct {
+	client.Client
+	Scheme            *runtime.Scheme
+	KafkaClientConfig *kafka.ClusterConfig
+	Messenger         *reporter.Messenger
+	Auditor           *audit.Auditor
+}
+
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaacls,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaacls/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaacls/finalizers,verbs=update
+
+// Reconcile would create ACL bindings of KafkaACL missing in Kafka cluster and delete bindings it owns,
+// which aren't in its spec anymore. Bindings KafkaACL owns are deleted together with it.
+func (r *KafkaACLReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx).WithValues("kafkaacl", req.NamespacedName)
+
+	// Fetch the KafkaACL instance
+	instance := &xov1alpha1.KafkaACL{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Return and don't requeue
+			reqLogger.Info("KafkaACL resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		reqLogger.Error(err, "Failed to get KafkaACL.")
+		return ctrl.Result{}, err
+	}
+
+	// Get Kafka client
+	kClient, err := r.KafkaClientConfig.GetClient()
+	if err != nil {
+		reqLogger.Info(fmt.Sprintf("Failed to get Kafka Client: %v", err))
+		r.Messenger.Send(fmt.Sprintf("%v", err), reporter.ErrorMessage,
+			reporter.Object(KindKafkaACL, instance.Namespace, instance.Name))
+		return ctrl.Result{}, nil
+	}
+	defer kClient.Close()
+
+	// changes made to Kafka would be audited as made by this object
+	ctx = audit.WithSource(ctx, audit.SourceFromObject(KindKafkaACL, instance))
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.deleteACL(ctx, kClient, instance, reqLogger)
+	}
+	if !controllerutil.ContainsFinalizer(instance, ACLFinalizer) {
+		controllerutil.AddFinalizer(instance, ACLFinalizer)
+		err = r.Update(ctx, instance)
+		if err != nil {
+			reqLogger.Error(err, "Failed to update KafkaACL finalizers.")
+			return ctrl.Result{}, err
+		}
+	}
+	return r.upsertACL(ctx, kClient, instance, reqLogger)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KafkaACLReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// init config
+	config, err := env.NewConfig()
+	if err != nil {
+		return err
+	}
+	// make label selector
+	labelSelectorPredicate, err := predicate.LabelSelectorPredicate(*config.LabelSelectors)
+	if err != nil {
+		return err
+	}
+	// init kafka client
+	r.KafkaClientConfig, err = kafka.NewClusterConfig(
+		config.KafkaTopicNameRegexp,
+		kafka.Brokers(config.KafkaBrokers),
+		kafka.MaxPartsPerTopic(config.MaxKafkaTopicsPartitions),
+		kafka.Auditor(r.Auditor),
+	)
+	if err != nil {
+		return err
+	}
+	// Messenger is shared between reconcilers and is run by manager
+	if r.Messenger == nil {
+		return fmt.Errorf("reporter Messenger must be provided")
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&xov1alpha1.KafkaACL{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles}).
+		WithEventFilter(labelSelectorPredicate).
+		WithEventFilter(ignoreUpdateDeletePredicate()).
+		Complete(r)
+}
+
+// upsertACL would create missing ACL bindings of KafkaACL and delete stale ones it owns
+func (r *KafkaACLReconciler) upsertACL(ctx context.Context, kClient *kafka.ClusterClient, acl *xov1alpha1.KafkaACL, reqLogger logr.Logger) (_ ctrl.Result, retErr error) {
+	// Init status
+	statusMessage := "Succeeded"
+	status := metav1.ConditionTrue
+	reason := ConditionReasonUpdateACL
+	changes := []reporter.Change{}
+	// spec wasn't changed since last reconcile
+	specObserved := observedGeneration(acl.Status.Conditions) == acl.Generation
+	notification := fmt.Sprintf("ACLs of %s are in sync", acl.Spec.Principal)
+
+	// Defer function to update status
+	defer func() {
+		// Log status update
+		reqLogger.Info(fmt.Sprintf("ACLs of %s %s status: %s", acl.Spec.Principal, reason, statusMessage))
+		// Send message to slack, Messenger would filter it by notification level
+		if status == metav1.ConditionFalse {
+			notification = statusMessage
+		}
+		r.Messenger.Send(notification,
+			resultMessageType(status, specObserved && len(changes) != 0),
+			reporter.Object(KindKafkaACL, acl.Namespace, acl.Name),
+			reporter.Reason(reason),
+			reporter.Diff(changes...))
+		meta.SetStatusCondition(&acl.Status.Conditions, metav1.Condition{
+			Type:               ConditionReady,
+			Status:             status,
+			Reason:             reason,
+			Message:            statusMessage,
+			ObservedGeneration: acl.Generation,
+		})
+		// we will return error of status update if it is not nil
+		err := r.Status().Update(ctx, acl)
+		if err != nil {
+			reqLogger.Info(fmt.Sprintf("Failed to update ACL status: %v", err))
+			retErr = errors.Join(retErr, err)
+		}
+	}()
+
+	desired, err := kafka.DesiredACLs(&acl.Spec)
+	if err != nil {
+		reason = ConditionReasonInvalidSpec
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("invalid kafka ACL %s: %v", acl.Name, err)
+		return ctrl.Result{}, nil
+	}
+	aclChanges, err := reconcileACLs(ctx, kClient, desired, &acl.Status.Bindings)
+	changes = append(changes, aclChanges...)
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't reconcile ACLs of kafka ACL %s: %v", acl.Name, err)
+		return ctrl.Result{}, nil
+	}
+
+	if len(changes) != 0 {
+		notification = fmt.Sprintf("ACLs of %s were changed", acl.Spec.Principal)
+		if specObserved {
+			notification = fmt.Sprintf("ACLs of %s drifted from spec and were restored", acl.Spec.Principal)
+		}
+	}
+	return ctrl.Result{
+		RequeueAfter: RevisitIntervalSec * time.Second,
+	}, nil
+}
+
+// deleteACL would delete ACL bindings KafkaACL owns and remove finalizer
+func (r *KafkaACLReconciler) deleteACL(ctx context.Context, kClient *kafka.ClusterClient, acl *xov1alpha1.KafkaACL, reqLogger logr.Logger) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(acl, ACLFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	fields := []reporter.MessageField{
+		reporter.Object(KindKafkaACL, acl.Namespace, acl.Name),
+		reporter.Reason(ConditionReasonDeleteACL),
+	}
+	err := kClient.DeleteACLs(ctx, acl.Status.Bindings)
+	if err != nil {
+		r.Messenger.Send(err.Error(), reporter.ErrorMessage, fields...)
+		return ctrl.Result{}, err
+	}
+	if len(acl.Status.Bindings) != 0 {
+		changes := make([]reporter.Change, 0, len(acl.Status.Bindings))
+		for _, binding := range acl.Status.Bindings {
+			changes = append(changes, reporter.Change{Field: "acl", Old: kafka.FormatACL(binding)})
+		}
+		notification := fmt.Sprintf("ACLs of %s were deleted", acl.Spec.Principal)
+		reqLogger.Info(notification)
+		r.Messenger.Send(notification, reporter.OKMessage, append(fields, reporter.Diff(changes...))...)
+	}
+	controllerutil.RemoveFinalizer(acl, ACLFinalizer)
+	return ctrl.Result{}, r.Update(ctx, acl)
+}
+
+// aclClient is part of Kafka cluster client managing ACL bindings
+type aclClient interface {
+	ACLs(ctx context.Context, bindings []xov1alpha1.ACLBinding) ([]xov1alpha1.ACLBinding, error)
+	CreateACLs(ctx context.Context, bindings []xov1alpha1.ACLBinding) error
+	DeleteACLs(ctx context.Context, bindings []xov1alpha1.ACLBinding) error
+}
+
+// reconcileACLs would create desired ACL bindings missing in Kafka cluster and delete owned ones which aren't desired.
+// Only bindings created here are owned, bindings which already existed could be made by hand or belong to other objects.
+// Bindings are owned once they are created, so they are deleted even if creation of others failed.
+func reconcileACLs(ctx context.Context, kClient aclClient, desired []xov1alpha1.ACLBinding, owned *[]xov1alpha1.ACLBinding) ([]reporter.Change, error) {
+	existing, err := kClient.ACLs(ctx, desired)
+	if err != nil {
+		return nil, fmt.Errorf("can't get ACLs: %w", err)
+	}
+	missing := subtractACLs(desired, existing)
+	stale := subtractACLs(*owned, desired)
+	changes := []reporter.Change{}
+
+	err = kClient.CreateACLs(ctx, missing)
+	*owned = append(subtractACLs(*owned, missing), missing...)
+	if err != nil {
+		return changes, fmt.Errorf("can't create ACLs: %w", err)
+	}
+	for _, binding := range missing {
+		changes = append(changes, reporter.Change{Field: "acl", New: kafka.FormatACL(binding)})
+	}
+	err = kClient.DeleteACLs(ctx, stale)
+	if err != nil {
+		return changes, fmt.Errorf("can't delete stale ACLs: %w", err)
+	}
+	for _, binding := range stale {
+		changes = append(changes, reporter.Change{Field: "acl", Old: kafka.FormatACL(binding)})
+	}
+	// owned are desired bindings which were created now or before, in order of desired
+	*owned = subtractACLs(desired, subtractACLs(desired, *owned))
+	return changes, nil
+}
+
+// subtractACLs would return ACL bindings of a which aren't in b
+func subtractACLs(a, b []xov1alpha1.ACLBinding) []xov1alpha1.ACLBinding {
+	in := make(map[xov1alpha1.ACLBinding]bool, len(b))
+	for _, binding := range b {
+		in[binding] = true
+	}
+	result := []xov1alpha1.ACLBinding{}
+	for _, binding := range a {
+		if !in[binding] {
+			result = append(result, binding)
+		}
+	}
+	return result
+}
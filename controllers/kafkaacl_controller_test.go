@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+// fakeCluster is Kafka cluster keeping ACL bindings and SCRAM credentials in memory
+type fakeCluster struct {
+	bindings  map[xov1alpha1.ACLBinding]bool
+	createErr error
+	// users are SCRAM credentials of users by their mechanisms
+	users map[string]map[string]fakeCredential
+	// upserts is how many times SCRAM credentials were upserted
+	upserts int
+}
+
+func newFakeCluster(bindings ...xov1alpha1.ACLBinding) *fakeCluster {
+	f := &fakeCluster{bindings: map[xov1alpha1.ACLBinding]bool{}, users: map[string]map[string]fakeCredential{}}
+	for _, binding := range bindings {
+		f.bindings[binding] = true
+	}
+	return f
+}
+
+func (f *fakeCluster) ACLs(_ context.Context, bindings []xov1alpha1.ACLBinding) ([]xov1alpha1.ACLBinding, error) {
+	existing := []xov1alpha1.ACLBinding{}
+	for _, binding := range bindings {
+		if f.bindings[binding] {
+			existing = append(existing, binding)
+		}
+	}
+	return existing, nil
+}
+
+func (f *fakeCluster) CreateACLs(_ context.Context, bindings []xov1alpha1.ACLBinding) error {
+	if f.createErr != nil && len(bindings) != 0 {
+		return f.createErr
+	}
+	for _, binding := range bindings {
+		f.bindings[binding] = true
+	}
+	return nil
+}
+
+func (f *fakeCluster) DeleteACLs(_ context.Context, bindings []xov1alpha1.ACLBinding) error {
+	for _, binding := range bindings {
+		delete(f.bindings, binding)
+	}
+	return nil
+}
+
+// list would return ACL bindings of cluster sorted by operation
+func (f *fakeCluster) list() []xov1alpha1.ACLBinding {
+	bindings := []xov1alpha1.ACLBinding{}
+	for binding := range f.bindings {
+		bindings = append(bindings, binding)
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Operation < bindings[j].Operation
+	})
+	return bindings
+}
+
+func testBinding(operation string) xov1alpha1.ACLBinding {
+	return xov1alpha1.ACLBinding{Principal: "User:orders", Host: "*", ResourceType: "TOPIC", ResourceName: "orders",
+		PatternType: "LITERAL", Operation: operation, Permission: "ALLOW"}
+}
+
+func TestSubtractACLs(t *testing.T) {
+	read, write, describe := testBinding("READ"), testBinding("WRITE"), testBinding("DESCRIBE")
+	tests := []struct {
+		name string
+		a, b []xov1alpha1.ACLBinding
+		want []xov1alpha1.ACLBinding
+	}{
+		{
+			name: "empty",
+			want: []xov1alpha1.ACLBinding{},
+		},
+		{
+			name: "nothing to subtract",
+			a:    []xov1alpha1.ACLBinding{read, write},
+			want: []xov1alpha1.ACLBinding{read, write},
+		},
+		{
+			name: "order of a is kept",
+			a:    []xov1alpha1.ACLBinding{write, describe, read},
+			b:    []xov1alpha1.ACLBinding{describe},
+			want: []xov1alpha1.ACLBinding{write, read},
+		},
+		{
+			name: "all subtracted",
+			a:    []xov1alpha1.ACLBinding{read},
+			b:    []xov1alpha1.ACLBinding{write, read},
+			want: []xov1alpha1.ACLBinding{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, subtractACLs(tt.a, tt.b))
+		})
+	}
+}
+
+func TestReconcileACLs(t *testing.T) {
+	read, write, describe := testBinding("READ"), testBinding("WRITE"), testBinding("DESCRIBE")
+	tests := []struct {
+		name        string
+		cluster     *fakeCluster
+		owned       []xov1alpha1.ACLBinding
+		desired     []xov1alpha1.ACLBinding
+		wantOwned   []xov1alpha1.ACLBinding
+		wantCluster []xov1alpha1.ACLBinding
+		wantChanges int
+		wantErr     string
+	}{
+		{
+			name:        "create missing",
+			cluster:     newFakeCluster(),
+			desired:     []xov1alpha1.ACLBinding{describe, read},
+			wantOwned:   []xov1alpha1.ACLBinding{describe, read},
+			wantCluster: []xov1alpha1.ACLBinding{describe, read},
+			wantChanges: 2,
+		},
+		{
+			name:        "existing binding isn't owned",
+			cluster:     newFakeCluster(read),
+			desired:     []xov1alpha1.ACLBinding{describe, read},
+			wantOwned:   []xov1alpha1.ACLBinding{describe},
+			wantCluster: []xov1alpha1.ACLBinding{describe, read},
+			wantChanges: 1,
+		},
+		{
+			name:        "binding which isn't owned isn't deleted",
+			cluster:     newFakeCluster(read),
+			wantOwned:   []xov1alpha1.ACLBinding{},
+			wantCluster: []xov1alpha1.ACLBinding{read},
+		},
+		{
+			name:        "stale owned binding is deleted",
+			cluster:     newFakeCluster(describe, read, write),
+			owned:       []xov1alpha1.ACLBinding{describe, write},
+			desired:     []xov1alpha1.ACLBinding{describe, read},
+			wantOwned:   []xov1alpha1.ACLBinding{describe},
+			wantCluster: []xov1alpha1.ACLBinding{describe, read},
+			wantChanges: 1,
+		},
+		{
+			name:        "owned binding deleted in cluster is restored",
+			cluster:     newFakeCluster(),
+			owned:       []xov1alpha1.ACLBinding{read},
+			desired:     []xov1alpha1.ACLBinding{read},
+			wantOwned:   []xov1alpha1.ACLBinding{read},
+			wantCluster: []xov1alpha1.ACLBinding{read},
+			wantChanges: 1,
+		},
+		{
+			name:        "failed bindings are owned",
+			cluster:     &fakeCluster{bindings: map[xov1alpha1.ACLBinding]bool{}, createErr: errors.New("broker is down")},
+			owned:       []xov1alpha1.ACLBinding{describe},
+			desired:     []xov1alpha1.ACLBinding{describe, read},
+			wantOwned:   []xov1alpha1.ACLBinding{describe, read},
+			wantCluster: []xov1alpha1.ACLBinding{},
+			wantErr:     "can't create ACLs: broker is down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owned := tt.owned
+			changes, err := reconcileACLs(context.Background(), tt.cluster, tt.desired, &owned)
+			if len(tt.wantErr) != 0 {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantOwned, owned)
+			assert.Equal(t, tt.wantCluster, tt.cluster.list())
+			assert.Len(t, changes, tt.wantChanges)
+		})
+	}
+}
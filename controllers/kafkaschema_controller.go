@@ -20,19 +20,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
 	"github.com/90poe/kafkaobjects-operator/internal/env"
 	"github.com/90poe/kafkaobjects-operator/internal/reporter"
 	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
@@ -43,13 +52,19 @@ import (
 type KafkaSchemaReconciler struct {
 	client.Client
 	Scheme                    *runtime.Scheme
-	KafkaSchemaRegistryClient *schemaregistry.Client
+	KafkaSchemaRegistryClient schemaregistry.Backend
 	Messenger                 *reporter.Messenger
+	// Registries makes clients of registries KafkaSchemas reference
+	Registries    *SchemaRegistries
+	labelSelector labels.Selector
+	// sourceReader reads ConfigMaps schemas are loaded from, they aren't cached
+	sourceReader client.Reader
 }
 
 //+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaschemas,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaschemas/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaschemas/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -79,6 +94,25 @@ func (r *KafkaSchemaReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// changes made to Schema Registry would be audited as made by this object
+	ctx = audit.WithSource(ctx, audit.SourceFromObject(KindKafkaSchema, instance))
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.deleteSchema(ctx, instance, reqLogger)
+	}
+	// Finalizer is needed only when subject is deleted together with KafkaSchema
+	retain := instance.Spec.DeletionPolicy == schemaregistry.DeletionPolicyRetain || len(instance.Spec.DeletionPolicy) == 0
+	if retain == controllerutil.ContainsFinalizer(instance, SchemaFinalizer) {
+		if retain {
+			controllerutil.RemoveFinalizer(instance, SchemaFinalizer)
+		} else {
+			controllerutil.AddFinalizer(instance, SchemaFinalizer)
+		}
+		err = r.Update(ctx, instance)
+		if err != nil {
+			reqLogger.Error(err, "Failed to update KafkaSchema finalizers.")
+			return ctrl.Result{}, err
+		}
+	}
 	return r.upsertSchema(ctx, instance, reqLogger)
 }
 
@@ -94,24 +128,53 @@ func (r *KafkaSchemaReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err != nil {
 		return err
 	}
-	r.KafkaSchemaRegistryClient, err = schemaregistry.NewClient(
-		schemaregistry.URL(config.SchemaRegistryURL),
-	)
+	r.labelSelector, err = metav1.LabelSelectorAsSelector(config.LabelSelectors)
 	if err != nil {
 		return err
 	}
-	// Make slack Messenger
-	r.Messenger, err = reporter.New(config.SlackToken,
-		reporter.SlackChannel(config.SlackChannel))
+	r.sourceReader = mgr.GetAPIReader()
+	// index schemas by schemas they reference, so we could find dependents
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &xov1alpha1.KafkaSchema{},
+		schemaRefIndexKey, indexSchemaRefs)
 	if err != nil {
 		return err
 	}
+	// index schemas by ConfigMaps they are loaded from, so we could reconcile them on change
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &xov1alpha1.KafkaSchema{},
+		schemaSourceIndexKey, indexSchemaSources)
+	if err != nil {
+		return err
+	}
+	// index schemas by topics they describe, so we could register them when topic is ready
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &xov1alpha1.KafkaSchema{},
+		topicRefIndexKey, indexTopicRefs)
+	if err != nil {
+		return err
+	}
+	// Schema Registries are shared between reconcilers
+	if r.Registries == nil {
+		return fmt.Errorf("schema registries must be provided")
+	}
+	r.KafkaSchemaRegistryClient = r.Registries.Default()
+	// Messenger is shared between reconcilers and is run by manager
+	if r.Messenger == nil {
+		return fmt.Errorf("reporter Messenger must be provided")
+	}
 	// Init Kafka manager
+	// predicates are set per watch, status updates of referenced schemas must pass to dependents
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&xov1alpha1.KafkaSchema{}).
+		For(&xov1alpha1.KafkaSchema{},
+			builder.WithPredicates(labelSelectorPredicate, ignoreUpdateDeletePredicate())).
+		Watches(&xov1alpha1.KafkaSchema{},
+			handler.EnqueueRequestsFromMapFunc(r.dependentSchemas),
+			builder.WithPredicates(schemaRegisteredPredicate())).
+		Watches(&xov1alpha1.KafkaTopic{},
+			handler.EnqueueRequestsFromMapFunc(r.schemasForTopic),
+			builder.WithPredicates(topicReadyPredicate())).
+		// only metadata of ConfigMaps is cached, any change of ConfigMap bumps its resourceVersion
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.schemasFromConfigMap),
+			builder.OnlyMetadata).
 		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles}).
-		WithEventFilter(labelSelectorPredicate).
-		WithEventFilter(ignoreUpdateDeletePredicate()).
 		Complete(r)
 }
 
@@ -122,54 +185,518 @@ func (r *KafkaSchemaReconciler) upsertSchema(ctx context.Context, schema *xov1al
 	status := metav1.ConditionTrue
 	condition := ConditionsInsert
 	reason := ConditionReasonCreateSchema
+	changes := []reporter.Change{}
+	// subject is derived from spec after schema is loaded
+	subject := schemaSubject(schema)
+	// spec wasn't changed since last reconcile
+	specObserved := observedGeneration(schema.Status.Conditions) == schema.Generation
+	// registered schema differs from spec which was already registered
+	drifted := false
+	// result of compatibility check, if it was done
+	var compatible *metav1.Condition
+	notification := ""
 
 	// Defer function to update status
 	defer func() {
 		// Log status update
-		reqLogger.Info(fmt.Sprintf("schema %s %s status: %s", schema.Spec.Name,
+		reqLogger.Info(fmt.Sprintf("schema %s %s status: %s", subject,
 			reason, statusMessage))
-		// Send message to slack
+		// Send message to slack, Messenger would filter it by notification level
 		if status == metav1.ConditionFalse {
-			// send message only on error
-			r.Messenger.Send(statusMessage, reporter.ErrorMessage)
+			notification = statusMessage
 		}
+		r.Messenger.Send(notification,
+			resultMessageType(status, drifted),
+			reporter.Object(KindKafkaSchema, schema.Namespace, schema.Name),
+			reporter.Subject(subject),
+			reporter.Reason(reason),
+			reporter.Diff(changes...))
 		// Remove last condition and set new one
 		meta.RemoveStatusCondition(&schema.Status.Conditions, condition)
 		meta.SetStatusCondition(&schema.Status.Conditions, metav1.Condition{
-			Type:    condition,
-			Status:  status,
-			Reason:  reason,
-			Message: statusMessage,
+			Type:               condition,
+			Status:             status,
+			Reason:             reason,
+			Message:            statusMessage,
+			ObservedGeneration: schema.Generation,
+		})
+		if compatible != nil {
+			compatible.ObservedGeneration = schema.Generation
+			meta.SetStatusCondition(&schema.Status.Conditions, *compatible)
+		}
+		// Ready condition is always there, reflecting result of last reconcile
+		meta.SetStatusCondition(&schema.Status.Conditions, metav1.Condition{
+			Type:               ConditionReady,
+			Status:             status,
+			Reason:             reason,
+			Message:            statusMessage,
+			ObservedGeneration: schema.Generation,
 		})
 		// we will return error of status update if it is not nil
 		err := r.Status().Update(ctx, schema)
 		if err != nil {
-			reqLogger.V(0).Info(fmt.Sprintf("Failed to update schema status: %v", retErr))
+			reqLogger.V(0).Info(fmt.Sprintf("Failed to update schema status: %v", err))
 			retErr = errors.Join(retErr, err)
 		}
 	}()
 
+	// Load schema from ConfigMap, KafkaSchema would be reconciled when it changes
+	spec, imports, err := r.loadSchema(ctx, schema)
+	if err != nil {
+		reason = ConditionReasonLoadSchema
+		if errors.Is(err, errInvalidSchemaSource) {
+			reason = ConditionReasonInvalidSpec
+		}
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't load kafka schema %s: %v", schema.Name, err)
+		return ctrl.Result{}, nil
+	}
+
+	// Validate schema, there is no point to requeue invalid one, it would be reconciled on spec change
+	err = schemaregistry.ValidateSchema(spec)
+	if err != nil {
+		reason = ConditionReasonInvalidSpec
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("invalid kafka schema %s: %v", schema.Name, err)
+		return ctrl.Result{}, nil
+	}
+
+	// Derive subject from subject strategy, schema can't be registered before topic it describes
+	derived, err := r.resolveSubject(ctx, schema, spec)
+	if errors.Is(err, errTopicNotReady) {
+		status = metav1.ConditionUnknown
+		reason = ConditionReasonWaitingForTopic
+		statusMessage = fmt.Sprintf("waiting for topic of kafka schema %s: %v", schema.Name, err)
+		notification = statusMessage
+		return ctrl.Result{
+			RequeueAfter: ReferencesRequeueIntervalSec * time.Second,
+		}, nil
+	}
+	if err != nil {
+		reason = ConditionReasonInvalidSpec
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't derive subject of kafka schema %s: %v", schema.Name, err)
+		return ctrl.Result{}, nil
+	}
+	subject = derived
+	spec.Name = subject
+	notification = fmt.Sprintf("schema %s is in sync", subject)
+
+	// Schema is registered in registry of referenced KafkaSchemaRegistry, which may not be created yet
+	registry, err := r.Registries.forSchema(ctx, schema)
+	if errors.Is(err, errRegistryNotFound) {
+		status = metav1.ConditionUnknown
+		reason = ConditionReasonWaitingForRegistry
+		statusMessage = fmt.Sprintf("waiting for schema registry of kafka schema %s: %v", schema.Name, err)
+		notification = statusMessage
+		return ctrl.Result{
+			RequeueAfter: ReferencesRequeueIntervalSec * time.Second,
+		}, nil
+	}
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't connect to schema registry of kafka schema %s: %v", schema.Name, err)
+		return ctrl.Result{}, nil
+	}
+
 	// Check if schema exists in Kafka Schema Registry
-	exists, err := r.KafkaSchemaRegistryClient.SchemaExists(schema.Spec.Name)
+	exists, err := registry.SchemaExists(ctx, subject)
 	if err != nil {
 		status = metav1.ConditionFalse
 		statusMessage = fmt.Sprintf("can't check if schema %s exists: %v", schema.Name, err)
 		return ctrl.Result{}, nil
 	}
 
-	// Create or update schema
+	// Resolve references, schema can't be registered before schemas it references
+	references, err := r.resolveReferences(ctx, registry, schema)
+	if errors.Is(err, errReferenceNotReady) {
+		status = metav1.ConditionUnknown
+		reason = ConditionReasonWaitingForReferences
+		statusMessage = fmt.Sprintf("waiting for references of kafka schema %s: %v", schema.Name, err)
+		notification = statusMessage
+		return ctrl.Result{
+			RequeueAfter: ReferencesRequeueIntervalSec * time.Second,
+		}, nil
+	}
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't resolve references of kafka schema %s: %v", schema.Name, err)
+		return ctrl.Result{}, nil
+	}
+
+	// Protobuf imports loaded with schema are registered before it, as they are referenced by it,
+	// validate only schema would only look them up
+	importRefs, err := r.resolveImports(ctx, registry, schema, spec, imports)
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't resolve imports of kafka schema %s: %v", schema.Name, err)
+		return ctrl.Result{}, nil
+	}
+	references = append(references, importRefs...)
+
 	if exists {
 		condition = ConditionsUpdate
 		reason = ConditionReasonUpdateSchema
 	}
-	err = r.KafkaSchemaRegistryClient.CreateSchema(&schema.Spec)
+
+	// Lookup schema under subject, schema registered already is in sync and isn't registered again
+	_, err = registry.LookupSchema(ctx, spec, references)
+	if err != nil && !errors.Is(err, schemaregistry.ErrSubjectNotFound) && !errors.Is(err, schemaregistry.ErrSchemaNotFound) {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't lookup kafka schema %s: %v", schema.Name, err)
+		return ctrl.Result{}, nil
+	}
+	registered := err == nil
+
+	// Reconcile compatibility level of subject first, as compatibility check depends on it
+	if !schema.Spec.ValidateOnly {
+		previous, cErr := registry.ReconcileCompatibility(ctx, spec)
+		if cErr != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't set compatibility level of kafka schema %s: %v", schema.Name, cErr)
+			return ctrl.Result{}, nil
+		}
+		if desired := strings.ToUpper(schema.Spec.Compatibility); previous != desired {
+			changes = append(changes, reporter.Change{Field: "compatibility", Old: previous, New: desired})
+			notification = fmt.Sprintf("compatibility level of subject %s was changed", subject)
+			if specObserved {
+				drifted = true
+				notification = fmt.Sprintf("compatibility level of subject %s drifted from spec and was restored", subject)
+			}
+		}
+	}
+
+	// Mode of subject is set before registration, so that IMPORT mode accepts schema with pinned ID,
+	// unless it is read-only, then it is set after registration, so that subject is frozen with schema of spec
+	desiredMode := strings.ToUpper(schema.Spec.Mode)
+	reconcileMode := func() error {
+		previous, mErr := registry.ReconcileMode(ctx, spec)
+		if mErr != nil || previous == desiredMode {
+			return mErr
+		}
+		if len(changes) == 0 {
+			notification = fmt.Sprintf("mode of subject %s was changed", subject)
+			if specObserved {
+				notification = fmt.Sprintf("mode of subject %s drifted from spec and was restored", subject)
+			}
+		}
+		changes = append(changes, reporter.Change{Field: "mode", Old: previous, New: desiredMode})
+		drifted = drifted || specObserved
+		return nil
+	}
+	mode := ""
+	if !schema.Spec.ValidateOnly {
+		if !schemaregistry.ReadOnly(desiredMode) {
+			err = reconcileMode()
+			if err != nil {
+				status = metav1.ConditionFalse
+				statusMessage = fmt.Sprintf("can't set mode of kafka schema %s: %v", schema.Name, err)
+				return ctrl.Result{}, nil
+			}
+		}
+		mode, err = registry.Mode(ctx, subject)
+		if err != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't get mode of kafka schema %s: %v", schema.Name, err)
+			return ctrl.Result{}, nil
+		}
+		schema.Status.Mode = mode
+	}
+
+	// Schema with pinned ID is registered only in IMPORT mode, Schema Registry would assign new ID otherwise
+	pinned := spec.ID != 0
+	importing := !registered && pinned && mode == schemaregistry.ModeImport
+
+	// Check compatibility with latest version, so incompatible schema isn't sent for registration.
+	// Schema Registry doesn't check compatibility of imported schemas.
+	compat := &schemaregistry.Compatibility{IsCompatible: true}
+	if !registered && !importing {
+		compat, err = registry.CheckCompatibility(ctx, spec, references)
+		if err != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't check compatibility of kafka schema %s: %v", schema.Name, err)
+			return ctrl.Result{}, nil
+		}
+	}
+	compatible = compatibilityCondition(compat)
+	if !compat.IsCompatible {
+		reason = ConditionReasonCheckCompat
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("kafka schema %s is incompatible with latest version: %s", schema.Name, compatible.Message)
+		return ctrl.Result{}, nil
+	}
+	if schema.Spec.ValidateOnly {
+		reason = ConditionReasonCheckCompat
+		statusMessage = "Compatible, validate only"
+		notification = fmt.Sprintf("schema %s is compatible with latest version, it wasn't registered as it is validate only", subject)
+		return ctrl.Result{
+			RequeueAfter: RevisitIntervalSec * time.Second,
+		}, nil
+	}
+
+	// Subject in READONLY or IMPORT mode would reject registration, it is retried when mode changes
+	if !registered && !importing && (pinned || !schemaregistry.Writable(mode)) {
+		reason = ConditionReasonRegistryMode
+		status = metav1.ConditionUnknown
+		statusMessage = fmt.Sprintf("kafka schema %s can't be registered, subject %s is in %s mode", schema.Name, subject, mode)
+		if pinned {
+			statusMessage = fmt.Sprintf("kafka schema %s pins ID %d, it can be registered only when subject %s is in %s mode",
+				schema.Name, spec.ID, subject, schemaregistry.ModeImport)
+		}
+		notification = statusMessage
+		return ctrl.Result{
+			RequeueAfter: ReferencesRequeueIntervalSec * time.Second,
+		}, nil
+	}
+
+	// Create or update schema
+	// structural changes of new version from latest one
+	var evolution []schemaregistry.SchemaChange
+	createSchema, registeredAs := registry.CreateSchema, "registered"
+	if importing {
+		createSchema, registeredAs = registry.ImportSchema, "imported"
+	}
+	switch {
+	case registered:
+		// nothing to register
+	case exists:
+		refsChanged := !reflect.DeepEqual(schema.Status.References, references) &&
+			len(schema.Status.References)+len(references) != 0
+		latest, lErr := registry.LatestSchema(ctx, subject)
+		if lErr == nil {
+			// schema of observed spec isn't registered anymore, we are restoring it,
+			// unless schema was changed in its ConfigMap
+			drifted = drifted || specObserved && !refsChanged && schema.Status.Fingerprint == schemaregistry.Fingerprint(spec)
+			var dErr error
+			evolution, dErr = schemaregistry.DiffSchemas(schemaregistry.SchemaType(spec), latest, spec.Schema)
+			if dErr != nil {
+				reqLogger.Info(fmt.Sprintf("can't compare schema %s with latest version: %v", subject, dErr))
+			}
+			changes = append(changes, schemaChanges(evolution, latest, spec.Schema)...)
+			notification = fmt.Sprintf("new version of schema %s was %s", subject, registeredAs)
+			if len(evolution) != 0 {
+				notification = fmt.Sprintf("new version of schema %s was %s (%s)",
+					subject, registeredAs, schemaregistry.SummarizeChanges(evolution))
+			}
+		}
+		if refsChanged {
+			changes = append(changes, reporter.Change{
+				Field: "references",
+				Old:   schemaregistry.FormatReferences(schema.Status.References),
+				New:   schemaregistry.FormatReferences(references),
+			})
+			notification = fmt.Sprintf("schema %s was %s with new references", subject, registeredAs)
+		}
+	default:
+		changes = append(changes, reporter.Change{Field: "schema", New: spec.Schema})
+		if len(references) != 0 {
+			changes = append(changes, reporter.Change{Field: "references", New: schemaregistry.FormatReferences(references)})
+		}
+		if metadata := schemaregistry.FormatMetadata(spec.Metadata); len(metadata) != 0 {
+			changes = append(changes, reporter.Change{Field: "metadata", New: metadata})
+		}
+		if ruleSet := schemaregistry.FormatRuleSet(spec.RuleSet); len(ruleSet) != 0 {
+			changes = append(changes, reporter.Change{Field: "ruleSet", New: ruleSet})
+		}
+		notification = fmt.Sprintf("schema %s was %s", subject, registeredAs)
+	}
+	registration, err := createSchema(ctx, spec, references)
 	if err != nil {
 		status = metav1.ConditionFalse
 		statusMessage = fmt.Sprintf("can't %s kafka schema %s: %v", reason, schema.Name, err)
 		return ctrl.Result{}, nil
 	}
+	if len(changes) != 0 {
+		notification = fmt.Sprintf("%s as version %d with ID %d", notification, registration.Version, registration.ID)
+	}
+	schema.Status.Subject = registration.Subject
+	schema.Status.ID = registration.ID
+	schema.Status.Version = registration.Version
+	schema.Status.SchemaType = registration.SchemaType
+	schema.Status.Fingerprint = registration.Fingerprint
+	schema.Status.CompatibilityLevel = registration.CompatibilityLevel
+	schema.Status.References = references
+	if !registered {
+		schema.Status.SchemaChanges = formatSchemaChanges(evolution)
+		if len(evolution) != 0 {
+			statusMessage = fmt.Sprintf("Succeeded, version %d: %s", registration.Version,
+				schemaregistry.SummarizeChanges(evolution))
+		}
+	}
+
+	// Schema registered before its ID was pinned keeps ID assigned by Schema Registry
+	if pinned && (registration.ID != spec.ID || spec.Version != 0 && registration.Version != spec.Version) {
+		pin := fmt.Sprintf("ID %d", spec.ID)
+		if spec.Version != 0 {
+			pin = fmt.Sprintf("%s and version %d", pin, spec.Version)
+		}
+		reason = ConditionReasonImportSchema
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("kafka schema %s is registered with ID %d as version %d, but spec pins %s",
+			schema.Name, registration.ID, registration.Version, pin)
+		return ctrl.Result{}, nil
+	}
+
+	// Old versions are pruned after registration, unless subject is frozen
+	if schema.Spec.VersionRetention != 0 && schemaregistry.Writable(mode) {
+		pruned, pErr := registry.PruneVersions(ctx, subject, schema.Spec.VersionRetention, registration.Version)
+		if len(pruned) != 0 {
+			schema.Status.PrunedVersions = pruned
+			if len(changes) == 0 {
+				notification = fmt.Sprintf("%d old versions of subject %s were pruned", len(pruned), subject)
+			}
+			changes = append(changes, reporter.Change{Field: "versions", Old: formatVersions(pruned)})
+		}
+		if pErr != nil {
+			reason = ConditionReasonPruneVersions
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't prune versions of kafka schema %s: %v", schema.Name, pErr)
+			return ctrl.Result{}, nil
+		}
+	}
+
+	// Subject is frozen after schema of spec is registered
+	if schemaregistry.ReadOnly(desiredMode) {
+		err = reconcileMode()
+		if err != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't set mode of kafka schema %s: %v", schema.Name, err)
+			return ctrl.Result{}, nil
+		}
+		schema.Status.Mode = desiredMode
+	}
 
 	return ctrl.Result{
 		RequeueAfter: RevisitIntervalSec * time.Second,
 	}, nil
 }
+
+// deleteSchema would delete subject KafkaSchema was registered under and subjects of its Protobuf imports
+// according to its deletion policy and remove finalizer, subject referenced by other subjects is not deleted
+func (r *KafkaSchemaReconciler) deleteSchema(ctx context.Context, schema *xov1alpha1.KafkaSchema, reqLogger logr.Logger) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(schema, SchemaFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	// schema wasn't registered if it has no subject, subject of its name may belong to other KafkaSchema.
+	// Imports are owned by KafkaSchema and deleted after schema referencing them.
+	subjects := []string{}
+	if len(schema.Status.Subject) != 0 {
+		subjects = append(subjects, schema.Status.Subject)
+	}
+	if schema.Spec.SchemaFrom != nil {
+		for _, imp := range schema.Spec.SchemaFrom.Imports {
+			subjects = append(subjects, importSubject(schema, imp.Name))
+		}
+	}
+	if policy := schema.Spec.DeletionPolicy; policy != schemaregistry.DeletionPolicyRetain && len(subjects) != 0 {
+		registry, err := r.Registries.forSchema(ctx, schema)
+		if err != nil {
+			r.Messenger.Send(fmt.Sprintf("can't delete subject %s: %v", subjects[0], err), reporter.ErrorMessage,
+				reporter.Object(KindKafkaSchema, schema.Namespace, schema.Name),
+				reporter.Subject(subjects[0]),
+				reporter.Reason(ConditionReasonDeleteSchema))
+			return ctrl.Result{}, err
+		}
+		for _, subject := range subjects {
+			result, err := r.deleteSubject(ctx, registry, schema, subject, reqLogger)
+			if err != nil || !result.IsZero() {
+				return result, err
+			}
+		}
+	}
+	controllerutil.RemoveFinalizer(schema, SchemaFinalizer)
+	return ctrl.Result{}, r.Update(ctx, schema)
+}
+
+// deleteSubject would delete subject of KafkaSchema according to its deletion policy,
+// subject referenced by other subjects is not deleted and deletion is retried later
+func (r *KafkaSchemaReconciler) deleteSubject(ctx context.Context, registry schemaregistry.Backend, schema *xov1alpha1.KafkaSchema,
+	subject string, reqLogger logr.Logger) (ctrl.Result, error) {
+	policy := schema.Spec.DeletionPolicy
+	fields := []reporter.MessageField{
+		reporter.Object(KindKafkaSchema, schema.Namespace, schema.Name),
+		reporter.Subject(subject),
+		reporter.Reason(ConditionReasonDeleteSchema),
+	}
+	referencedBy, err := registry.ReferencedBy(ctx, subject)
+	if err != nil {
+		r.Messenger.Send(fmt.Sprintf("can't check references to subject %s: %v", subject, err),
+			reporter.ErrorMessage, fields...)
+		return ctrl.Result{}, err
+	}
+	if len(referencedBy) != 0 {
+		statusMessage := fmt.Sprintf("subject %s can't be deleted, it is referenced by %s",
+			subject, strings.Join(referencedBy, ", "))
+		reqLogger.Info(statusMessage)
+		r.Messenger.Send(statusMessage, reporter.ErrorMessage, fields...)
+		schema.Status.ReferencedBy = referencedBy
+		meta.SetStatusCondition(&schema.Status.Conditions, metav1.Condition{
+			Type:               ConditionReady,
+			Status:             metav1.ConditionFalse,
+			Reason:             ConditionReasonDeleteSchema,
+			Message:            statusMessage,
+			ObservedGeneration: schema.Generation,
+		})
+		return ctrl.Result{
+			RequeueAfter: ReferencesRequeueIntervalSec * time.Second,
+		}, r.Status().Update(ctx, schema)
+	}
+	err = registry.DeleteSubject(ctx, subject, policy == schemaregistry.DeletionPolicyHardDelete)
+	if err != nil {
+		r.Messenger.Send(err.Error(), reporter.ErrorMessage, fields...)
+		return ctrl.Result{}, err
+	}
+	notification := fmt.Sprintf("subject %s was soft deleted", subject)
+	if policy == schemaregistry.DeletionPolicyHardDelete {
+		notification = fmt.Sprintf("subject %s was permanently deleted", subject)
+	}
+	reqLogger.Info(notification)
+	r.Messenger.Send(notification, reporter.OKMessage,
+		append(fields, reporter.Diff(reporter.Change{Field: "subject", Old: subject}))...)
+	return ctrl.Result{}, nil
+}
+
+// formatVersions would format versions as one line, i.e. for notifications
+func formatVersions(versions []int) string {
+	formatted := make([]string, 0, len(versions))
+	for _, version := range versions {
+		formatted = append(formatted, strconv.Itoa(version))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// schemaChanges would return structural changes of schema for notification,
+// or whole schemas if structure didn't change or can't be compared
+func schemaChanges(evolution []schemaregistry.SchemaChange, old, new string) []reporter.Change {
+	if len(evolution) == 0 {
+		return []reporter.Change{{Field: "schema", Old: old, New: new}}
+	}
+	changes := make([]reporter.Change, 0, len(evolution))
+	for _, change := range evolution {
+		field := change.Path
+		switch change.Kind {
+		case schemaregistry.SchemaChangeDefault:
+			field += " default"
+		case schemaregistry.SchemaChangeSymbols:
+			field += " symbols"
+		}
+		changes = append(changes, reporter.Change{Field: field, Old: change.Old, New: change.New})
+	}
+	return changes
+}
+
+// formatSchemaChanges would format structural changes of schema for status, keeping it small
+func formatSchemaChanges(evolution []schemaregistry.SchemaChange) []string {
+	if len(evolution) == 0 {
+		return nil
+	}
+	formatted := make([]string, 0, MaxSchemaChanges+1)
+	for i, change := range evolution {
+		if i == MaxSchemaChanges {
+			formatted = append(formatted, fmt.Sprintf("... and %d more", len(evolution)-MaxSchemaChanges))
+			break
+		}
+		formatted = append(formatted, change.String())
+	}
+	return formatted
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+	"github.com/90poe/kafkaobjects-operator/internal/reporter"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/go-logr/logr"
+)
+
+// KafkaSchemaRegistryReconciler reconciles global mode and compatibility level of Schema Registry
+type KafkaSchemaRegistryReconciler struct {
+	client.Client
+	Scheme                    *runtime.Scheme
+	KafkaSchemaRegistryClient schemaregistry.Backend
+	Messenger                 *reporter.Messenger
+	// Registries makes clients of registries with URL
+	Registries *SchemaRegistries
+}
+
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaschemaregistries,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaschemaregistries/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkaschemaregistries/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+// Reconcile would set global mode and compatibility level of Schema Registry from KafkaSchemaRegistry,
+// registry operator is configured with or one at URL of spec. Config is left as is when KafkaSchemaRegistry is deleted.
+func (r *KafkaSchemaRegistryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx).WithValues("kafkaschemaregistry", req.Name)
+
+	// Fetch the KafkaSchemaRegistry instance
+	instance := &xov1alpha1.KafkaSchemaRegistry{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			reqLogger.Info("KafkaSchemaRegistry resource not found. Ignoring since object must be deleted.")
+			r.Registries.forget(req.Name)
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		reqLogger.Error(err, "Failed to get KafkaSchemaRegistry.")
+		return ctrl.Result{}, err
+	}
+
+	// changes made to Schema Registry would be audited as made by this object
+	ctx = audit.WithSource(ctx, audit.SourceFromObject(KindKafkaSchemaRegistry, instance))
+	return r.updateConfig(ctx, instance, reqLogger)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KafkaSchemaRegistryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// init config
+	config, err := env.NewConfig()
+	if err != nil {
+		return err
+	}
+	// make label selector
+	labelSelectorPredicate, err := predicate.LabelSelectorPredicate(*config.LabelSelectors)
+	if err != nil {
+		return err
+	}
+	// Schema Registries are shared between reconcilers
+	if r.Registries == nil {
+		return fmt.Errorf("schema registries must be provided")
+	}
+	r.KafkaSchemaRegistryClient = r.Registries.Default()
+	// Messenger is shared between reconcilers and is run by manager
+	if r.Messenger == nil {
+		return fmt.Errorf("reporter Messenger must be provided")
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&xov1alpha1.KafkaSchemaRegistry{},
+			builder.WithPredicates(labelSelectorPredicate, ignoreUpdateDeletePredicate())).
+		Complete(r)
+}
+
+// updateConfig would update global config of Schema Registry
+func (r *KafkaSchemaRegistryReconciler) updateConfig(ctx context.Context, registry *xov1alpha1.KafkaSchemaRegistry, reqLogger logr.Logger) (_ ctrl.Result, retErr error) {
+	// Init status
+	statusMessage := "Succeeded"
+	status := metav1.ConditionTrue
+	changes := []reporter.Change{}
+	// spec wasn't changed since last reconcile, so changes we make are drift
+	specObserved := observedGeneration(registry.Status.Conditions) == registry.Generation
+	notification := "global config of schema registry is in sync"
+
+	// Defer function to update status
+	defer func() {
+		reqLogger.Info(fmt.Sprintf("schema registry %s status: %s", ConditionReasonUpdateConfig, statusMessage))
+		if status == metav1.ConditionFalse {
+			notification = statusMessage
+		}
+		r.Messenger.Send(notification,
+			resultMessageType(status, specObserved && len(changes) != 0),
+			reporter.Object(KindKafkaSchemaRegistry, "", registry.Name),
+			reporter.Reason(ConditionReasonUpdateConfig),
+			reporter.Diff(changes...))
+		meta.SetStatusCondition(&registry.Status.Conditions, metav1.Condition{
+			Type:               ConditionReady,
+			Status:             status,
+			Reason:             ConditionReasonUpdateConfig,
+			Message:            statusMessage,
+			ObservedGeneration: registry.Generation,
+		})
+		// we will return error of status update if it is not nil
+		err := r.Status().Update(ctx, registry)
+		if err != nil {
+			reqLogger.V(0).Info(fmt.Sprintf("Failed to update schema registry status: %v", err))
+			retErr = errors.Join(retErr, err)
+		}
+	}()
+
+	backend, err := r.Registries.forRegistry(ctx, registry)
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't connect to schema registry: %v", err)
+		return ctrl.Result{}, nil
+	}
+
+	// Compatibility level is set first, so it is in place when mode allows registrations again
+	desiredLevel := strings.ToUpper(registry.Spec.Compatibility)
+	level, err := backend.ReconcileGlobalCompatibility(ctx, desiredLevel)
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't set global compatibility level: %v", err)
+		return ctrl.Result{}, nil
+	}
+	if len(desiredLevel) != 0 && level != desiredLevel {
+		changes = append(changes, reporter.Change{Field: "compatibility", Old: level, New: desiredLevel})
+		level = desiredLevel
+	}
+	registry.Status.CompatibilityLevel = level
+
+	desiredMode := strings.ToUpper(registry.Spec.Mode)
+	mode, err := backend.ReconcileGlobalMode(ctx, desiredMode)
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't set global mode: %v", err)
+		return ctrl.Result{}, nil
+	}
+	if len(desiredMode) != 0 && mode != desiredMode {
+		changes = append(changes, reporter.Change{Field: "mode", Old: mode, New: desiredMode})
+		mode = desiredMode
+	}
+	registry.Status.Mode = mode
+
+	if len(changes) != 0 {
+		notification = "global config of schema registry was changed"
+		if specObserved {
+			notification = "global config of schema registry drifted from spec and was restored"
+		}
+	}
+	return ctrl.Result{
+		RequeueAfter: RevisitIntervalSec * time.Second,
+	}, nil
+}
@@ -33,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
 	"github.com/90poe/kafkaobjects-operator/internal/env"
 	"github.com/90poe/kafkaobjects-operator/internal/kafka"
 	"github.com/90poe/kafkaobjects-operator/internal/reporter"
@@ -45,6 +46,7 @@ type KafkaTopicReconciler struct {
 	Scheme            *runtime.Scheme
 	KafkaClientConfig *kafka.ClusterConfig
 	Messenger         *reporter.Messenger
+	Auditor           *audit.Auditor
 }
 
 //+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkatopics,verbs=get;list;watch;create;update;patch;delete
@@ -83,11 +85,15 @@ func (r *KafkaTopicReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	kClient, err := r.KafkaClientConfig.GetClient()
 	if err != nil {
 		reqLogger.V(0).Info(fmt.Sprintf("Failed to get Kafka Client: %v", err))
-		r.Messenger.Send(fmt.Sprintf("%v", err), reporter.ErrorMessage)
+		r.Messenger.Send(fmt.Sprintf("%v", err), reporter.ErrorMessage,
+			reporter.Object(KindKafkaTopic, instance.Namespace, instance.Name),
+			reporter.Topic(instance.Spec.Name))
 		return ctrl.Result{}, nil
 	}
 	defer kClient.Close()
 
+	// changes made to Kafka would be audited as made by this object
+	ctx = audit.WithSource(ctx, audit.SourceFromObject(KindKafkaTopic, instance))
 	return r.upsertTopic(ctx, kClient, instance, reqLogger)
 }
 
@@ -108,15 +114,14 @@ func (r *KafkaTopicReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		config.KafkaTopicNameRegexp,
 		kafka.Brokers(config.KafkaBrokers),
 		kafka.MaxPartsPerTopic(config.MaxKafkaTopicsPartitions),
+		kafka.Auditor(r.Auditor),
 	)
 	if err != nil {
 		return err
 	}
-	// Make slack messanger
-	r.Messenger, err = reporter.New(config.SlackToken,
-		reporter.SlackChannel(config.SlackChannel))
-	if err != nil {
-		return err
+	// Messenger is shared between reconcilers and is run by manager
+	if r.Messenger == nil {
+		return fmt.Errorf("reporter Messenger must be provided")
 	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&xov1alpha1.KafkaTopic{}).
@@ -133,29 +138,47 @@ func (r *KafkaTopicReconciler) upsertTopic(ctx context.Context, kClient *kafka.C
 	status := metav1.ConditionTrue
 	condition := ConditionsInsert
 	reason := ConditionReasonCreateTopic
+	changes := []reporter.Change{}
+	// spec wasn't changed since last reconcile
+	specObserved := observedGeneration(topic.Status.Conditions) == topic.Generation
+	notification := fmt.Sprintf("topic %s is in sync", topic.Spec.Name)
 
 	// Defer function to update status
 	defer func() {
 		// Log status update
 		reqLogger.Info(fmt.Sprintf("topic %s %s status: %s", topic.Spec.Name,
 			reason, statusMessage))
-		// Send message to slack
+		// Send message to slack, Messenger would filter it by notification level
 		if status == metav1.ConditionFalse {
-			// send message only on error
-			r.Messenger.Send(statusMessage, reporter.ErrorMessage)
+			notification = statusMessage
 		}
+		r.Messenger.Send(notification,
+			resultMessageType(status, specObserved && len(changes) != 0),
+			reporter.Object(KindKafkaTopic, topic.Namespace, topic.Name),
+			reporter.Topic(topic.Spec.Name),
+			reporter.Reason(reason),
+			reporter.Diff(changes...))
 		// Remove last condition and set new one
 		meta.RemoveStatusCondition(&topic.Status.Conditions, condition)
 		meta.SetStatusCondition(&topic.Status.Conditions, metav1.Condition{
-			Type:    condition,
-			Status:  status,
-			Reason:  reason,
-			Message: statusMessage,
+			Type:               condition,
+			Status:             status,
+			Reason:             reason,
+			Message:            statusMessage,
+			ObservedGeneration: topic.Generation,
+		})
+		// Ready condition is always there, reflecting result of last reconcile
+		meta.SetStatusCondition(&topic.Status.Conditions, metav1.Condition{
+			Type:               ConditionReady,
+			Status:             status,
+			Reason:             reason,
+			Message:            statusMessage,
+			ObservedGeneration: topic.Generation,
 		})
 		// we will return error of status update if it is not nil
 		err := r.Status().Update(ctx, topic)
 		if err != nil {
-			reqLogger.V(0).Info(fmt.Sprintf("Failed to update topic status: %v", retErr))
+			reqLogger.V(0).Info(fmt.Sprintf("Failed to update topic status: %v", err))
 			retErr = errors.Join(retErr, err)
 		}
 	}()
@@ -169,12 +192,29 @@ func (r *KafkaTopicReconciler) upsertTopic(ctx context.Context, kClient *kafka.C
 	}
 
 	// Create or update topic
+	desired := kafka.DesiredConfigs(&topic.Spec)
 	if exists {
 		condition = ConditionsUpdate
 		reason = ConditionReasonUpdateTopic
-		err = kClient.UpdateTopic(&topic.Spec)
+		var current map[string]string
+		current, err = kClient.TopicConfigs(topic.Spec.Name)
+		if err != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't get kafka topic %s configs: %v", topic.Name, err)
+			return ctrl.Result{}, nil
+		}
+		changes = configDiff(current, desired)
+		if len(changes) != 0 {
+			notification = fmt.Sprintf("topic %s configs were changed", topic.Spec.Name)
+			if specObserved {
+				notification = fmt.Sprintf("topic %s configs drifted from spec and were restored", topic.Spec.Name)
+			}
+		}
+		err = kClient.UpdateTopic(ctx, &topic.Spec)
 	} else {
-		err = kClient.CreateTopic(&topic.Spec)
+		changes = configDiff(map[string]string{}, desired)
+		notification = fmt.Sprintf("topic %s was created", topic.Spec.Name)
+		err = kClient.CreateTopic(ctx, &topic.Spec)
 	}
 	if err != nil {
 		status = metav1.ConditionFalse
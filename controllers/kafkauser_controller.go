@@ -0,0 +1,500 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+	"github.com/90poe/kafkaobjects-operator/internal/kafka"
+	"github.com/90poe/kafkaobjects-operator/internal/reporter"
+	"github.com/go-logr/logr"
+)
+
+const (
+	// userSecretMechanismKey, userSecretBootstrapKey and userSecretJAASKey are keys of connection info in Secret of KafkaUser
+	userSecretMechanismKey = "sasl.mechanism"
+	userSecretBootstrapKey = "bootstrap.servers"
+	userSecretJAASKey      = "sasl.jaas.config"
+	// userPasswordDigestAnnotation is annotation of Secret with digest of password set in Kafka cluster
+	userPasswordDigestAnnotation = "xo.90poe.io/password-digest"
+	// userPasswordBytes is how many random bytes generated password has
+	userPasswordBytes = 32
+)
+
+// KafkaUserReconciler reconciles a KafkaUser object
+type KafkaUserReconciler struct {
+	client.Client
+	Scheme            *runtime.Scheme
+	KafkaClientConfig *kafka.ClusterConfig
+	Messenger         *reporter.Messenger
+	Auditor           *audit.Auditor
+	// bootstrapServers are written into Secret of KafkaUser as connection info
+	bootstrapServers string
+	// secretReader reads Secrets of KafkaUsers, they aren't cached
+	secretReader client.Reader
+	// anyUsername allows username of KafkaUser which isn't prefixed by its namespace
+	anyUsername bool
+}
+
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkausers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkausers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=xo.90poe.io,resources=kafkausers/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
+
+// Reconcile would create SCRAM credential of KafkaUser with generated password, write it into Secret owned by KafkaUser
+// and reconcile ACL bindings of user. Password is set in Kafka cluster again whenever it is changed in Secret,
+// it is generated again if Secret doesn't have it. Credential and bindings are deleted together with KafkaUser.
+func (r *KafkaUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	reqLogger := log.FromContext(ctx).WithValues("kafkauser", req.NamespacedName)
+
+	// Fetch the KafkaUser instance
+	instance := &xov1alpha1.KafkaUser{}
+	err := r.Get(ctx, req.NamespacedName, instance)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Return and don't requeue
+			reqLogger.Info("KafkaUser resource not found. Ignoring since object must be deleted.")
+			return ctrl.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		reqLogger.Error(err, "Failed to get KafkaUser.")
+		return ctrl.Result{}, err
+	}
+
+	// Get Kafka client
+	kClient, err := r.KafkaClientConfig.GetClient()
+	if err != nil {
+		reqLogger.Info(fmt.Sprintf("Failed to get Kafka Client: %v", err))
+		r.Messenger.Send(fmt.Sprintf("%v", err), reporter.ErrorMessage,
+			reporter.Object(KindKafkaUser, instance.Namespace, instance.Name))
+		return ctrl.Result{}, nil
+	}
+	defer kClient.Close()
+
+	// changes made to Kafka would be audited as made by this object
+	ctx = audit.WithSource(ctx, audit.SourceFromObject(KindKafkaUser, instance))
+	if !instance.DeletionTimestamp.IsZero() {
+		return r.deleteUser(ctx, kClient, instance, reqLogger)
+	}
+	if !controllerutil.ContainsFinalizer(instance, UserFinalizer) {
+		controllerutil.AddFinalizer(instance, UserFinalizer)
+		err = r.Update(ctx, instance)
+		if err != nil {
+			reqLogger.Error(err, "Failed to update KafkaUser finalizers.")
+			return ctrl.Result{}, err
+		}
+	}
+	return r.upsertUser(ctx, kClient, instance, reqLogger)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KafkaUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// init config
+	config, err := env.NewConfig()
+	if err != nil {
+		return err
+	}
+	// make label selector
+	labelSelectorPredicate, err := predicate.LabelSelectorPredicate(*config.LabelSelectors)
+	if err != nil {
+		return err
+	}
+	// init kafka client
+	r.KafkaClientConfig, err = kafka.NewClusterConfig(
+		config.KafkaTopicNameRegexp,
+		kafka.Brokers(config.KafkaBrokers),
+		kafka.MaxPartsPerTopic(config.MaxKafkaTopicsPartitions),
+		kafka.Auditor(r.Auditor),
+	)
+	if err != nil {
+		return err
+	}
+	r.bootstrapServers = config.KafkaBrokers
+	r.secretReader = mgr.GetAPIReader()
+	r.anyUsername = config.KafkaAnyUsername
+	// Messenger is shared between reconcilers and is run by manager
+	if r.Messenger == nil {
+		return fmt.Errorf("reporter Messenger must be provided")
+	}
+	// Secrets have labels of their KafkaUser, so they pass the same label selector.
+	// Only their metadata is cached, any change of Secret bumps its resourceVersion.
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&xov1alpha1.KafkaUser{},
+			builder.WithPredicates(labelSelectorPredicate, ignoreUpdateDeletePredicate())).
+		Owns(&corev1.Secret{}, builder.WithPredicates(labelSelectorPredicate), builder.OnlyMetadata).
+		WithOptions(controller.Options{MaxConcurrentReconciles: config.MaxConcurrentReconciles}).
+		Complete(r)
+}
+
+// userClient is part of Kafka cluster client managing SCRAM credentials and ACL bindings of users
+type userClient interface {
+	aclClient
+	UserSCRAMs(ctx context.Context, user string) (map[string]int32, error)
+	UpsertUserSCRAM(ctx context.Context, user, mechanism string, iterations int32, password string) error
+	DeleteUserSCRAM(ctx context.Context, user string, mechanisms ...string) error
+}
+
+// upsertUser would create or update SCRAM credential of KafkaUser, its Secret and ACL bindings
+func (r *KafkaUserReconciler) upsertUser(ctx context.Context, kClient userClient, user *xov1alpha1.KafkaUser, reqLogger logr.Logger) (_ ctrl.Result, retErr error) {
+	// Init status
+	statusMessage := "Succeeded"
+	status := metav1.ConditionTrue
+	reason := ConditionReasonUpdateUser
+	changes := []reporter.Change{}
+	// spec wasn't changed since last reconcile
+	specObserved := observedGeneration(user.Status.Conditions) == user.Generation
+	username, secretName := kafkaUsername(user), userSecretName(user)
+	mechanism, iterations := user.Spec.Mechanism, user.Spec.Iterations
+	if len(mechanism) == 0 {
+		mechanism = kafka.DefaultSCRAMMechanism
+	}
+	if iterations == 0 {
+		iterations = kafka.DefaultSCRAMIterations
+	}
+	notification := fmt.Sprintf("user %s is in sync", username)
+
+	// Defer function to update status
+	defer func() {
+		// Log status update
+		reqLogger.Info(fmt.Sprintf("user %s %s status: %s", username, reason, statusMessage))
+		// Send message to slack, Messenger would filter it by notification level
+		if status == metav1.ConditionFalse {
+			notification = statusMessage
+		}
+		r.Messenger.Send(notification,
+			resultMessageType(status, specObserved && len(changes) != 0),
+			reporter.Object(KindKafkaUser, user.Namespace, user.Name),
+			reporter.Reason(reason),
+			reporter.Diff(changes...))
+		meta.SetStatusCondition(&user.Status.Conditions, metav1.Condition{
+			Type:               ConditionReady,
+			Status:             status,
+			Reason:             reason,
+			Message:            statusMessage,
+			ObservedGeneration: user.Generation,
+		})
+		// we will return error of status update if it is not nil
+		err := r.Status().Update(ctx, user)
+		if err != nil {
+			reqLogger.Info(fmt.Sprintf("Failed to update user status: %v", err))
+			retErr = errors.Join(retErr, err)
+		}
+	}()
+
+	err := r.checkUsername(user)
+	if err != nil {
+		reason = ConditionReasonInvalidSpec
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("invalid kafka user %s: %v", user.Name, err)
+		return ctrl.Result{}, nil
+	}
+	desired, err := userACLs(username, user.Spec.ACLs)
+	if err != nil {
+		reason = ConditionReasonInvalidSpec
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("invalid kafka user %s: %v", user.Name, err)
+		return ctrl.Result{}, nil
+	}
+
+	// password is kept in Secret, it is generated if Secret doesn't have it
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: user.Namespace}}
+	err = r.secretReader.Get(ctx, client.ObjectKeyFromObject(secret), secret)
+	secretFound := err == nil
+	if err != nil && !kerrors.IsNotFound(err) {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't get secret %s of kafka user %s: %v", secretName, user.Name, err)
+		return ctrl.Result{}, nil
+	}
+	if err == nil && !metav1.IsControlledBy(secret, user) {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("secret %s isn't owned by kafka user %s", secretName, user.Name)
+		return ctrl.Result{}, nil
+	}
+	password := string(secret.Data[corev1.BasicAuthPasswordKey])
+	if len(password) == 0 {
+		password, err = generatePassword()
+		if err != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't make password of kafka user %s: %v", user.Name, err)
+			return ctrl.Result{}, nil
+		}
+	}
+	digest := passwordDigest(password)
+
+	credentials, err := kClient.UserSCRAMs(ctx, username)
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't get credential of kafka user %s: %v", user.Name, err)
+		return ctrl.Result{}, nil
+	}
+	// credential is owned once it is in status or in Secret we wrote, others could belong to other objects or be made by hand
+	owned := user.Status.Username == username || (secretFound && string(secret.Data[corev1.BasicAuthUsernameKey]) == username)
+	if !owned && len(credentials) != 0 {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("credential of user %s already exists and isn't owned by kafka user %s", username, user.Name)
+		return ctrl.Result{}, nil
+	}
+	// credential is set again if it is missing or password in Secret was changed
+	existingIterations := credentials[mechanism]
+	if existingIterations != iterations || secret.Annotations[userPasswordDigestAnnotation] != digest {
+		err = kClient.UpsertUserSCRAM(ctx, username, mechanism, iterations, password)
+		if err != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't set credential of kafka user %s: %v", user.Name, err)
+			return ctrl.Result{}, nil
+		}
+		switch {
+		case existingIterations == 0:
+			changes = append(changes, reporter.Change{Field: "credential", New: formatCredential(username, mechanism, iterations)})
+		case existingIterations != iterations:
+			changes = append(changes, reporter.Change{Field: "credential",
+				Old: formatCredential(username, mechanism, existingIterations), New: formatCredential(username, mechanism, iterations)})
+		default:
+			changes = append(changes, reporter.Change{Field: "password", New: "rotated"})
+		}
+	}
+	// previous credential is deleted once username or mechanism was changed
+	previousUser, previousMechanism := user.Status.Username, user.Status.Mechanism
+	user.Status.Username, user.Status.Mechanism = username, mechanism
+	if len(previousUser) != 0 && (previousUser != username || previousMechanism != mechanism) {
+		err = kClient.DeleteUserSCRAM(ctx, previousUser, previousMechanism)
+		if err != nil {
+			user.Status.Username, user.Status.Mechanism = previousUser, previousMechanism
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't delete previous credential of kafka user %s: %v", user.Name, err)
+			return ctrl.Result{}, nil
+		}
+		changes = append(changes, reporter.Change{Field: "credential", Old: formatCredential(previousUser, previousMechanism, 0)})
+	}
+
+	err = r.writeSecret(ctx, user, secret, secretFound, digest, r.secretData(username, mechanism, password))
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't write secret %s of kafka user %s: %v", secretName, user.Name, err)
+		return ctrl.Result{}, nil
+	}
+	if !secretFound {
+		changes = append(changes, reporter.Change{Field: "secret", New: secretName})
+	}
+	// previous Secret is deleted once secret name was changed
+	previousSecret := user.Status.SecretName
+	user.Status.SecretName = secretName
+	if len(previousSecret) != 0 && previousSecret != secretName {
+		err = r.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: previousSecret, Namespace: user.Namespace}})
+		if client.IgnoreNotFound(err) != nil {
+			status = metav1.ConditionFalse
+			statusMessage = fmt.Sprintf("can't delete previous secret %s of kafka user %s: %v", previousSecret, user.Name, err)
+			return ctrl.Result{}, nil
+		}
+		changes = append(changes, reporter.Change{Field: "secret", Old: previousSecret})
+	}
+
+	aclChanges, err := reconcileACLs(ctx, kClient, desired, &user.Status.Bindings)
+	changes = append(changes, aclChanges...)
+	if err != nil {
+		status = metav1.ConditionFalse
+		statusMessage = fmt.Sprintf("can't reconcile ACLs of kafka user %s: %v", user.Name, err)
+		return ctrl.Result{}, nil
+	}
+
+	if len(changes) != 0 {
+		notification = fmt.Sprintf("user %s was changed", username)
+		if specObserved {
+			notification = fmt.Sprintf("user %s drifted from spec and was restored", username)
+		}
+	}
+	return ctrl.Result{
+		RequeueAfter: RevisitIntervalSec * time.Second,
+	}, nil
+}
+
+// deleteUser would delete ACL bindings and SCRAM credential KafkaUser owns and remove finalizer,
+// Secret of KafkaUser is deleted by garbage collector
+func (r *KafkaUserReconciler) deleteUser(ctx context.Context, kClient userClient, user *xov1alpha1.KafkaUser, reqLogger logr.Logger) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(user, UserFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	fields := []reporter.MessageField{
+		reporter.Object(KindKafkaUser, user.Namespace, user.Name),
+		reporter.Reason(ConditionReasonDeleteUser),
+	}
+	err := kClient.DeleteACLs(ctx, user.Status.Bindings)
+	if err != nil {
+		r.Messenger.Send(err.Error(), reporter.ErrorMessage, fields...)
+		return ctrl.Result{}, err
+	}
+	changes := make([]reporter.Change, 0, len(user.Status.Bindings)+1)
+	for _, binding := range user.Status.Bindings {
+		changes = append(changes, reporter.Change{Field: "acl", Old: kafka.FormatACL(binding)})
+	}
+	if len(user.Status.Username) != 0 {
+		err = kClient.DeleteUserSCRAM(ctx, user.Status.Username, user.Status.Mechanism)
+		if err != nil {
+			r.Messenger.Send(err.Error(), reporter.ErrorMessage, fields...)
+			return ctrl.Result{}, err
+		}
+		changes = append(changes, reporter.Change{Field: "credential", Old: formatCredential(user.Status.Username, user.Status.Mechanism, 0)})
+	}
+	if len(changes) != 0 {
+		notification := fmt.Sprintf("user %s was deleted", kafkaUsername(user))
+		reqLogger.Info(notification)
+		r.Messenger.Send(notification, reporter.OKMessage, append(fields, reporter.Diff(changes...))...)
+	}
+	controllerutil.RemoveFinalizer(user, UserFinalizer)
+	return ctrl.Result{}, r.Update(ctx, user)
+}
+
+// writeSecret would create Secret of KafkaUser or update it, if it was changed
+func (r *KafkaUserReconciler) writeSecret(ctx context.Context, user *xov1alpha1.KafkaUser, secret *corev1.Secret, found bool,
+	digest string, data map[string][]byte) error {
+	existing := secret.DeepCopy()
+	if secret.Labels == nil {
+		secret.Labels = map[string]string{}
+	}
+	for k, v := range user.Labels {
+		secret.Labels[k] = v
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[userPasswordDigestAnnotation] = digest
+	secret.Data = data
+	err := controllerutil.SetControllerReference(user, secret, r.Scheme)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return r.Create(ctx, secret)
+	}
+	if equality.Semantic.DeepEqual(existing, secret) {
+		return nil
+	}
+	return r.Update(ctx, secret)
+}
+
+// secretData would return credential and connection info written into Secret of KafkaUser
+func (r *KafkaUserReconciler) secretData(username, mechanism, password string) map[string][]byte {
+	jaas := fmt.Sprintf("org.apache.kafka.common.security.scram.ScramLoginModule required username=%q password=%q;", username, password)
+	return map[string][]byte{
+		corev1.BasicAuthUsernameKey: []byte(username),
+		corev1.BasicAuthPasswordKey: []byte(password),
+		userSecretMechanismKey:      []byte(mechanism),
+		userSecretBootstrapKey:      []byte(r.bootstrapServers),
+		userSecretJAASKey:           []byte(jaas),
+	}
+}
+
+// kafkaUsername would return user name of KafkaUser in Kafka cluster,
+// default one is qualified by namespace, so KafkaUsers of different namespaces don't share it
+func kafkaUsername(user *xov1alpha1.KafkaUser) string {
+	if len(user.Spec.Username) != 0 {
+		return user.Spec.Username
+	}
+	return fmt.Sprintf("%s.%s", user.Namespace, user.Name)
+}
+
+// checkUsername would return error when username set in spec isn't prefixed by namespace of KafkaUser,
+// so KafkaUser can't take username ACLs of other namespaces are granted to, unless operator allows any username
+func (r *KafkaUserReconciler) checkUsername(user *xov1alpha1.KafkaUser) error {
+	if r.anyUsername || len(user.Spec.Username) == 0 {
+		return nil
+	}
+	prefix := user.Namespace + "."
+	if !strings.HasPrefix(user.Spec.Username, prefix) || len(user.Spec.Username) == len(prefix) {
+		return fmt.Errorf("username %s must be prefixed by namespace %s", user.Spec.Username, prefix)
+	}
+	return nil
+}
+
+// userSecretName would return name of Secret of KafkaUser
+func userSecretName(user *xov1alpha1.KafkaUser) string {
+	if len(user.Spec.SecretName) != 0 {
+		return user.Spec.SecretName
+	}
+	return user.Name
+}
+
+// userACLs would return ACL bindings of inline ACL rules of KafkaUser, rules could overlap
+func userACLs(username string, rules []xov1alpha1.KafkaUserACL) ([]xov1alpha1.ACLBinding, error) {
+	bindings := []xov1alpha1.ACLBinding{}
+	for i, rule := range rules {
+		ruleBindings, err := kafka.DesiredACLs(&xov1alpha1.KafkaACLSpec{
+			Principal:    "User:" + username,
+			Host:         rule.Host,
+			ResourceType: rule.ResourceType,
+			ResourceName: rule.ResourceName,
+			PatternType:  rule.PatternType,
+			Operations:   rule.Operations,
+			Permission:   rule.Permission,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("acls[%d]: %w", i, err)
+		}
+		bindings = append(bindings, subtractACLs(ruleBindings, bindings)...)
+	}
+	return bindings, nil
+}
+
+// generatePassword would return random password of user
+func generatePassword() (string, error) {
+	b := make([]byte, userPasswordBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("can't generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// passwordDigest would return digest of password, it is compared to find out if password was changed
+func passwordDigest(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatCredential would format SCRAM credential of user for notifications, iterations are omitted if 0
+func formatCredential(username, mechanism string, iterations int32) string {
+	if iterations == 0 {
+		return fmt.Sprintf("%s %s", username, mechanism)
+	}
+	return fmt.Sprintf("%s %s (%d iterations)", username, mechanism, iterations)
+}
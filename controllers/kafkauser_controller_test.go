@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/reporter"
+)
+
+// fakeCredential is SCRAM credential of fakeCluster
+type fakeCredential struct {
+	iterations int32
+	password   string
+}
+
+func (f *fakeCluster) UserSCRAMs(_ context.Context, user string) (map[string]int32, error) {
+	credentials := map[string]int32{}
+	for mechanism, credential := range f.users[user] {
+		credentials[mechanism] = credential.iterations
+	}
+	return credentials, nil
+}
+
+func (f *fakeCluster) UpsertUserSCRAM(_ context.Context, user, mechanism string, iterations int32, password string) error {
+	if f.users[user] == nil {
+		f.users[user] = map[string]fakeCredential{}
+	}
+	f.users[user][mechanism] = fakeCredential{iterations: iterations, password: password}
+	f.upserts++
+	return nil
+}
+
+func (f *fakeCluster) DeleteUserSCRAM(_ context.Context, user string, mechanisms ...string) error {
+	for _, mechanism := range mechanisms {
+		delete(f.users[user], mechanism)
+	}
+	if len(f.users[user]) == 0 {
+		delete(f.users, user)
+	}
+	return nil
+}
+
+func (f *fakeClient) Delete(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	key := objectKey(obj, client.ObjectKeyFromObject(obj))
+	if _, ok := f.objects[key]; !ok {
+		return kerrors.NewNotFound(schema.GroupResource{}, obj.GetName())
+	}
+	delete(f.objects, key)
+	return nil
+}
+
+func (f *fakeClient) Status() client.SubResourceWriter {
+	return &fakeStatusWriter{client: f}
+}
+
+// fakeStatusWriter would update status of objects in fakeClient
+type fakeStatusWriter struct {
+	client.SubResourceWriter
+	client *fakeClient
+}
+
+func (w *fakeStatusWriter) Update(ctx context.Context, obj client.Object, _ ...client.SubResourceUpdateOption) error {
+	return w.client.Update(ctx, obj)
+}
+
+func newUserReconciler(t *testing.T, objects ...client.Object) (*KafkaUserReconciler, *fakeClient) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, xov1alpha1.AddToScheme(scheme))
+	messenger, err := reporter.New("")
+	require.NoError(t, err)
+	c := &fakeClient{objects: map[string]client.Object{}}
+	for _, obj := range objects {
+		require.NoError(t, c.Create(context.Background(), obj))
+	}
+	return &KafkaUserReconciler{
+		Client:           c,
+		Scheme:           scheme,
+		Messenger:        messenger,
+		bootstrapServers: "kafka:9092",
+		secretReader:     c,
+	}, c
+}
+
+func testUser() *xov1alpha1.KafkaUser {
+	return &xov1alpha1.KafkaUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "orders", Namespace: "team", UID: "uid", Generation: 1,
+			Finalizers: []string{UserFinalizer}},
+		Spec: xov1alpha1.KafkaUserSpec{
+			ACLs: []xov1alpha1.KafkaUserACL{
+				{ResourceType: "TOPIC", ResourceName: "orders", Operations: []string{"READ"}},
+			},
+		},
+	}
+}
+
+// reconcileUser would run upsertUser and return Ready condition and Secret of KafkaUser
+func reconcileUser(t *testing.T, r *KafkaUserReconciler, kClient userClient, user *xov1alpha1.KafkaUser) (*metav1.Condition, *corev1.Secret) {
+	ctx := context.Background()
+	_, err := r.upsertUser(ctx, kClient, user, log.FromContext(ctx))
+	require.NoError(t, err)
+	secret := &corev1.Secret{}
+	err = r.Get(ctx, client.ObjectKey{Namespace: user.Namespace, Name: userSecretName(user)}, secret)
+	if kerrors.IsNotFound(err) {
+		secret = nil
+	} else {
+		require.NoError(t, err)
+	}
+	return meta.FindStatusCondition(user.Status.Conditions, ConditionReady), secret
+}
+
+func TestKafkaUserReconciler_upsertUser(t *testing.T) {
+	r, c := newUserReconciler(t)
+	cluster := newFakeCluster()
+	user := testUser()
+
+	// credential is created with generated password and written into Secret
+	cond, secret := reconcileUser(t, r, cluster, user)
+	require.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+	require.NotNil(t, secret)
+	password := string(secret.Data[corev1.BasicAuthPasswordKey])
+	assert.Len(t, password, 43)
+	assert.Equal(t, "team.orders", string(secret.Data[corev1.BasicAuthUsernameKey]))
+	assert.Equal(t, "kafka:9092", string(secret.Data[userSecretBootstrapKey]))
+	assert.Equal(t, "SCRAM-SHA-512", string(secret.Data[userSecretMechanismKey]))
+	assert.True(t, metav1.IsControlledBy(secret, user))
+	assert.Equal(t, map[string]fakeCredential{"SCRAM-SHA-512": {iterations: 8192, password: password}}, cluster.users["team.orders"])
+	assert.Equal(t, "team.orders", user.Status.Username)
+	assert.Equal(t, "orders", user.Status.SecretName)
+	assert.Len(t, user.Status.Bindings, 1)
+	assert.Equal(t, "User:team.orders", user.Status.Bindings[0].Principal)
+
+	// nothing is changed if Secret wasn't changed
+	cond, _ = reconcileUser(t, r, cluster, user)
+	require.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+	assert.Equal(t, 1, cluster.upserts)
+
+	// password changed in Secret is set in Kafka cluster
+	secret.Data[corev1.BasicAuthPasswordKey] = []byte("rotated-password")
+	require.NoError(t, c.Update(context.Background(), secret))
+	cond, secret = reconcileUser(t, r, cluster, user)
+	require.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+	assert.Equal(t, "rotated-password", cluster.users["team.orders"]["SCRAM-SHA-512"].password)
+	assert.Equal(t, passwordDigest("rotated-password"), secret.Annotations[userPasswordDigestAnnotation])
+	assert.Contains(t, string(secret.Data[userSecretJAASKey]), `password="rotated-password"`)
+
+	// password removed from Secret is generated again
+	delete(secret.Data, corev1.BasicAuthPasswordKey)
+	require.NoError(t, c.Update(context.Background(), secret))
+	cond, secret = reconcileUser(t, r, cluster, user)
+	require.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+	password = string(secret.Data[corev1.BasicAuthPasswordKey])
+	assert.NotEqual(t, "rotated-password", password)
+	assert.Equal(t, password, cluster.users["team.orders"]["SCRAM-SHA-512"].password)
+	assert.Equal(t, 3, cluster.upserts)
+
+	// credential of previous mechanism is deleted
+	user.Spec.Mechanism = "SCRAM-SHA-256"
+	cond, _ = reconcileUser(t, r, cluster, user)
+	require.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+	assert.Equal(t, map[string]fakeCredential{"SCRAM-SHA-256": {iterations: 8192, password: password}}, cluster.users["team.orders"])
+}
+
+func TestKafkaUserReconciler_upsertUserNotOwned(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    func(*xov1alpha1.KafkaUser)
+		objects []client.Object
+		wantMsg string
+	}{
+		{
+			name:    "credential of other user",
+			user:    func(u *xov1alpha1.KafkaUser) { u.Spec.Username = "team.admin" },
+			wantMsg: "credential of user team.admin already exists and isn't owned by kafka user orders",
+		},
+		{
+			name: "secret of other object",
+			user: func(u *xov1alpha1.KafkaUser) { u.Spec.SecretName = "kafka" },
+			objects: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "kafka", Namespace: "team"},
+				Data: map[string][]byte{corev1.BasicAuthUsernameKey: []byte("team.admin")}}},
+			wantMsg: "secret kafka isn't owned by kafka user orders",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newUserReconciler(t, tt.objects...)
+			cluster := newFakeCluster()
+			cluster.users["team.admin"] = map[string]fakeCredential{"SCRAM-SHA-512": {iterations: 4096, password: "admin"}}
+			user := testUser()
+			tt.user(user)
+
+			cond, _ := reconcileUser(t, r, cluster, user)
+			assert.Equal(t, metav1.ConditionFalse, cond.Status)
+			assert.Equal(t, tt.wantMsg, cond.Message)
+			assert.Equal(t, map[string]fakeCredential{"SCRAM-SHA-512": {iterations: 4096, password: "admin"}}, cluster.users["team.admin"])
+			assert.Zero(t, cluster.upserts)
+			assert.Empty(t, user.Status.Username)
+		})
+	}
+}
+
+func TestKafkaUserReconciler_upsertUserUsername(t *testing.T) {
+	tests := []struct {
+		name        string
+		username    string
+		anyUsername bool
+		wantMsg     string
+	}{
+		{
+			name:     "prefixed by namespace",
+			username: "team.orders-service",
+		},
+		{
+			name:     "other namespace",
+			username: "billing.orders",
+			wantMsg:  "invalid kafka user orders: username billing.orders must be prefixed by namespace team.",
+		},
+		{
+			name:     "only namespace",
+			username: "team.",
+			wantMsg:  "invalid kafka user orders: username team. must be prefixed by namespace team.",
+		},
+		{
+			name:        "any username is allowed",
+			username:    "orders-service",
+			anyUsername: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newUserReconciler(t)
+			r.anyUsername = tt.anyUsername
+			cluster := newFakeCluster()
+			user := testUser()
+			user.Spec.Username = tt.username
+
+			cond, _ := reconcileUser(t, r, cluster, user)
+			if len(tt.wantMsg) != 0 {
+				assert.Equal(t, metav1.ConditionFalse, cond.Status)
+				assert.Equal(t, ConditionReasonInvalidSpec, cond.Reason)
+				assert.Equal(t, tt.wantMsg, cond.Message)
+				assert.Empty(t, cluster.users)
+				assert.Empty(t, cluster.list())
+				return
+			}
+			assert.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+			assert.Contains(t, cluster.users, tt.username)
+		})
+	}
+}
+
+func TestKafkaUserReconciler_deleteUser(t *testing.T) {
+	r, c := newUserReconciler(t)
+	cluster := newFakeCluster()
+	user := testUser()
+	cond, _ := reconcileUser(t, r, cluster, user)
+	require.Equal(t, metav1.ConditionTrue, cond.Status, cond.Message)
+	// credential and binding of other user aren't deleted
+	other := testBinding("READ")
+	cluster.bindings[other] = true
+	cluster.users["admin"] = map[string]fakeCredential{"SCRAM-SHA-512": {iterations: 4096, password: "admin"}}
+
+	ctx := context.Background()
+	_, err := r.deleteUser(ctx, cluster, user, log.FromContext(ctx))
+	require.NoError(t, err)
+	assert.Equal(t, []xov1alpha1.ACLBinding{other}, cluster.list())
+	assert.NotContains(t, cluster.users, "team.orders")
+	assert.Contains(t, cluster.users, "admin")
+	assert.False(t, controllerutil.ContainsFinalizer(user, UserFinalizer))
+	stored := &xov1alpha1.KafkaUser{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(user), stored))
+	assert.Empty(t, stored.Finalizers)
+}
+
+func TestUserACLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []xov1alpha1.KafkaUserACL
+		want    []xov1alpha1.ACLBinding
+		wantErr string
+	}{
+		{
+			name: "no rules",
+			want: []xov1alpha1.ACLBinding{},
+		},
+		{
+			name: "overlapping rules",
+			rules: []xov1alpha1.KafkaUserACL{
+				{ResourceType: "TOPIC", ResourceName: "orders", Operations: []string{"READ", "DESCRIBE"}},
+				{ResourceType: "TOPIC", ResourceName: "orders", Operations: []string{"READ", "WRITE"}},
+			},
+			want: []xov1alpha1.ACLBinding{testBinding("DESCRIBE"), testBinding("READ"), testBinding("WRITE")},
+		},
+		{
+			name: "invalid rule",
+			rules: []xov1alpha1.KafkaUserACL{
+				{ResourceType: "TOPIC", ResourceName: "orders", Operations: []string{"READ"}},
+				{ResourceType: "GROUP", Operations: []string{"READ"}},
+			},
+			wantErr: "acls[1]: resource name of GROUP must be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := userACLs("orders", tt.rules)
+			if len(tt.wantErr) != 0 {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestKafkaUsername(t *testing.T) {
+	user := testUser()
+	assert.Equal(t, "team.orders", kafkaUsername(user))
+	user.Namespace = "billing"
+	assert.Equal(t, "billing.orders", kafkaUsername(user))
+	user.Spec.Username = "orders-service"
+	assert.Equal(t, "orders-service", kafkaUsername(user))
+}
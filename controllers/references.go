@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+)
+
+const (
+	ConditionReasonWaitingForReferences = "WaitingForReferences"
+	// ReferencesRequeueIntervalSec is how often we check if references were registered
+	ReferencesRequeueIntervalSec = 60
+	// schemaRefIndexKey is index of KafkaSchemas by KafkaSchemas they reference
+	schemaRefIndexKey = ".spec.references.schemaRef"
+)
+
+// errReferenceNotReady is returned when referenced schema isn't registered yet
+var errReferenceNotReady = errors.New("referenced schema is not registered yet")
+
+// resolveReferences would resolve subjects and versions of schema references.
+// Subjects which aren't qualified with context are in context of schema.
+func (r *KafkaSchemaReconciler) resolveReferences(ctx context.Context, registry schemaregistry.Backend,
+	schema *xov1alpha1.KafkaSchema) ([]xov1alpha1.ResolvedReference, error) {
+	resolved := make([]xov1alpha1.ResolvedReference, 0, len(schema.Spec.References))
+	for _, ref := range schema.Spec.References {
+		subject := schemaregistry.ContextSubject(schema.Spec.Context, ref.Subject)
+		if ref.SchemaRef != nil {
+			refSchema := &xov1alpha1.KafkaSchema{}
+			err := r.Get(ctx, schemaRefKey(schema.Namespace, ref.SchemaRef), refSchema)
+			if kerrors.IsNotFound(err) {
+				return nil, fmt.Errorf("%w: KafkaSchema %s not found", errReferenceNotReady, schemaRefKey(schema.Namespace, ref.SchemaRef))
+			}
+			if err != nil {
+				return nil, fmt.Errorf("can't get referenced KafkaSchema %s: %w", schemaRefKey(schema.Namespace, ref.SchemaRef), err)
+			}
+			if !reflect.DeepEqual(refSchema.Spec.RegistryRef, schema.Spec.RegistryRef) {
+				return nil, fmt.Errorf("referenced KafkaSchema %s is registered in other schema registry", schemaRefKey(schema.Namespace, ref.SchemaRef))
+			}
+			if !schemaRegistered(refSchema) {
+				return nil, fmt.Errorf("%w: KafkaSchema %s is not Ready", errReferenceNotReady, schemaRefKey(schema.Namespace, ref.SchemaRef))
+			}
+			subject = schemaSubject(refSchema)
+		}
+		version := ref.Version
+		if version == 0 {
+			latest, err := registry.LatestVersion(ctx, subject)
+			if errors.Is(err, schemaregistry.ErrSubjectNotFound) {
+				return nil, fmt.Errorf("%w: subject %s not found", errReferenceNotReady, subject)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("can't get latest version of referenced subject %s: %w", subject, err)
+			}
+			version = latest
+		}
+		resolved = append(resolved, xov1alpha1.ResolvedReference{
+			Name:    ref.Name,
+			Subject: subject,
+			Version: version,
+		})
+	}
+	return resolved, nil
+}
+
+// schemaRegistered is true when current spec of schema was registered
+func schemaRegistered(schema *xov1alpha1.KafkaSchema) bool {
+	ready := meta.FindStatusCondition(schema.Status.Conditions, ConditionReady)
+	return ready != nil && ready.Status == metav1.ConditionTrue && ready.ObservedGeneration == schema.Generation
+}
+
+// schemaRefKey would return namespaced name of referenced KafkaSchema
+func schemaRefKey(namespace string, ref *xov1alpha1.KafkaSchemaRef) types.NamespacedName {
+	if len(ref.Namespace) != 0 {
+		namespace = ref.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}
+}
+
+// indexSchemaRefs would index KafkaSchema by KafkaSchemas it references
+func indexSchemaRefs(obj client.Object) []string {
+	schema, ok := obj.(*xov1alpha1.KafkaSchema)
+	if !ok {
+		return nil
+	}
+	keys := []string{}
+	for _, ref := range schema.Spec.References {
+		if ref.SchemaRef != nil {
+			keys = append(keys, schemaRefKey(schema.Namespace, ref.SchemaRef).String())
+		}
+	}
+	return keys
+}
+
+// dependentSchemas would return requests for KafkaSchemas referencing changed one
+func (r *KafkaSchemaReconciler) dependentSchemas(ctx context.Context, obj client.Object) []reconcile.Request {
+	dependents := &xov1alpha1.KafkaSchemaList{}
+	err := r.List(ctx, dependents,
+		client.MatchingFields{schemaRefIndexKey: client.ObjectKeyFromObject(obj).String()},
+		client.MatchingLabelsSelector{Selector: r.labelSelector})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "can't list KafkaSchemas referencing", "kafkaschema", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(dependents.Items))
+	for i := range dependents.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&dependents.Items[i])})
+	}
+	return requests
+}
+
+// schemaRegisteredPredicate would pass only KafkaSchemas which spec, references or new version were just registered,
+// so that schemas referencing them are registered with new version
+func schemaRegisteredPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(_ event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSchema, ok := e.ObjectOld.(*xov1alpha1.KafkaSchema)
+			if !ok {
+				return false
+			}
+			newSchema, ok := e.ObjectNew.(*xov1alpha1.KafkaSchema)
+			if !ok {
+				return false
+			}
+			if !schemaRegistered(newSchema) {
+				return false
+			}
+			// new version was registered, i.e. with other references, schema changed in ConfigMap or restored,
+			// and its dependents must follow
+			return !schemaRegistered(oldSchema) ||
+				oldSchema.Status.Version != newSchema.Status.Version ||
+				oldSchema.Status.ID != newSchema.Status.ID ||
+				!reflect.DeepEqual(oldSchema.Status.References, newSchema.Status.References)
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
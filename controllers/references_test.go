@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+func registeredSchema(version, id int) *xov1alpha1.KafkaSchema {
+	schema := &xov1alpha1.KafkaSchema{ObjectMeta: metav1.ObjectMeta{Name: "orders-value", Generation: 1}}
+	schema.Status.Conditions = []metav1.Condition{{Type: ConditionReady, Status: metav1.ConditionTrue, ObservedGeneration: 1}}
+	schema.Status.Version, schema.Status.ID = version, id
+	return schema
+}
+
+func TestSchemaRegisteredPredicate(t *testing.T) {
+	p := schemaRegisteredPredicate()
+	registered := registeredSchema(1, 10)
+	notRegistered := registeredSchema(1, 10)
+	notRegistered.Status.Conditions[0].Status = metav1.ConditionFalse
+	withReferences := registeredSchema(1, 10)
+	withReferences.Status.References = []xov1alpha1.ResolvedReference{{Name: "money.proto", Subject: "money", Version: 2}}
+
+	tests := []struct {
+		name     string
+		old, new *xov1alpha1.KafkaSchema
+		want     bool
+	}{
+		{name: "nothing registered", old: registered, new: registeredSchema(1, 10), want: false},
+		{name: "spec registered", old: notRegistered, new: registered, want: true},
+		{name: "not registered", old: registered, new: notRegistered, want: false},
+		{name: "references registered", old: registered, new: withReferences, want: true},
+		// schema changed in ConfigMap or restored is registered without new generation
+		{name: "new version registered", old: registered, new: registeredSchema(2, 11), want: true},
+		{name: "new ID registered", old: registered, new: registeredSchema(1, 11), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: tt.new}))
+		})
+	}
+}
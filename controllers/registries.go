@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+)
+
+const (
+	ConditionReasonWaitingForRegistry = "WaitingForRegistry"
+	// credentialsCAKey is key of credentials Secret with CA certificates of Schema Registry
+	credentialsCAKey = "ca.crt"
+	// credentialsTokenKey is key of credentials Secret with bearer token
+	credentialsTokenKey = "token"
+)
+
+// errRegistryNotFound is returned when KafkaSchemaRegistry referenced by KafkaSchema doesn't exist
+var errRegistryNotFound = errors.New("referenced schema registry doesn't exist")
+
+// SchemaRegistries makes clients of Schema Registries described by KafkaSchemaRegistry objects,
+// they are shared by reconcilers. Registry operator is configured with is used for objects without URL.
+type SchemaRegistries struct {
+	reader client.Reader
+	// secretReader reads credentials Secrets of registries, they aren't cached
+	secretReader client.Reader
+	config       *env.Config
+	// defaultBackend is client of registry operator is configured with
+	defaultBackend schemaregistry.Backend
+	options        []schemaregistry.Option
+	// mu guards backends
+	mu sync.Mutex
+	// backends are clients of registries by name of KafkaSchemaRegistry
+	backends map[string]*cachedBackend
+}
+
+// cachedBackend is client of registry, it is made again when KafkaSchemaRegistry or its credentials Secret is changed
+type cachedBackend struct {
+	// version is resourceVersion of KafkaSchemaRegistry and its credentials Secret client was made of
+	version string
+	backend schemaregistry.Backend
+}
+
+// NewSchemaRegistries would make SchemaRegistries of manager, changes made by their clients are audited
+func NewSchemaRegistries(mgr ctrl.Manager, config *env.Config, auditor *audit.Auditor) (*SchemaRegistries, error) {
+	options := []schemaregistry.Option{schemaregistry.Auditor(auditor)}
+	defaultBackend, err := schemaregistry.NewBackend(config, options...)
+	if err != nil {
+		return nil, err
+	}
+	return &SchemaRegistries{
+		reader:         mgr.GetClient(),
+		secretReader:   mgr.GetAPIReader(),
+		config:         config,
+		defaultBackend: defaultBackend,
+		options:        options,
+		backends:       map[string]*cachedBackend{},
+	}, nil
+}
+
+// Default would return client of Schema Registry operator is configured with
+func (s *SchemaRegistries) Default() schemaregistry.Backend {
+	return s.defaultBackend
+}
+
+// forSchema would return client of Schema Registry KafkaSchema is registered in
+func (s *SchemaRegistries) forSchema(ctx context.Context, schema *xov1alpha1.KafkaSchema) (schemaregistry.Backend, error) {
+	if schema.Spec.RegistryRef == nil {
+		return s.defaultBackend, nil
+	}
+	registry := &xov1alpha1.KafkaSchemaRegistry{}
+	err := s.reader.Get(ctx, types.NamespacedName{Name: schema.Spec.RegistryRef.Name}, registry)
+	if kerrors.IsNotFound(err) {
+		s.forget(schema.Spec.RegistryRef.Name)
+		return nil, fmt.Errorf("%w: KafkaSchemaRegistry %s not found", errRegistryNotFound, schema.Spec.RegistryRef.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get referenced KafkaSchemaRegistry %s: %w", schema.Spec.RegistryRef.Name, err)
+	}
+	return s.forRegistry(ctx, registry)
+}
+
+// forRegistry would return client of Schema Registry described by KafkaSchemaRegistry.
+// Credentials of registry operator is configured with are never sent to other registries.
+// Client is made once for each version of KafkaSchemaRegistry and its credentials Secret.
+func (s *SchemaRegistries) forRegistry(ctx context.Context, registry *xov1alpha1.KafkaSchemaRegistry) (schemaregistry.Backend, error) {
+	if len(registry.Spec.URL) == 0 {
+		return s.defaultBackend, nil
+	}
+	var secret *corev1.Secret
+	version := registry.ResourceVersion
+	if ref := registry.Spec.CredentialsSecretRef; ref != nil {
+		if len(ref.Namespace) == 0 {
+			return nil, fmt.Errorf("namespace of credentials Secret %s of KafkaSchemaRegistry %s must be set", ref.Name, registry.Name)
+		}
+		secret = &corev1.Secret{}
+		err := s.secretReader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret)
+		if err != nil {
+			return nil, fmt.Errorf("can't get credentials Secret %s/%s of KafkaSchemaRegistry %s: %w", ref.Namespace, ref.Name, registry.Name, err)
+		}
+		version += "/" + secret.ResourceVersion
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.backends[registry.Name]
+	if ok && cached.version == version {
+		return cached.backend, nil
+	}
+	backend, err := s.newBackend(registry, secret)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		cached.backend.Close()
+	}
+	s.backends[registry.Name] = &cachedBackend{version: version, backend: backend}
+	return backend, nil
+}
+
+// newBackend would make client of Schema Registry at URL of KafkaSchemaRegistry with credentials of Secret
+func (s *SchemaRegistries) newBackend(registry *xov1alpha1.KafkaSchemaRegistry, secret *corev1.Secret) (schemaregistry.Backend, error) {
+	config := *s.config
+	config.SchemaRegistryURL = registry.Spec.URL
+	config.SchemaRegistryUsername, config.SchemaRegistryPassword, config.SchemaRegistryTokenFile = "", "", ""
+	config.SchemaRegistryCAFile, config.SchemaRegistryCertFile, config.SchemaRegistryKeyFile = "", "", ""
+	options := append([]schemaregistry.Option{}, s.options...)
+	if secret != nil {
+		username, password := secret.Data[corev1.BasicAuthUsernameKey], secret.Data[corev1.BasicAuthPasswordKey]
+		if len(username) != 0 || len(password) != 0 {
+			options = append(options, schemaregistry.BasicAuth(string(username), string(password)))
+		}
+		if token, ok := secret.Data[credentialsTokenKey]; ok {
+			options = append(options, schemaregistry.BearerToken(string(token)))
+		}
+		if ca, ok := secret.Data[credentialsCAKey]; ok {
+			options = append(options, schemaregistry.CA(ca))
+		}
+		cert, key := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+		if len(cert) != 0 || len(key) != 0 {
+			options = append(options, schemaregistry.ClientKeyPair(cert, key))
+		}
+	}
+	backend, err := schemaregistry.NewBackend(&config, options...)
+	if err != nil {
+		return nil, fmt.Errorf("can't make client of schema registry %s: %w", registry.Spec.URL, err)
+	}
+	return backend, nil
+}
+
+// forget would close client of KafkaSchemaRegistry which was deleted
+func (s *SchemaRegistries) forget(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.backends[name]; ok {
+		cached.backend.Close()
+		delete(s.backends, name)
+	}
+}
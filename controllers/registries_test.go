@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+)
+
+// fakeClient is Kubernetes client keeping objects in memory, it only has methods used by tests
+type fakeClient struct {
+	client.Client
+	objects map[string]client.Object
+}
+
+func objectKey(obj client.Object, key client.ObjectKey) string {
+	return fmt.Sprintf("%T/%s", obj, key)
+}
+
+func (f *fakeClient) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	stored, ok := f.objects[objectKey(obj, key)]
+	if !ok {
+		return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(stored.DeepCopyObject()).Elem())
+	return nil
+}
+
+func (f *fakeClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	f.objects[objectKey(obj, client.ObjectKeyFromObject(obj))] = obj.DeepCopyObject().(client.Object)
+	return nil
+}
+
+func (f *fakeClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	f.objects[objectKey(obj, client.ObjectKeyFromObject(obj))] = obj.DeepCopyObject().(client.Object)
+	return nil
+}
+
+func TestSchemaRegistries_forRegistry(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "registry", Name: "credentials", ResourceVersion: "1"},
+		Data:       map[string][]byte{corev1.BasicAuthUsernameKey: []byte("orders"), corev1.BasicAuthPasswordKey: []byte("secret")},
+	}
+	c := &fakeClient{objects: map[string]client.Object{}}
+	require.NoError(t, c.Create(context.Background(), secret))
+	s := &SchemaRegistries{secretReader: c, config: &env.Config{}, backends: map[string]*cachedBackend{}}
+	registry := &xov1alpha1.KafkaSchemaRegistry{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps", ResourceVersion: "1"},
+		Spec: xov1alpha1.KafkaSchemaRegistrySpec{URL: "http://registry:8081",
+			CredentialsSecretRef: &corev1.SecretReference{Namespace: "registry", Name: "credentials"}},
+	}
+
+	backend, err := s.forRegistry(context.Background(), registry)
+	require.NoError(t, err)
+	cached, err := s.forRegistry(context.Background(), registry)
+	require.NoError(t, err)
+	assert.Same(t, backend, cached, "client is made once for same versions")
+
+	secret.ResourceVersion = "2"
+	require.NoError(t, c.Update(context.Background(), secret))
+	rotated, err := s.forRegistry(context.Background(), registry)
+	require.NoError(t, err)
+	assert.NotSame(t, backend, rotated, "client is made again when credentials Secret is changed")
+
+	registry.ResourceVersion = "2"
+	changed, err := s.forRegistry(context.Background(), registry)
+	require.NoError(t, err)
+	assert.NotSame(t, rotated, changed, "client is made again when KafkaSchemaRegistry is changed")
+	assert.Equal(t, "2/2", s.backends["apps"].version)
+
+	s.forget("apps")
+	assert.Empty(t, s.backends)
+}
+
+func TestSchemaRegistries_newBackend(t *testing.T) {
+	s := &SchemaRegistries{config: &env.Config{}, backends: map[string]*cachedBackend{}}
+	registry := &xov1alpha1.KafkaSchemaRegistry{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec:       xov1alpha1.KafkaSchemaRegistrySpec{URL: "http://registry:8081"},
+	}
+
+	_, err := s.newBackend(registry, &corev1.Secret{Data: map[string][]byte{credentialsTokenKey: []byte("token")}})
+	require.NoError(t, err)
+	_, err = s.newBackend(registry, &corev1.Secret{Data: map[string][]byte{
+		corev1.BasicAuthUsernameKey: []byte("orders"), corev1.BasicAuthPasswordKey: []byte("secret"), credentialsTokenKey: []byte("token")}})
+	require.Error(t, err, "basic auth and bearer token can't be used together")
+	_, err = s.newBackend(registry, &corev1.Secret{Data: map[string][]byte{corev1.TLSCertKey: []byte("cert")}})
+	require.Error(t, err, "client certificate must have key")
+}
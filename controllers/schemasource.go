@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+)
+
+const (
+	ConditionReasonLoadSchema = "LoadSchema"
+	// schemaSourceIndexKey is index of KafkaSchemas by ConfigMaps they are loaded from
+	schemaSourceIndexKey = ".spec.schemaFrom"
+)
+
+var (
+	// errInvalidSchemaSource is returned when neither or both of schema and schemaFrom are set
+	errInvalidSchemaSource = errors.New("invalid schema source")
+	// errSchemaSourceNotFound is returned when ConfigMap with schema or its key doesn't exist,
+	// KafkaSchema would be reconciled again when it is created
+	errSchemaSourceNotFound = errors.New("schema source not found")
+)
+
+// loadSchema would return copy of spec with schema loaded from ConfigMap, and Protobuf
+// imports loaded from the same ConfigMap by import path. Inline schema is returned as it is.
+func (r *KafkaSchemaReconciler) loadSchema(ctx context.Context, schema *xov1alpha1.KafkaSchema) (*xov1alpha1.KafkaSchemaSpec, map[string]string, error) {
+	spec := schema.Spec.DeepCopy()
+	source := spec.SchemaFrom
+	if source == nil {
+		if len(spec.Schema) == 0 {
+			return nil, nil, fmt.Errorf("%w: either schema or schemaFrom must be set", errInvalidSchemaSource)
+		}
+		return spec, nil, nil
+	}
+	if len(spec.Schema) != 0 {
+		return nil, nil, fmt.Errorf("%w: schema and schemaFrom can't be set both", errInvalidSchemaSource)
+	}
+	if source.ConfigMapKeyRef == nil {
+		return nil, nil, fmt.Errorf("%w: schemaFrom must have configMapKeyRef", errInvalidSchemaSource)
+	}
+	if len(source.Imports) != 0 && schemaregistry.SchemaType(spec) != schemaregistry.SchemaTypeProtobuf {
+		return nil, nil, fmt.Errorf("%w: imports are supported only by %s schemas", errInvalidSchemaSource, schemaregistry.SchemaTypeProtobuf)
+	}
+	ref := source.ConfigMapKeyRef
+	data, err := r.sourceData(ctx, schema.Namespace, ref.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec.Schema, err = sourceKey(ref.Name, ref.Key, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	imports := make(map[string]string, len(source.Imports))
+	for _, imp := range source.Imports {
+		imports[imp.Name], err = sourceKey(ref.Name, imp.Key, data)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return spec, imports, nil
+}
+
+// sourceData would return all data of ConfigMap schema is loaded from.
+// It is read from API server, so ConfigMaps of cluster aren't cached.
+func (r *KafkaSchemaReconciler) sourceData(ctx context.Context, namespace, name string) (map[string]string, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.sourceReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, configMap)
+	if kerrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%w: ConfigMap %s doesn't exist", errSchemaSourceNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get ConfigMap %s: %w", name, err)
+	}
+	data := make(map[string]string, len(configMap.Data)+len(configMap.BinaryData))
+	for key, value := range configMap.BinaryData {
+		data[key] = string(value)
+	}
+	for key, value := range configMap.Data {
+		data[key] = value
+	}
+	return data, nil
+}
+
+// sourceKey would return value of key of ConfigMap data
+func sourceKey(name, key string, data map[string]string) (string, error) {
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: ConfigMap %s doesn't have key %s", errSchemaSourceNotFound, name, key)
+	}
+	return value, nil
+}
+
+// importSubject would return subject Protobuf import of KafkaSchema is registered under, in context of schema.
+// Subject is owned by KafkaSchema, so imports of the same path with different content don't conflict.
+func importSubject(schema *xov1alpha1.KafkaSchema, path string) string {
+	return schemaregistry.ContextSubject(schema.Spec.Context, fmt.Sprintf("%s.%s/%s", schema.Namespace, schema.Name, path))
+}
+
+// resolveImports would return references to Protobuf imports under subjects of KafkaSchema named by their import paths.
+// Imports are registered first, unless schema is validate only, then they are only looked up.
+func (r *KafkaSchemaReconciler) resolveImports(ctx context.Context, registry schemaregistry.Backend, schema *xov1alpha1.KafkaSchema,
+	spec *xov1alpha1.KafkaSchemaSpec, imports map[string]string) ([]xov1alpha1.ResolvedReference, error) {
+	if spec.SchemaFrom == nil {
+		return nil, nil
+	}
+	references := make([]xov1alpha1.ResolvedReference, 0, len(spec.SchemaFrom.Imports))
+	for _, imp := range spec.SchemaFrom.Imports {
+		subject := importSubject(schema, imp.Name)
+		importSpec := &xov1alpha1.KafkaSchemaSpec{
+			Name:       subject,
+			Schema:     imports[imp.Name],
+			SchemaType: schemaregistry.SchemaTypeProtobuf,
+		}
+		var registration *schemaregistry.Registration
+		var err error
+		if spec.ValidateOnly {
+			registration, err = registry.LookupSchema(ctx, importSpec, nil)
+			if errors.Is(err, schemaregistry.ErrSubjectNotFound) || errors.Is(err, schemaregistry.ErrSchemaNotFound) {
+				return nil, fmt.Errorf("import %s isn't registered under subject %s, it isn't registered for validate only schema", imp.Name, subject)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("can't lookup import %s: %w", imp.Name, err)
+			}
+		} else {
+			registration, err = registry.CreateSchema(ctx, importSpec, nil)
+			if err != nil {
+				return nil, fmt.Errorf("can't register import %s: %w", imp.Name, err)
+			}
+		}
+		references = append(references, xov1alpha1.ResolvedReference{
+			Name:    imp.Name,
+			Subject: subject,
+			Version: registration.Version,
+		})
+	}
+	return references, nil
+}
+
+// indexSchemaSources would index KafkaSchema by name of ConfigMap it is loaded from,
+// KafkaSchema can't load schema from other namespace.
+func indexSchemaSources(obj client.Object) []string {
+	schema, ok := obj.(*xov1alpha1.KafkaSchema)
+	if !ok || schema.Spec.SchemaFrom == nil || schema.Spec.SchemaFrom.ConfigMapKeyRef == nil {
+		return nil
+	}
+	return []string{schema.Spec.SchemaFrom.ConfigMapKeyRef.Name}
+}
+
+// schemasFromConfigMap would map ConfigMap to requests for KafkaSchemas loaded from it
+func (r *KafkaSchemaReconciler) schemasFromConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	schemas := &xov1alpha1.KafkaSchemaList{}
+	err := r.List(ctx, schemas,
+		client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{schemaSourceIndexKey: obj.GetName()},
+		client.MatchingLabelsSelector{Selector: r.labelSelector})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "can't list KafkaSchemas loaded from ConfigMap", "name", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(schemas.Items))
+	for i := range schemas.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&schemas.Items[i])})
+	}
+	return requests
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+func TestImportSubject(t *testing.T) {
+	orders := &xov1alpha1.KafkaSchema{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "orders-value"}}
+	refunds := &xov1alpha1.KafkaSchema{ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "refunds-value"}}
+
+	assert.Equal(t, "payments.orders-value/common/money.proto", importSubject(orders, "common/money.proto"))
+	// the same import of other KafkaSchema is registered under its own subject
+	assert.NotEqual(t, importSubject(orders, "common/money.proto"), importSubject(refunds, "common/money.proto"))
+}
+
+func TestSourceKey(t *testing.T) {
+	data := map[string]string{"orders.proto": `syntax = "proto3";`}
+	value, err := sourceKey("orders", "orders.proto", data)
+	assert.NoError(t, err)
+	assert.Equal(t, `syntax = "proto3";`, value)
+
+	_, err = sourceKey("orders", "money.proto", data)
+	assert.ErrorIs(t, err, errSchemaSourceNotFound)
+	assert.EqualError(t, err, "schema source not found: ConfigMap orders doesn't have key money.proto")
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+)
+
+const (
+	ConditionReasonWaitingForTopic = "WaitingForTopic"
+	// topicRefIndexKey is index of KafkaSchemas by KafkaTopics they describe
+	topicRefIndexKey = ".spec.topicRef"
+)
+
+// errTopicNotReady is returned when referenced topic isn't created yet
+var errTopicNotReady = errors.New("referenced topic is not ready yet")
+
+// resolveSubject would derive subject of schema from its subject strategy, topic and record name.
+// Loaded spec is used, as record name is taken from schema.
+func (r *KafkaSchemaReconciler) resolveSubject(ctx context.Context, schema *xov1alpha1.KafkaSchema, spec *xov1alpha1.KafkaSchemaSpec) (string, error) {
+	topic := ""
+	if spec.TopicRef != nil {
+		key := topicRefKey(schema.Namespace, spec.TopicRef)
+		kafkaTopic := &xov1alpha1.KafkaTopic{}
+		err := r.Get(ctx, key, kafkaTopic)
+		if kerrors.IsNotFound(err) {
+			return "", fmt.Errorf("%w: KafkaTopic %s not found", errTopicNotReady, key)
+		}
+		if err != nil {
+			return "", fmt.Errorf("can't get referenced KafkaTopic %s: %w", key, err)
+		}
+		if !topicReady(kafkaTopic) {
+			return "", fmt.Errorf("%w: KafkaTopic %s is not Ready", errTopicNotReady, key)
+		}
+		topic = kafkaTopic.Spec.Name
+	}
+	return schemaregistry.Subject(spec, topic)
+}
+
+// schemaSubject would return subject schema was registered under, or its name if it wasn't registered yet
+func schemaSubject(schema *xov1alpha1.KafkaSchema) string {
+	if len(schema.Status.Subject) != 0 {
+		return schema.Status.Subject
+	}
+	return schema.Spec.Name
+}
+
+// topicReady is true when current spec of topic was applied to Kafka
+func topicReady(topic *xov1alpha1.KafkaTopic) bool {
+	ready := meta.FindStatusCondition(topic.Status.Conditions, ConditionReady)
+	return ready != nil && ready.Status == metav1.ConditionTrue && ready.ObservedGeneration == topic.Generation
+}
+
+// topicRefKey would return namespaced name of referenced KafkaTopic
+func topicRefKey(namespace string, ref *xov1alpha1.KafkaTopicRef) types.NamespacedName {
+	if len(ref.Namespace) != 0 {
+		namespace = ref.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}
+}
+
+// indexTopicRefs would index KafkaSchema by KafkaTopic it describes
+func indexTopicRefs(obj client.Object) []string {
+	schema, ok := obj.(*xov1alpha1.KafkaSchema)
+	if !ok || schema.Spec.TopicRef == nil {
+		return nil
+	}
+	return []string{topicRefKey(schema.Namespace, schema.Spec.TopicRef).String()}
+}
+
+// schemasForTopic would return requests for KafkaSchemas describing changed KafkaTopic
+func (r *KafkaSchemaReconciler) schemasForTopic(ctx context.Context, obj client.Object) []reconcile.Request {
+	schemas := &xov1alpha1.KafkaSchemaList{}
+	err := r.List(ctx, schemas,
+		client.MatchingFields{topicRefIndexKey: client.ObjectKeyFromObject(obj).String()},
+		client.MatchingLabelsSelector{Selector: r.labelSelector})
+	if err != nil {
+		log.FromContext(ctx).Error(err, "can't list KafkaSchemas of", "kafkatopic", client.ObjectKeyFromObject(obj))
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(schemas.Items))
+	for i := range schemas.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&schemas.Items[i])})
+	}
+	return requests
+}
+
+// topicReadyPredicate would pass only KafkaTopics which just became Ready, so that schemas
+// waiting for them are registered
+func topicReadyPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(_ event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldTopic, ok := e.ObjectOld.(*xov1alpha1.KafkaTopic)
+			if !ok {
+				return false
+			}
+			newTopic, ok := e.ObjectNew.(*xov1alpha1.KafkaTopic)
+			if !ok {
+				return false
+			}
+			return !topicReady(oldTopic) && topicReady(newTopic)
+		},
+		DeleteFunc: func(_ event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(_ event.GenericEvent) bool {
+			return false
+		},
+	}
+}
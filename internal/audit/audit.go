@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Actions we are auditing
+const (
+	ActionCreate        = "create"
+	ActionAlter         = "alter"
+	ActionDelete        = "delete"
+	ActionCompatibility = "compatibility"
+	ActionMode          = "mode"
+	// Systems we are changing
+	SystemKafka          = "kafka"
+	SystemSchemaRegistry = "schemaregistry"
+)
+
+type (
+	// Source is Kubernetes object which triggered the change
+	Source struct {
+		Kind       string `json:"kind"`
+		Namespace  string `json:"namespace"`
+		Name       string `json:"name"`
+		UID        string `json:"uid,omitempty"`
+		Generation int64  `json:"generation"`
+		// User is field manager who made the last change of object spec
+		User string `json:"user,omitempty"`
+	}
+	// Record is a single audited mutating call to Kafka or Schema Registry.
+	// Records are chained by hashes, so removing or changing any record is visible.
+	Record struct {
+		Time     time.Time         `json:"time"`
+		System   string            `json:"system"`
+		Action   string            `json:"action"`
+		Resource string            `json:"resource"`
+		Source   *Source           `json:"source,omitempty"`
+		Old      map[string]string `json:"old,omitempty"`
+		New      map[string]string `json:"new,omitempty"`
+		Error    string            `json:"error,omitempty"`
+		PrevHash string            `json:"prevHash"`
+		Hash     string            `json:"hash"`
+	}
+	// Sink is a destination of audit records
+	Sink interface {
+		Write(ctx context.Context, rec *Record) error
+		Close() error
+	}
+	// chainedSink is a sink which remembers last record written to it,
+	// so that chain of hashes continues after restart
+	chainedSink interface {
+		LastHash() string
+	}
+	// Auditor would write audit records to all its sinks
+	Auditor struct {
+		mu       sync.Mutex
+		sinks    []Sink
+		prevHash string
+	}
+	sourceKey struct{}
+)
+
+// New would make Auditor writing to sinks
+func New(sinks ...Sink) *Auditor {
+	a := &Auditor{
+		sinks: sinks,
+	}
+	for _, sink := range sinks {
+		if chained, ok := sink.(chainedSink); ok && len(chained.LastHash()) != 0 {
+			a.prevHash = chained.LastHash()
+			break
+		}
+	}
+	return a
+}
+
+// Record would write audit record to all sinks. Nil Auditor records nothing.
+// Source of change is taken from context, see WithSource.
+func (a *Auditor) Record(ctx context.Context, rec *Record) {
+	if a == nil {
+		return
+	}
+	reqLogger := log.FromContext(ctx).WithValues("audit", rec.Resource)
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+	if rec.Source == nil {
+		rec.Source = SourceFrom(ctx)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	rec.PrevHash = a.prevHash
+	rec.Hash = ""
+	data, err := json.Marshal(rec)
+	if err != nil {
+		reqLogger.Error(err, "can't marshal audit record")
+		return
+	}
+	sum := sha256.Sum256(data)
+	rec.Hash = hex.EncodeToString(sum[:])
+	a.prevHash = rec.Hash
+	for _, sink := range a.sinks {
+		err = sink.Write(ctx, rec)
+		if err != nil {
+			reqLogger.Error(err, "can't write audit record")
+		}
+	}
+}
+
+// Close would close all sinks
+func (a *Auditor) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var err error
+	for _, sink := range a.sinks {
+		err = errors.Join(err, sink.Close())
+	}
+	return err
+}
+
+// Verify would check that records are chained properly and were not changed
+func Verify(records []*Record) error {
+	prevHash := ""
+	for i, rec := range records {
+		if i != 0 && rec.PrevHash != prevHash {
+			return fmt.Errorf("record %d: chain is broken, previous hash %s, expected %s", i, rec.PrevHash, prevHash)
+		}
+		check := *rec
+		check.Hash = ""
+		data, err := json.Marshal(&check)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != rec.Hash {
+			return fmt.Errorf("record %d: hash mismatch, record was changed", i)
+		}
+		prevHash = rec.Hash
+	}
+	return nil
+}
+
+// WithSource would return context with Source of changes
+func WithSource(ctx context.Context, src *Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+// SourceFrom would return Source of changes from context or nil
+func SourceFrom(ctx context.Context) *Source {
+	src, _ := ctx.Value(sourceKey{}).(*Source) // nolint: errcheck
+	return src
+}
+
+// SourceFromObject would make Source from Kubernetes object. User is taken from
+// managedFields: it is manager of the latest change, excluding status updates.
+func SourceFromObject(kind string, obj client.Object) *Source {
+	src := &Source{
+		Kind:       kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		UID:        string(obj.GetUID()),
+		Generation: obj.GetGeneration(),
+	}
+	var latest *metav1.ManagedFieldsEntry
+	for i, entry := range obj.GetManagedFields() {
+		if entry.Subresource == "status" {
+			continue
+		}
+		if latest == nil || entry.Time != nil && (latest.Time == nil || !entry.Time.Before(latest.Time)) {
+			latest = &obj.GetManagedFields()[i]
+		}
+	}
+	if latest != nil {
+		src.User = latest.Manager
+	}
+	return src
+}
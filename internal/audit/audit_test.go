@@ -0,0 +1,99 @@
+package audit_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAuditor_FileChain(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	sink, err := audit.NewFileSink(path)
+	require.NoError(t, err)
+	a := audit.New(sink)
+	ctx := audit.WithSource(context.Background(), &audit.Source{
+		Kind: "KafkaTopic", Namespace: "default", Name: "test", Generation: 2, User: "kubectl",
+	})
+	a.Record(ctx, &audit.Record{
+		System:   audit.SystemKafka,
+		Action:   audit.ActionCreate,
+		Resource: "test-topic",
+		New:      map[string]string{"retention.ms": "1000"},
+	})
+	a.Record(ctx, &audit.Record{
+		System:   audit.SystemKafka,
+		Action:   audit.ActionAlter,
+		Resource: "test-topic",
+		Old:      map[string]string{"retention.ms": "1000"},
+		New:      map[string]string{"retention.ms": "2000"},
+	})
+	require.NoError(t, a.Close())
+
+	// chain continues after restart
+	sink, err = audit.NewFileSink(path)
+	require.NoError(t, err)
+	a = audit.New(sink)
+	a.Record(context.Background(), &audit.Record{
+		System:   audit.SystemSchemaRegistry,
+		Action:   audit.ActionCompatibility,
+		Resource: "test-value",
+		Error:    "can't set compatibility",
+	})
+	require.NoError(t, a.Close())
+
+	records, err := audit.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.NoError(t, audit.Verify(records))
+	require.Equal(t, "kubectl", records[1].Source.User)
+	require.Equal(t, int64(2), records[1].Source.Generation)
+	require.Nil(t, records[2].Source)
+	require.Equal(t, records[1].Hash, records[2].PrevHash)
+
+	// any change of record is visible
+	records[1].New["retention.ms"] = "3000"
+	require.Error(t, audit.Verify(records))
+	// as well as removed record
+	records, err = audit.ReadFile(path)
+	require.NoError(t, err)
+	require.Error(t, audit.Verify([]*audit.Record{records[0], records[2]}))
+}
+
+func TestAuditor_Nil(t *testing.T) {
+	t.Parallel()
+
+	var a *audit.Auditor
+	a.Record(context.Background(), &audit.Record{Resource: "test"})
+	require.NoError(t, a.Close())
+}
+
+func TestSourceFromObject(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	obj := &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+		Namespace:  "default",
+		Name:       "test",
+		Generation: 3,
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Manager: "kubectl", Time: &metav1.Time{Time: now.Add(-time.Hour)}},
+			{Manager: "argocd", Time: &metav1.Time{Time: now.Add(-time.Minute)}},
+			{Manager: "kafkaobjects-operator", Subresource: "status", Time: &metav1.Time{Time: now}},
+		},
+	}}
+	src := audit.SourceFromObject("KafkaSchema", obj)
+	require.Equal(t, &audit.Source{
+		Kind:       "KafkaSchema",
+		Namespace:  "default",
+		Name:       "test",
+		Generation: 3,
+		User:       "argocd",
+	}, src)
+}
@@ -0,0 +1,160 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// KafkaDeliveryTimeout is how long audit record is produced to Kafka before it is dropped
+const KafkaDeliveryTimeout = 30 * time.Second
+
+type (
+	// FileSink would write audit records to JSON-lines file
+	FileSink struct {
+		mu       sync.Mutex
+		file     *os.File
+		lastHash string
+	}
+	// KafkaSink would produce audit records to Kafka topic in background,
+	// records which can't be delivered in KafkaDeliveryTimeout are logged and counted
+	KafkaSink struct {
+		kCl    *kgo.Client
+		topic  string
+		failed atomic.Int64
+	}
+)
+
+// NewFileSink would open JSON-lines file for appending audit records
+func NewFileSink(path string) (*FileSink, error) {
+	s := &FileSink{}
+	// continue chain of records from the last one in file
+	existing, err := ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(existing) != 0 {
+		s.lastHash = existing[len(existing)-1].Hash
+	}
+	s.file, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) // nolint: gosec
+	if err != nil {
+		return nil, fmt.Errorf("can't open audit file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ReadFile would read all audit records from JSON-lines file
+func ReadFile(path string) ([]*Record, error) {
+	file, err := os.Open(path) // nolint: gosec
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records := []*Record{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		rec := &Record{}
+		err = json.Unmarshal(scanner.Bytes(), rec)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse audit record %d of %s: %w", len(records), path, err)
+		}
+		records = append(records, rec)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("can't read audit file %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// LastHash would return hash of the last record in file
+func (s *FileSink) LastHash() string {
+	return s.lastHash
+}
+
+// Write would append record to file
+func (s *FileSink) Write(_ context.Context, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("can't marshal audit record: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("can't write audit record: %w", err)
+	}
+	s.lastHash = rec.Hash
+	return s.file.Sync()
+}
+
+// Close would close audit file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// NewKafkaSink would make sink producing records to Kafka topic
+func NewKafkaSink(kCl *kgo.Client, topic string) (*KafkaSink, error) {
+	if kCl == nil {
+		return nil, fmt.Errorf("kafka client must be provided for audit sink")
+	}
+	if len(topic) == 0 {
+		return nil, fmt.Errorf("kafka audit topic must be provided")
+	}
+	return &KafkaSink{
+		kCl:   kCl,
+		topic: topic,
+	}, nil
+}
+
+// Write would buffer record for producing to Kafka and return without waiting for it,
+// record key is changed resource, so all changes of resource are in the same partition
+func (s *KafkaSink) Write(ctx context.Context, rec *Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("can't marshal audit record: %w", err)
+	}
+	kRec := kgo.KeySliceRecord([]byte(rec.System+"/"+rec.Resource), data)
+	kRec.Topic = s.topic
+	reqLogger := log.FromContext(ctx).WithValues("audit", rec.Resource, "hash", rec.Hash)
+	// record must not be cancelled with reconcile, but it must not be retried forever either
+	produceCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), KafkaDeliveryTimeout)
+	s.kCl.TryProduce(produceCtx, kRec, func(_ *kgo.Record, err error) {
+		cancel()
+		if err != nil {
+			s.failed.Add(1)
+			reqLogger.Error(err, "can't produce audit record", "topic", s.topic)
+		}
+	})
+	return nil
+}
+
+// Failed would return how many audit records weren't produced to Kafka
+func (s *KafkaSink) Failed() int64 {
+	return s.failed.Load()
+}
+
+// Close would wait for buffered records, at most KafkaDeliveryTimeout, and close Kafka client
+func (s *KafkaSink) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), KafkaDeliveryTimeout)
+	defer cancel()
+	err := s.kCl.Flush(ctx)
+	s.kCl.Close()
+	if err != nil {
+		return fmt.Errorf("can't produce buffered audit records to %s: %w", s.topic, err)
+	}
+	return nil
+}
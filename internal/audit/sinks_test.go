@@ -0,0 +1,28 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestKafkaSink_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	kCl, err := kgo.NewClient(kgo.SeedBrokers("127.0.0.1:1"), kgo.RecordDeliveryTimeout(time.Second))
+	require.NoError(t, err)
+	sink, err := audit.NewKafkaSink(kCl, "audit")
+	require.NoError(t, err)
+	a := audit.New(sink)
+
+	// Kafka outage doesn't block auditing, records are dropped after their delivery timeout
+	start := time.Now()
+	a.Record(context.Background(), &audit.Record{System: audit.SystemKafka, Action: audit.ActionCreate, Resource: "test-topic"})
+	require.Less(t, time.Since(start), time.Second)
+	require.Eventually(t, func() bool { return sink.Failed() == 1 }, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, a.Close())
+}
@@ -0,0 +1,195 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Schedule is parsed cron schedule in standard 5 fields format:
+	// minute hour day-of-month month day-of-week
+	Schedule struct {
+		expr   string
+		minute uint64
+		hour   uint64
+		dom    uint64
+		month  uint64
+		dow    uint64
+		anyDom bool
+		anyDow bool
+		loc    *time.Location
+	}
+	// bounds of cron field
+	bounds struct {
+		name     string
+		min, max int
+		names    map[string]int
+	}
+)
+
+var (
+	minutes = bounds{name: "minute", min: 0, max: 59}
+	hours   = bounds{name: "hour", min: 0, max: 23}
+	doms    = bounds{name: "day of month", min: 1, max: 31}
+	months  = bounds{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dows = bounds{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+	// descriptors are shortcuts for commonly used schedules
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// Parse would parse cron expression, times of schedule are in loc timezone
+func Parse(expr string, loc *time.Location) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if descr, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = descr
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression `%s` must have 5 fields, has %d", expr, len(fields))
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	s := &Schedule{
+		expr:   expr,
+		anyDom: fields[2] == "*" || fields[2] == "?",
+		anyDow: fields[4] == "*" || fields[4] == "?",
+		loc:    loc,
+	}
+	var err error
+	for _, field := range []struct {
+		value  string
+		bounds bounds
+		bits   *uint64
+	}{
+		{fields[0], minutes, &s.minute},
+		{fields[1], hours, &s.hour},
+		{fields[2], doms, &s.dom},
+		{fields[3], months, &s.month},
+		{fields[4], dows, &s.dow},
+	} {
+		*field.bits, err = parseField(field.value, field.bounds)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse cron expression `%s`: %w", expr, err)
+		}
+	}
+	// 7 is Sunday too
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseField would parse comma separated list of ranges with optional step
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step `%s` of %s", stepStr, b.name)
+			}
+		}
+		start, end := b.min, b.max
+		if rng != "*" && rng != "?" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			start, err = b.value(from)
+			if err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				end, err = b.value(to)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = b.max
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range `%s` of %s", rng, b.name)
+		}
+		for i := start; i <= end; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+// value would parse single value of field, either number or name
+func (b bounds) value(str string) (int, error) {
+	if nr, ok := b.names[strings.ToLower(str)]; ok {
+		return nr, nil
+	}
+	nr, err := strconv.Atoi(str)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s `%s`", b.name, str)
+	}
+	if nr < b.min || nr > b.max {
+		return 0, fmt.Errorf("%s `%d` is out of range %d-%d", b.name, nr, b.min, b.max)
+	}
+	return nr, nil
+}
+
+// Next would return next time after t when schedule fires,
+// or zero time if schedule would never fire (i.e. 30 of February)
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	// five years is enough to find any valid date, including leap years
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows cron rules: if both day of month and day of week are restricted,
+// day matches when any of them matches
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
@@ -0,0 +1,61 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/90poe/kafkaobjects-operator/internal/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	t.Parallel()
+
+	// Monday
+	from := time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{expr: "0 9 * * *", next: time.Date(2024, time.January, 16, 9, 0, 0, 0, time.UTC)},
+		{expr: "@daily", next: time.Date(2024, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{expr: "@hourly", next: time.Date(2024, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", next: time.Date(2024, time.January, 15, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 8 * * sat,sun", next: time.Date(2024, time.January, 20, 8, 0, 0, 0, time.UTC)},
+		{expr: "0 8 * * 1-5", next: time.Date(2024, time.January, 16, 8, 0, 0, 0, time.UTC)},
+		{expr: "0 0 1 feb *", next: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", next: time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// day of month OR day of week
+		{expr: "0 0 20 * 2", next: time.Date(2024, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", next: time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		s, err := cron.Parse(test.expr, time.UTC)
+		require.NoError(t, err, test.expr)
+		assert.Equal(t, test.next, s.Next(from), test.expr)
+	}
+	// never fires
+	s, err := cron.Parse("0 0 30 2 *", time.UTC)
+	require.NoError(t, err)
+	assert.True(t, s.Next(from).IsZero())
+}
+
+func TestParse_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{expr: "0 9 * *", err: "must have 5 fields"},
+		{expr: "60 9 * * *", err: "minute `60` is out of range"},
+		{expr: "0 9 * * funday", err: "invalid day of week"},
+		{expr: "*/0 9 * * *", err: "invalid step"},
+		{expr: "0 10-9 * * *", err: "invalid range"},
+	}
+	for _, test := range tests {
+		_, err := cron.Parse(test.expr, time.UTC)
+		assert.ErrorContains(t, err, test.err, test.expr)
+	}
+}
@@ -7,15 +7,36 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// Config is configuration of operator read from environment, KAFKA_BROKERS, SCHEMA_REGISTRY_URL and LABEL_SELECTOR are optional.
+// KAFKA_ANY_USERNAME allows KafkaUsers to have username which isn't prefixed by their namespace.
+// AUDIT_FILE keeps chain of audit records between restarts only when it is on mounted volume.
 type Config struct {
-	KafkaBrokers             string `env:"KAFKA_BROKERS" env-required` // nolint
+	KafkaBrokers             string `env:"KAFKA_BROKERS"`
 	MaxKafkaTopicsPartitions uint   `env:"KAFKA_TOPIC_MAX_PARTITIONS" env-default:"3"`
 	KafkaTopicNameRegexp     string `env:"KAFKA_TOPIC_NAME_REGEXP" env-default:".*"`
-	SchemaRegistryURL        string `env:"SCHEMA_REGISTRY_URL" env-required` // nolint
+	KafkaAnyUsername         bool   `env:"KAFKA_ANY_USERNAME" env-default:"false"`
+	SchemaRegistryURL        string `env:"SCHEMA_REGISTRY_URL"`
+	SchemaRegistryUsername   string `env:"SCHEMA_REGISTRY_USERNAME"`
+	SchemaRegistryPassword   string `env:"SCHEMA_REGISTRY_PASSWORD"`
+	SchemaRegistryTokenFile  string `env:"SCHEMA_REGISTRY_TOKEN_FILE"`
+	SchemaRegistryCAFile     string `env:"SCHEMA_REGISTRY_CA_FILE"`
+	SchemaRegistryCertFile   string `env:"SCHEMA_REGISTRY_CERT_FILE"`
+	SchemaRegistryKeyFile    string `env:"SCHEMA_REGISTRY_KEY_FILE"`
+	SchemaRegistryBackend    string `env:"SCHEMA_REGISTRY_BACKEND" env-default:"confluent"`
+	ApicurioAPIVersion       string `env:"APICURIO_API_VERSION" env-default:"v3"`
+	ApicurioGroup            string `env:"APICURIO_GROUP" env-default:"default"`
 	MaxConcurrentReconciles  int    `env:"MAX_CONCURRENT_RECONCILES" env-default:"2"`
-	LabelSelectorsInt        string `env:"LABEL_SELECTOR" env-required` // nolint
+	LabelSelectorsInt        string `env:"LABEL_SELECTOR"`
 	SlackToken               string `env:"SLACK_TOKEN"`
 	SlackChannel             string `env:"SLACK_CHANNEL" env-default:"empty"`
+	SlackBufferSize          int    `env:"SLACK_BUFFER_SIZE" env-default:"100"`
+	ClusterName              string `env:"CLUSTER_NAME"`
+	NotificationLevel        string `env:"NOTIFICATION_LEVEL" env-default:"errors"`
+	DigestSchedule           string `env:"DIGEST_SCHEDULE"`
+	AuditFile                string `env:"AUDIT_FILE" env-default:"/tmp/audit.jsonl"`
+	AuditKafkaTopic          string `env:"AUDIT_KAFKA_TOPIC"`
+	EnableWebhooks           bool   `env:"ENABLE_WEBHOOKS" env-default:"false"`
+	WebhookCompatibility     bool   `env:"WEBHOOK_COMPATIBILITY_CHECK" env-default:"false"`
 	LabelSelectors           *metav1.LabelSelector
 }
 
@@ -90,3 +90,16 @@ func TestMustGetEnv(t *testing.T) {
 		assert.Equal(t, test.getValue.(bool), ret) // nolint: errcheck
 	}
 }
+
+func TestNewConfig(t *testing.T) {
+	// KAFKA_BROKERS, SCHEMA_REGISTRY_URL and LABEL_SELECTOR are optional
+	for _, key := range []string{"KAFKA_BROKERS", "SCHEMA_REGISTRY_URL", "LABEL_SELECTOR"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	conf, err := env.NewConfig()
+	assert.NoError(t, err)
+	assert.Empty(t, conf.KafkaBrokers)
+	assert.Empty(t, conf.SchemaRegistryURL)
+	assert.Equal(t, "confluent", conf.SchemaRegistryBackend)
+}
@@ -0,0 +1,248 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/twmb/franz-go/pkg/kadm"
+)
+
+const (
+	// ACLResourceTypeTopic and others are resource types of KafkaACL
+	ACLResourceTypeTopic           = "TOPIC"
+	ACLResourceTypeGroup           = "GROUP"
+	ACLResourceTypeTransactionalID = "TRANSACTIONAL_ID"
+	ACLResourceTypeCluster         = "CLUSTER"
+	// ACLClusterName is name of the only CLUSTER resource
+	ACLClusterName = "kafka-cluster"
+	// ACLPatternLiteral and ACLPatternPrefixed are pattern types of KafkaACL
+	ACLPatternLiteral  = "LITERAL"
+	ACLPatternPrefixed = "PREFIXED"
+	// ACLPermissionAllow and ACLPermissionDeny are permissions of KafkaACL
+	ACLPermissionAllow = "ALLOW"
+	ACLPermissionDeny  = "DENY"
+	// ACLAnyHost is host matching any host
+	ACLAnyHost = "*"
+)
+
+// aclOperations are operations of KafkaACL by their names
+var aclOperations = func() map[string]kadm.ACLOperation {
+	operations := map[string]kadm.ACLOperation{}
+	for _, op := range []kadm.ACLOperation{
+		kadm.OpAll, kadm.OpRead, kadm.OpWrite, kadm.OpCreate, kadm.OpDelete, kadm.OpAlter, kadm.OpDescribe,
+		kadm.OpClusterAction, kadm.OpDescribeConfigs, kadm.OpAlterConfigs, kadm.OpIdempotentWrite,
+	} {
+		operations[op.String()] = op
+	}
+	return operations
+}()
+
+// DesiredACLs would return ACL bindings of KafkaACL spec, one for each of operations, with defaults applied
+func DesiredACLs(spec *api.KafkaACLSpec) ([]api.ACLBinding, error) {
+	resourceType := strings.ToUpper(spec.ResourceType)
+	name := spec.ResourceName
+	switch resourceType {
+	case ACLResourceTypeCluster:
+		name = ACLClusterName
+	case ACLResourceTypeTopic, ACLResourceTypeGroup, ACLResourceTypeTransactionalID:
+		if len(name) == 0 {
+			return nil, fmt.Errorf("resource name of %s must be set", resourceType)
+		}
+	default:
+		return nil, fmt.Errorf("unknown resource type %s", spec.ResourceType)
+	}
+	host, pattern, permission := spec.Host, strings.ToUpper(spec.PatternType), strings.ToUpper(spec.Permission)
+	if len(host) == 0 {
+		host = ACLAnyHost
+	}
+	if len(pattern) == 0 {
+		pattern = ACLPatternLiteral
+	}
+	if len(permission) == 0 {
+		permission = ACLPermissionAllow
+	}
+	bindings := make([]api.ACLBinding, 0, len(spec.Operations))
+	seen := map[string]bool{}
+	for _, op := range spec.Operations {
+		op = strings.ToUpper(op)
+		if _, ok := aclOperations[op]; !ok {
+			return nil, fmt.Errorf("unknown operation %s", op)
+		}
+		if seen[op] {
+			continue
+		}
+		seen[op] = true
+		bindings = append(bindings, api.ACLBinding{
+			Principal:    spec.Principal,
+			Host:         host,
+			ResourceType: resourceType,
+			ResourceName: name,
+			PatternType:  pattern,
+			Operation:    op,
+			Permission:   permission,
+		})
+	}
+	sort.Slice(bindings, func(i, j int) bool {
+		return bindings[i].Operation < bindings[j].Operation
+	})
+	return bindings, nil
+}
+
+// FormatACL would format ACL binding as one line, i.e. for notifications
+func FormatACL(binding api.ACLBinding) string {
+	return fmt.Sprintf("%s %s from %s to %s on %s %s (%s)", binding.Permission, binding.Principal, binding.Host,
+		binding.Operation, binding.ResourceType, binding.ResourceName, binding.PatternType)
+}
+
+// aclBuilder would make builder matching exactly ACL binding
+func aclBuilder(binding api.ACLBinding) (*kadm.ACLBuilder, error) {
+	b := kadm.NewACLs()
+	// builder would match any resource of type if name isn't set
+	if len(binding.ResourceName) == 0 {
+		return nil, fmt.Errorf("resource name of ACL %s must be set", FormatACL(binding))
+	}
+	switch binding.ResourceType {
+	case ACLResourceTypeTopic:
+		b.Topics(binding.ResourceName)
+	case ACLResourceTypeGroup:
+		b.Groups(binding.ResourceName)
+	case ACLResourceTypeTransactionalID:
+		b.TransactionalIDs(binding.ResourceName)
+	case ACLResourceTypeCluster:
+		b.Clusters()
+	default:
+		return nil, fmt.Errorf("unknown resource type of ACL %s", FormatACL(binding))
+	}
+	switch binding.PatternType {
+	case ACLPatternLiteral:
+		b.ResourcePatternType(kadm.ACLPatternLiteral)
+	case ACLPatternPrefixed:
+		b.ResourcePatternType(kadm.ACLPatternPrefixed)
+	default:
+		return nil, fmt.Errorf("unknown pattern type of ACL %s", FormatACL(binding))
+	}
+	op, ok := aclOperations[binding.Operation]
+	if !ok {
+		return nil, fmt.Errorf("unknown operation of ACL %s", FormatACL(binding))
+	}
+	b.Operations(op)
+	switch binding.Permission {
+	case ACLPermissionAllow:
+		b.Allow(binding.Principal).AllowHosts(binding.Host)
+	case ACLPermissionDeny:
+		b.Deny(binding.Principal).DenyHosts(binding.Host)
+	default:
+		return nil, fmt.Errorf("unknown permission of ACL %s", FormatACL(binding))
+	}
+	return b, nil
+}
+
+// ACLs would return those of bindings which exist in Kafka cluster
+func (c *ClusterClient) ACLs(ctx context.Context, bindings []api.ACLBinding) ([]api.ACLBinding, error) {
+	if c.kCl == nil {
+		return nil, fmt.Errorf("we don't have connection to Kafka cluster")
+	}
+	kAdm := kadm.NewClient(c.kCl)
+	existing := []api.ACLBinding{}
+	for _, binding := range bindings {
+		b, err := aclBuilder(binding)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := kAdm.DescribeACLs(ctx, b)
+		if err != nil {
+			return nil, fmt.Errorf("can't describe ACL %s: %w", FormatACL(binding), err)
+		}
+		found := false
+		for _, r := range resp {
+			if r.Err != nil {
+				return nil, fmt.Errorf("can't describe ACL %s, cluster err: %w", FormatACL(binding), r.Err)
+			}
+			found = found || len(r.Described) != 0
+		}
+		if found {
+			existing = append(existing, binding)
+		}
+	}
+	return existing, nil
+}
+
+// CreateACLs would create ACL bindings in Kafka cluster, auditing each of them
+func (c *ClusterClient) CreateACLs(ctx context.Context, bindings []api.ACLBinding) error {
+	if c.kCl == nil {
+		return fmt.Errorf("we don't have connection to Kafka cluster")
+	}
+	kAdm := kadm.NewClient(c.kCl)
+	var errs error
+	for _, binding := range bindings {
+		b, err := aclBuilder(binding)
+		if err != nil {
+			return err
+		}
+		resp, err := kAdm.CreateACLs(ctx, b)
+		for _, r := range resp {
+			if r.Err != nil {
+				err = errors.Join(err, fmt.Errorf("%w: %s", r.Err, r.ErrMessage))
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("can't create ACL %s: %w", FormatACL(binding), err)
+			errs = errors.Join(errs, err)
+		}
+		c.audit(ctx, audit.ActionCreate, aclResource(binding), nil, aclConfigs(binding), err)
+	}
+	return errs
+}
+
+// DeleteACLs would delete exactly ACL bindings from Kafka cluster, auditing each of them
+func (c *ClusterClient) DeleteACLs(ctx context.Context, bindings []api.ACLBinding) error {
+	if c.kCl == nil {
+		return fmt.Errorf("we don't have connection to Kafka cluster")
+	}
+	kAdm := kadm.NewClient(c.kCl)
+	var errs error
+	for _, binding := range bindings {
+		b, err := aclBuilder(binding)
+		if err != nil {
+			return err
+		}
+		resp, err := kAdm.DeleteACLs(ctx, b)
+		for _, r := range resp {
+			if r.Err != nil {
+				err = errors.Join(err, fmt.Errorf("%w: %s", r.Err, r.ErrMessage))
+			}
+			for _, deleted := range r.Deleted {
+				if deleted.Err != nil {
+					err = errors.Join(err, fmt.Errorf("%w: %s", deleted.Err, deleted.ErrMessage))
+				}
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("can't delete ACL %s: %w", FormatACL(binding), err)
+			errs = errors.Join(errs, err)
+		}
+		c.audit(ctx, audit.ActionDelete, aclResource(binding), aclConfigs(binding), nil, err)
+	}
+	return errs
+}
+
+// aclResource would return resource ACL binding is audited as
+func aclResource(binding api.ACLBinding) string {
+	return fmt.Sprintf("acl:%s:%s", binding.ResourceType, binding.ResourceName)
+}
+
+// aclConfigs would return fields of ACL binding for audit
+func aclConfigs(binding api.ACLBinding) map[string]string {
+	return map[string]string{
+		"principal":   binding.Principal,
+		"host":        binding.Host,
+		"patternType": binding.PatternType,
+		"operation":   binding.Operation,
+		"permission":  binding.Permission,
+	}
+}
@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	api "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDesiredACLs(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    *api.KafkaACLSpec
+		want    []api.ACLBinding
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			spec: &api.KafkaACLSpec{
+				Principal:    "User:orders",
+				ResourceType: "TOPIC",
+				ResourceName: "orders",
+				Operations:   []string{"READ", "DESCRIBE", "READ"},
+			},
+			want: []api.ACLBinding{
+				{Principal: "User:orders", Host: "*", ResourceType: "TOPIC", ResourceName: "orders",
+					PatternType: "LITERAL", Operation: "DESCRIBE", Permission: "ALLOW"},
+				{Principal: "User:orders", Host: "*", ResourceType: "TOPIC", ResourceName: "orders",
+					PatternType: "LITERAL", Operation: "READ", Permission: "ALLOW"},
+			},
+		},
+		{
+			name: "cluster",
+			spec: &api.KafkaACLSpec{
+				Principal:    "User:admin",
+				Host:         "10.0.0.1",
+				ResourceType: "CLUSTER",
+				Operations:   []string{"ALTER_CONFIGS"},
+				Permission:   "DENY",
+			},
+			want: []api.ACLBinding{
+				{Principal: "User:admin", Host: "10.0.0.1", ResourceType: "CLUSTER", ResourceName: "kafka-cluster",
+					PatternType: "LITERAL", Operation: "ALTER_CONFIGS", Permission: "DENY"},
+			},
+		},
+		{
+			name: "no resource name",
+			spec: &api.KafkaACLSpec{
+				Principal:    "User:orders",
+				ResourceType: "GROUP",
+				Operations:   []string{"READ"},
+			},
+			wantErr: "resource name of GROUP must be set",
+		},
+		{
+			name: "unknown operation",
+			spec: &api.KafkaACLSpec{
+				Principal:    "User:orders",
+				ResourceType: "TOPIC",
+				ResourceName: "orders",
+				Operations:   []string{"ANY"},
+			},
+			wantErr: "unknown operation ANY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DesiredACLs(tt.spec)
+			if len(tt.wantErr) != 0 {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCreateACLs(t *testing.T) {
+	c := &ClusterClient{}
+	err := c.CreateACLs(context.Background(), []api.ACLBinding{{ResourceType: "TOPIC", ResourceName: "orders"}})
+	assert.EqualError(t, err, "we don't have connection to Kafka cluster")
+
+	_, err = aclBuilder(api.ACLBinding{Principal: "User:orders", Host: "*", ResourceType: "TOPIC",
+		PatternType: "LITERAL", Operation: "READ", Permission: "ALLOW"})
+	assert.ErrorContains(t, err, "resource name of ACL")
+
+	_, err = aclBuilder(api.ACLBinding{Principal: "User:orders", Host: "*", ResourceType: "TOPIC", ResourceName: "orders",
+		PatternType: "LITERAL", Operation: "READ", Permission: "ALLOW"})
+	assert.NoError(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	api "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
 	"github.com/twmb/franz-go/pkg/kadm"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
@@ -18,6 +19,7 @@ type (
 		kCl              *kgo.Client
 		maxPartsPerTopic uint
 		topicNamePattern *regexp.Regexp
+		auditor          *audit.Auditor
 	}
 )
 
@@ -42,6 +44,11 @@ func (c *ClusterClient) TopicExists(topic *api.KafkaTopicSpec) (bool, error) {
 	return false, nil
 }
 
+// Topics would return names of all topics in Kafka cluster, except internal ones
+func (c *ClusterClient) Topics() ([]string, error) {
+	return c.getTopics()
+}
+
 // getTopics would return Kafka topics
 func (c *ClusterClient) getTopics() ([]string, error) {
 	kAdm := kadm.NewClient(c.kCl)
@@ -57,7 +64,7 @@ func (c *ClusterClient) getTopics() ([]string, error) {
 }
 
 // CreateTopic is going to create Kafka topic from data from Structures
-func (c *ClusterClient) CreateTopic(topic *api.KafkaTopicSpec) error {
+func (c *ClusterClient) CreateTopic(ctx context.Context, topic *api.KafkaTopicSpec) error {
 	if topic.Partitions > c.maxPartsPerTopic {
 		return fmt.Errorf("%s can't have more partitions than %d", topic.Name, c.maxPartsPerTopic)
 	}
@@ -70,58 +77,115 @@ func (c *ClusterClient) CreateTopic(topic *api.KafkaTopicSpec) error {
 	}
 	kAdm := kadm.NewClient(c.kCl)
 	// topic config
-	configs := make(map[string]*string, 6)
-	configs["min.insync.replicas"] = kadm.StringPtr(
-		fmt.Sprintf("%d", topic.MinInSyncReplicas))
+	desired := DesiredConfigs(topic)
+	configs := make(map[string]*string, len(desired))
+	for name, value := range desired {
+		configs[name] = kadm.StringPtr(value)
+	}
+
+	resp, err := kAdm.CreateTopic(
+		ctx,
+		int32(topic.Partitions),  // nolint: gosec
+		int16(topic.Replication), // nolint: gosec
+		configs,
+		topic.Name,
+	)
+
+	if err != nil {
+		err = fmt.Errorf("can't create topic: %w", err)
+	} else if resp.Err != nil {
+		err = fmt.Errorf("can't create topic, cluster err: %w", resp.Err)
+	}
+	// audit creation
+	desired["partitions"] = fmt.Sprintf("%d", topic.Partitions)
+	desired["replication"] = fmt.Sprintf("%d", topic.Replication)
+	c.audit(ctx, audit.ActionCreate, topic.Name, nil, desired, err)
+	return err
+}
+
+// audit would record mutating call to Kafka cluster
+func (c *ClusterClient) audit(ctx context.Context, action, topic string, old, new map[string]string, err error) {
+	rec := &audit.Record{
+		System:   audit.SystemKafka,
+		Action:   action,
+		Resource: topic,
+		Old:      old,
+		New:      new,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	c.auditor.Record(ctx, rec)
+}
+
+// DesiredConfigs would return Kafka topic configs we would set for topic spec
+func DesiredConfigs(topic *api.KafkaTopicSpec) map[string]string {
+	configs := make(map[string]string, 7)
+	configs["min.insync.replicas"] = fmt.Sprintf("%d", topic.MinInSyncReplicas)
 	// Retention MS
 	retentionMS := "-1"
 	if topic.RetentionHours > 0 {
 		retentionMS = fmt.Sprintf("%d", topic.RetentionHours*3600*1000)
 	}
-	configs["retention.ms"] = &retentionMS
+	configs["retention.ms"] = retentionMS
 	// Retention Bytes
 	retentionBytes := "-1"
 	if topic.RetentionBytes > 0 {
 		retentionBytes = fmt.Sprintf("%d", topic.RetentionBytes)
 	}
-	configs["retention.bytes"] = &retentionBytes
+	configs["retention.bytes"] = retentionBytes
 
 	if topic.Segment.Bytes != 0 {
-		segmentBytes := fmt.Sprintf("%d", topic.Segment.Bytes)
-		configs["segment.bytes"] = &segmentBytes
+		configs["segment.bytes"] = fmt.Sprintf("%d", topic.Segment.Bytes)
 	}
 
 	if topic.Segment.MS != 0 {
-		segmentMS := fmt.Sprintf("%d", topic.Segment.MS)
-		configs["segment.ms"] = &segmentMS
+		configs["segment.ms"] = fmt.Sprintf("%d", topic.Segment.MS)
 	}
 
 	if len(topic.CleanupPolicy) != 0 {
-		policy := strings.ToLower(topic.CleanupPolicy)
-		configs["cleanup.policy"] = &policy
+		configs["cleanup.policy"] = strings.ToLower(topic.CleanupPolicy)
 	}
 
 	maxMessageBytes := "1048576"
 	if topic.MaxMessageBytes != 1048576 {
 		maxMessageBytes = fmt.Sprintf("%d", topic.MaxMessageBytes)
 	}
-	configs["max.message.bytes"] = &maxMessageBytes
+	configs["max.message.bytes"] = maxMessageBytes
+	return configs
+}
 
-	resp, err := kAdm.CreateTopic(
-		context.Background(),
-		int32(topic.Partitions),  // nolint: gosec
-		int16(topic.Replication), // nolint: gosec
-		configs,
-		topic.Name,
-	)
+// TopicConfigs would return current configs of topic in Kafka cluster
+func (c *ClusterClient) TopicConfigs(name string) (map[string]string, error) {
+	configs, err := c.TopicsConfigs(name)
+	if err != nil {
+		return nil, err
+	}
+	return configs[name], nil
+}
 
+// TopicsConfigs would return current configs of topics in Kafka cluster by topic name
+func (c *ClusterClient) TopicsConfigs(names ...string) (map[string]map[string]string, error) {
+	if c.kCl == nil {
+		return nil, fmt.Errorf("we don't have connection to Kafka cluster")
+	}
+	kAdm := kadm.NewClient(c.kCl)
+	resp, err := kAdm.DescribeTopicConfigs(context.Background(), names...)
 	if err != nil {
-		return fmt.Errorf("can't create topic: %w", err)
+		return nil, fmt.Errorf("can't describe topics configs: %w", err)
 	}
-	if resp.Err != nil {
-		return fmt.Errorf("can't create topic, cluster err: %w", err)
+	topicsConfigs := make(map[string]map[string]string, len(resp))
+	for _, rc := range resp {
+		if rc.Err != nil {
+			return nil, fmt.Errorf("can't describe topic %s configs, cluster err: %w", rc.Name, rc.Err)
+		}
+		configs := make(map[string]string, len(rc.Configs))
+		for _, cfg := range rc.Configs {
+			configs[cfg.Key] = cfg.MaybeValue()
+		}
+		topicsConfigs[rc.Name] = configs
 	}
-	return nil
+	return topicsConfigs, nil
 }
 
 // alertConfig would return AlterConfig for topic
@@ -134,7 +198,7 @@ func (c *ClusterClient) alertConfig(name, value string) kadm.AlterConfig {
 }
 
 // UpdateTopic is going to update Kafka topic from data from Structures
-func (c *ClusterClient) UpdateTopic(topic *api.KafkaTopicSpec) error {
+func (c *ClusterClient) UpdateTopic(ctx context.Context, topic *api.KafkaTopicSpec) error {
 	if topic.Partitions > c.maxPartsPerTopic {
 		return fmt.Errorf("%s can't have more partitions than %d", topic.Name, c.maxPartsPerTopic)
 	}
@@ -143,41 +207,23 @@ func (c *ClusterClient) UpdateTopic(topic *api.KafkaTopicSpec) error {
 	}
 	kAdm := kadm.NewClient(c.kCl)
 	// topic config
-	configs := make([]kadm.AlterConfig, 0, 5)
-	configs = append(configs, c.alertConfig("min.insync.replicas", fmt.Sprintf("%d", topic.MinInSyncReplicas)))
-	// Retention MS
-	retentionMS := "-1"
-	if topic.RetentionHours > 0 {
-		retentionMS = fmt.Sprintf("%d", topic.RetentionHours*3600*1000)
-	}
-	configs = append(configs, c.alertConfig("retention.ms", retentionMS))
-	// Retention Bytes
-	retentionBytes := "-1"
-	if topic.RetentionBytes > 0 {
-		retentionBytes = fmt.Sprintf("%d", topic.RetentionBytes)
-	}
-	configs = append(configs, c.alertConfig("retention.bytes", retentionBytes))
-
-	if topic.Segment.Bytes != 0 {
-		configs = append(configs, c.alertConfig("segment.bytes", fmt.Sprintf("%d", topic.Segment.Bytes)))
-	}
-
-	if topic.Segment.MS != 0 {
-		configs = append(configs, c.alertConfig("segment.ms", fmt.Sprintf("%d", topic.Segment.MS)))
-	}
-
-	if len(topic.CleanupPolicy) != 0 {
-		configs = append(configs, c.alertConfig("cleanup.policy", strings.ToLower(topic.CleanupPolicy)))
-	}
-
-	maxMessageBytes := "1048576"
-	if topic.MaxMessageBytes != 1048576 {
-		maxMessageBytes = fmt.Sprintf("%d", topic.MaxMessageBytes)
+	desired := DesiredConfigs(topic)
+	configs := make([]kadm.AlterConfig, 0, len(desired))
+	for name, value := range desired {
+		configs = append(configs, c.alertConfig(name, value))
+	}
+	// current configs are needed only for audit, so we don't fail if we can't get them
+	old, new := map[string]string{}, map[string]string{}
+	current, cErr := c.TopicConfigs(topic.Name)
+	for name, value := range desired {
+		if cErr != nil || current[name] != value {
+			old[name] = current[name]
+			new[name] = value
+		}
 	}
-	configs = append(configs, c.alertConfig("max.message.bytes", maxMessageBytes))
 
 	resp, err := kAdm.AlterTopicConfigsState(
-		context.Background(),
+		ctx,
 		configs,
 		topic.Name,
 	)
@@ -188,8 +234,11 @@ func (c *ClusterClient) UpdateTopic(topic *api.KafkaTopicSpec) error {
 		}
 	}
 	if err != nil {
-		return fmt.Errorf("can't create topic: %w", err)
+		err = fmt.Errorf("can't create topic: %w", err)
 	}
-
-	return nil
+	// audit only real changes
+	if len(new) != 0 || err != nil {
+		c.audit(ctx, audit.ActionAlter, topic.Name, old, new, err)
+	}
+	return err
 }
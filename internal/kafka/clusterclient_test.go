@@ -1,6 +1,7 @@
 package kafka
 
 import (
+	"context"
 	"regexp"
 	"testing"
 
@@ -55,7 +56,7 @@ func TestCreateTopic(t *testing.T) {
 				topicNamePattern: regexp.MustCompile(tt.namePattern),
 			}
 
-			err := c.CreateTopic(tt.topic)
+			err := c.CreateTopic(context.Background(), tt.topic)
 			assert.EqualError(t, err, tt.wantErr)
 		})
 	}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
 	"github.com/twmb/franz-go/pkg/kgo"
 	"github.com/twmb/franz-go/pkg/kversion"
 )
@@ -23,6 +24,7 @@ type (
 		tlsInsecureSkipVerify bool
 		maxPartsPerTopic      uint
 		topicNamePattern      *regexp.Regexp
+		auditor               *audit.Auditor
 	}
 	Option func(*ClusterConfig) error
 )
@@ -87,6 +89,14 @@ func MaxPartsPerTopic(max uint) Option {
 	}
 }
 
+// Auditor is option function to set Auditor recording all changes made to Kafka cluster
+func Auditor(auditor *audit.Auditor) Option {
+	return func(m *ClusterConfig) error {
+		m.auditor = auditor
+		return nil
+	}
+}
+
 // NewClusterConfig would return ClusterClient or would return error if error occured
 func NewClusterConfig(kafkaTopicNameRegexp string, options ...Option) (*ClusterConfig, error) {
 	// initialise cluster client
@@ -145,6 +155,7 @@ func (c *ClusterConfig) GetClient() (*ClusterClient, error) {
 	cl := &ClusterClient{
 		maxPartsPerTopic: c.maxPartsPerTopic,
 		topicNamePattern: c.topicNamePattern,
+		auditor:          c.auditor,
 	}
 	cl.kCl, err = kgo.NewClient(c.kOpts...)
 	if err != nil {
@@ -152,3 +163,12 @@ func (c *ClusterConfig) GetClient() (*ClusterClient, error) {
 	}
 	return cl, nil
 }
+
+// RawClient will make a new franz-go client with settings of cluster, i.e. for producing
+func (c *ClusterConfig) RawClient() (*kgo.Client, error) {
+	kCl, err := kgo.NewClient(c.kOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("can't make KafkaCluster client: %w", err)
+	}
+	return kCl, nil
+}
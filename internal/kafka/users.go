@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+const (
+	// DefaultSCRAMMechanism and DefaultSCRAMIterations are defaults of KafkaUser credential
+	DefaultSCRAMMechanism  = "SCRAM-SHA-512"
+	DefaultSCRAMIterations = 8192
+)
+
+// scramMechanism would return SCRAM mechanism by its name, i.e. SCRAM-SHA-512
+func scramMechanism(name string) (kadm.ScramMechanism, error) {
+	for _, mechanism := range []kadm.ScramMechanism{kadm.ScramSha256, kadm.ScramSha512} {
+		if mechanism.String() == name {
+			return mechanism, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown SCRAM mechanism %s", name)
+}
+
+// UserSCRAMs would return iterations of SCRAM credentials of user by their mechanisms, none if user doesn't exist
+func (c *ClusterClient) UserSCRAMs(ctx context.Context, user string) (map[string]int32, error) {
+	if c.kCl == nil {
+		return nil, fmt.Errorf("we don't have connection to Kafka cluster")
+	}
+	kAdm := kadm.NewClient(c.kCl)
+	resp, err := kAdm.DescribeUserSCRAMs(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("can't describe SCRAM credentials of user %s: %w", user, err)
+	}
+	credentials := map[string]int32{}
+	described, ok := resp[user]
+	if !ok || errors.Is(described.Err, kerr.ResourceNotFound) {
+		return credentials, nil
+	}
+	if described.Err != nil {
+		return nil, fmt.Errorf("can't describe SCRAM credentials of user %s, cluster err: %w: %s", user, described.Err, described.ErrMessage)
+	}
+	for _, info := range described.CredInfos {
+		credentials[info.Mechanism.String()] = info.Iterations
+	}
+	return credentials, nil
+}
+
+// UserSCRAM would return iterations of SCRAM credential of user with mechanism, 0 if user doesn't have it
+func (c *ClusterClient) UserSCRAM(ctx context.Context, user, mechanism string) (int32, error) {
+	_, err := scramMechanism(mechanism)
+	if err != nil {
+		return 0, err
+	}
+	credentials, err := c.UserSCRAMs(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+	return credentials[mechanism], nil
+}
+
+// UpsertUserSCRAM would create or update SCRAM credential of user with mechanism, password isn't audited
+func (c *ClusterClient) UpsertUserSCRAM(ctx context.Context, user, mechanism string, iterations int32, password string) error {
+	if c.kCl == nil {
+		return fmt.Errorf("we don't have connection to Kafka cluster")
+	}
+	m, err := scramMechanism(mechanism)
+	if err != nil {
+		return err
+	}
+	oldIterations, err := c.UserSCRAM(ctx, user, mechanism)
+	if err != nil {
+		return err
+	}
+	kAdm := kadm.NewClient(c.kCl)
+	resp, err := kAdm.AlterUserSCRAMs(ctx, nil, []kadm.UpsertSCRAM{{
+		User:       user,
+		Mechanism:  m,
+		Iterations: iterations,
+		Password:   password,
+	}})
+	if err == nil {
+		err = alteredSCRAMErr(resp, user)
+	}
+	if err != nil {
+		err = fmt.Errorf("can't upsert SCRAM credential of user %s: %w", user, err)
+	}
+	action, old := audit.ActionCreate, map[string]string(nil)
+	if oldIterations != 0 {
+		action, old = audit.ActionAlter, scramConfigs(mechanism, oldIterations)
+	}
+	c.audit(ctx, action, userResource(user), old, scramConfigs(mechanism, iterations), err)
+	return err
+}
+
+// DeleteUserSCRAM would delete SCRAM credentials of user with mechanisms, missing ones are ignored
+func (c *ClusterClient) DeleteUserSCRAM(ctx context.Context, user string, mechanisms ...string) error {
+	if c.kCl == nil {
+		return fmt.Errorf("we don't have connection to Kafka cluster")
+	}
+	kAdm := kadm.NewClient(c.kCl)
+	var errs error
+	for _, mechanism := range mechanisms {
+		iterations, err := c.UserSCRAM(ctx, user, mechanism)
+		if err != nil {
+			return err
+		}
+		if iterations == 0 {
+			continue
+		}
+		m, _ := scramMechanism(mechanism)
+		resp, err := kAdm.AlterUserSCRAMs(ctx, []kadm.DeleteSCRAM{{User: user, Mechanism: m}}, nil)
+		if err == nil {
+			err = alteredSCRAMErr(resp, user)
+		}
+		if err != nil {
+			err = fmt.Errorf("can't delete SCRAM credential %s of user %s: %w", mechanism, user, err)
+			errs = errors.Join(errs, err)
+		}
+		c.audit(ctx, audit.ActionDelete, userResource(user), scramConfigs(mechanism, iterations), nil, err)
+	}
+	return errs
+}
+
+// alteredSCRAMErr would return cluster error of altering SCRAM credentials of user
+func alteredSCRAMErr(resp kadm.AlteredUserSCRAMs, user string) error {
+	altered, ok := resp[user]
+	if !ok {
+		return fmt.Errorf("cluster didn't respond for user %s", user)
+	}
+	if altered.Err != nil {
+		return fmt.Errorf("%w: %s", altered.Err, altered.ErrMessage)
+	}
+	return nil
+}
+
+// userResource would return resource SCRAM credential of user is audited as
+func userResource(user string) string {
+	return fmt.Sprintf("user:%s", user)
+}
+
+// scramConfigs would return fields of SCRAM credential for audit
+func scramConfigs(mechanism string, iterations int32) map[string]string {
+	return map[string]string{
+		"mechanism":  mechanism,
+		"iterations": strconv.Itoa(int(iterations)),
+	}
+}
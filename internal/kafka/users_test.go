@@ -0,0 +1,42 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kerr"
+)
+
+func TestScramMechanism(t *testing.T) {
+	m, err := scramMechanism("SCRAM-SHA-256")
+	assert.NoError(t, err)
+	assert.Equal(t, kadm.ScramSha256, m)
+
+	m, err = scramMechanism("SCRAM-SHA-512")
+	assert.NoError(t, err)
+	assert.Equal(t, kadm.ScramSha512, m)
+
+	_, err = scramMechanism("PLAIN")
+	assert.EqualError(t, err, "unknown SCRAM mechanism PLAIN")
+}
+
+func TestUserSCRAM(t *testing.T) {
+	c := &ClusterClient{}
+	_, err := c.UserSCRAMs(context.Background(), "orders")
+	assert.EqualError(t, err, "we don't have connection to Kafka cluster")
+	_, err = c.UserSCRAM(context.Background(), "orders", "SCRAM-SHA-512")
+	assert.EqualError(t, err, "we don't have connection to Kafka cluster")
+	err = c.UpsertUserSCRAM(context.Background(), "orders", "SCRAM-SHA-512", 8192, "secret")
+	assert.EqualError(t, err, "we don't have connection to Kafka cluster")
+	err = c.DeleteUserSCRAM(context.Background(), "orders", "SCRAM-SHA-512")
+	assert.EqualError(t, err, "we don't have connection to Kafka cluster")
+
+	assert.NoError(t, alteredSCRAMErr(kadm.AlteredUserSCRAMs{"orders": {User: "orders"}}, "orders"))
+	assert.EqualError(t, alteredSCRAMErr(kadm.AlteredUserSCRAMs{}, "orders"), "cluster didn't respond for user orders")
+	err = alteredSCRAMErr(kadm.AlteredUserSCRAMs{"orders": {User: "orders", Err: kerr.UnacceptableCredential, ErrMessage: "too short"}}, "orders")
+	assert.ErrorIs(t, err, kerr.UnacceptableCredential)
+
+	assert.Equal(t, map[string]string{"mechanism": "SCRAM-SHA-256", "iterations": "4096"}, scramConfigs("SCRAM-SHA-256", 4096))
+}
@@ -0,0 +1,193 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/slack-go/slack"
+)
+
+const (
+	// MaxTextLength is a bit less than 3000 characters Slack allows in section text
+	MaxTextLength = 2900
+	// MaxDiffValueLength would keep large values, like schemas, readable in diff
+	MaxDiffValueLength = 300
+	// MaxBlocks is how many blocks Slack allows in one message
+	MaxBlocks = 50
+)
+
+// titles of messages by type
+var titles = map[MessageType]string{
+	OKMessage:    ":white_check_mark: OK",
+	WarnMessage:  ":warning: Warning",
+	ErrorMessage: ":rotating_light: Error",
+}
+
+// TitleChanged is title of successful messages about changed objects
+const TitleChanged = ":pencil2: Changed"
+
+// messageGroup is a list of messages about same object
+type messageGroup struct {
+	key      string
+	messages []Message
+}
+
+// groupMessages would group messages by object they are about, keeping order
+func groupMessages(messages []Message) []*messageGroup {
+	groups := []*messageGroup{}
+	byKey := make(map[string]*messageGroup)
+	for _, msg := range messages {
+		key := msg.threadKey()
+		group, ok := byKey[key]
+		if !ok {
+			group = &messageGroup{key: key}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.messages = append(group.messages, msg)
+	}
+	return groups
+}
+
+// severity would return most severe message type of group
+func (g *messageGroup) severity() MessageType {
+	severity := MessageType(OKMessage)
+	for _, msg := range g.messages {
+		if msg.MsgType() > severity {
+			severity = msg.MsgType()
+		}
+	}
+	return severity
+}
+
+// title would return title of message in Slack
+func (g *messageGroup) title() string {
+	for _, msg := range g.messages {
+		if msg.IsReport() {
+			return msg.report
+		}
+	}
+	severity := g.severity()
+	if severity == OKMessage {
+		for _, msg := range g.messages {
+			if msg.HasChanges() {
+				return TitleChanged
+			}
+		}
+	}
+	title, ok := titles[severity]
+	if !ok {
+		title = titles[ErrorMessage]
+	}
+	return title
+}
+
+// blocks would make Slack Block Kit blocks for group of messages
+func (g *messageGroup) blocks(cluster string) []slack.Block {
+	blocks := []slack.Block{
+		slack.NewHeaderBlock(slack.NewTextBlockObject(slack.PlainTextType, g.title(), true, false)),
+	}
+	// Object context is the same for all messages in group, we take it from the last one
+	last := g.messages[len(g.messages)-1]
+	fields := []*slack.TextBlockObject{}
+	addField := func(name, value string) {
+		if len(value) == 0 {
+			return
+		}
+		fields = append(fields, slack.NewTextBlockObject(slack.MarkdownType,
+			fmt.Sprintf("*%s*\n%s", name, value), false, false))
+	}
+	addField("Cluster", cluster)
+	addField("Namespace", last.namespace)
+	if len(last.kind) != 0 || len(last.name) != 0 {
+		addField("Resource", fmt.Sprintf("%s/%s", last.kind, last.name))
+	}
+	addField(last.targetLabel, last.target)
+	addField("Reason", last.reason)
+	if len(fields) != 0 {
+		blocks = append(blocks, slack.NewSectionBlock(nil, fields, nil))
+	}
+	for _, msg := range g.messages {
+		if msg.IsReport() {
+			// reports could be long, we split them into several sections
+			for _, chunk := range chunks(msg.message, MaxTextLength) {
+				blocks = append(blocks, slack.NewSectionBlock(
+					slack.NewTextBlockObject(slack.MarkdownType, chunk, false, false),
+					nil, nil))
+			}
+			continue
+		}
+		text := fmt.Sprintf("`%s` %s", msg.time.Format("2006-01-02 15:04:05"), msg.message)
+		blocks = append(blocks, slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType, truncate(text, MaxTextLength), false, false),
+			nil, nil))
+		if len(msg.diff) == 0 {
+			continue
+		}
+		lines := make([]string, 0, len(msg.diff))
+		for _, change := range msg.diff {
+			lines = append(lines, Change{
+				Field: change.Field,
+				Old:   truncate(change.Old, MaxDiffValueLength),
+				New:   truncate(change.New, MaxDiffValueLength),
+			}.String())
+		}
+		blocks = append(blocks, slack.NewSectionBlock(
+			slack.NewTextBlockObject(slack.MarkdownType,
+				fmt.Sprintf("```%s```", truncate(strings.Join(lines, "\n"), MaxTextLength)), false, false),
+			nil, nil))
+	}
+	blocks = append(blocks, slack.NewContextBlock("",
+		slack.NewImageBlockElement(BotLogo, BotName),
+		slack.NewTextBlockObject(slack.PlainTextType, BotName, false, false)))
+	return blocks
+}
+
+// pages would split blocks into pages Slack accepts as one message each
+func pages(blocks []slack.Block, maxBlocks int) [][]slack.Block {
+	result := [][]slack.Block{}
+	for len(blocks) > maxBlocks {
+		result = append(result, blocks[:maxBlocks])
+		blocks = blocks[maxBlocks:]
+	}
+	return append(result, blocks)
+}
+
+// text would return plain text fallback of group, used in notifications
+func (g *messageGroup) text() string {
+	lines := make([]string, 0, len(g.messages))
+	for _, msg := range g.messages {
+		lines = append(lines, msg.String())
+	}
+	return truncate(strings.Join(lines, "\n"), MaxTextLength)
+}
+
+// truncate would cut string to max characters
+func truncate(str string, maxLen int) string {
+	runes := []rune(str)
+	if len(runes) <= maxLen {
+		return str
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
+
+// chunks would split text by lines into chunks no longer than maxLen
+func chunks(text string, maxLen int) []string {
+	result := []string{}
+	current := []string{}
+	length := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = truncate(line, maxLen)
+		if length+len(line)+1 > maxLen && len(current) != 0 {
+			result = append(result, strings.Join(current, "\n"))
+			current = []string{}
+			length = 0
+		}
+		current = append(current, line)
+		length += len(line) + 1
+	}
+	if len(current) != 0 {
+		result = append(result, strings.Join(current, "\n"))
+	}
+	return result
+}
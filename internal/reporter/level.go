@@ -0,0 +1,59 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Notification levels
+const (
+	// LevelErrors would send only errors
+	LevelErrors Level = iota
+	// LevelChanges would send errors, warnings and messages about changed objects
+	LevelChanges
+	// LevelAll would send all messages, including objects which are in sync
+	LevelAll
+)
+
+// Level is a notification level of Messenger
+type Level uint8
+
+// ParseLevel would parse notification level from its name
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(strings.Trim(level, " \t")) {
+	case "", "errors":
+		return LevelErrors, nil
+	case "changes":
+		return LevelChanges, nil
+	case "all":
+		return LevelAll, nil
+	}
+	return LevelErrors, fmt.Errorf("unknown notification level `%s`, must be one of errors, changes, all", level)
+}
+
+// Allows would return true if message should be sent at this level
+func (l Level) Allows(msg *Message) bool {
+	if msg.IsReport() {
+		return true
+	}
+	switch msg.MsgType() {
+	case ErrorMessage:
+		return true
+	case WarnMessage:
+		return l >= LevelChanges
+	}
+	if msg.HasChanges() {
+		return l >= LevelChanges
+	}
+	return l >= LevelAll
+}
+
+func (l Level) String() string {
+	switch l {
+	case LevelChanges:
+		return "changes"
+	case LevelAll:
+		return "all"
+	}
+	return "errors"
+}
@@ -2,6 +2,7 @@ package reporter
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -18,16 +19,85 @@ type (
 		time    time.Time
 		message string
 		msgType MessageType
+		// object context, all of them are optional
+		kind      string
+		namespace string
+		name      string
+		// targetLabel is either Topic or Subject
+		targetLabel string
+		target      string
+		reason      string
+		diff        []Change
+		// report is title of scheduled report, reports are always sent
+		report string
+	}
+	// MessageField would add context to Message
+	MessageField func(*Message)
+	// Change is one changed field of object spec
+	Change struct {
+		Field string
+		Old   string
+		New   string
 	}
 )
 
+// Object would set Kubernetes object message is about
+func Object(kind, namespace, name string) MessageField {
+	return func(m *Message) {
+		m.kind = kind
+		m.namespace = namespace
+		m.name = name
+	}
+}
+
+// Topic would set Kafka topic message is about
+func Topic(topic string) MessageField {
+	return func(m *Message) {
+		m.targetLabel = "Topic"
+		m.target = topic
+	}
+}
+
+// Subject would set Schema Registry subject message is about
+func Subject(subject string) MessageField {
+	return func(m *Message) {
+		m.targetLabel = "Subject"
+		m.target = subject
+	}
+}
+
+// Reason would set reason of message, i.e. condition reason
+func Reason(reason string) MessageField {
+	return func(m *Message) {
+		m.reason = reason
+	}
+}
+
+// Diff would add changed fields of object spec
+func Diff(changes ...Change) MessageField {
+	return func(m *Message) {
+		m.diff = append(m.diff, changes...)
+	}
+}
+
+// Report would mark message as scheduled report with title
+func Report(title string) MessageField {
+	return func(m *Message) {
+		m.report = title
+	}
+}
+
 // NewMessage would initialises Message and would return it
-func NewMessage(msg string, msgType MessageType) *Message {
-	return &Message{
+func NewMessage(msg string, msgType MessageType, fields ...MessageField) *Message {
+	m := &Message{
 		time:    time.Now(),
 		message: msg,
 		msgType: msgType,
 	}
+	for _, field := range fields {
+		field(m)
+	}
+	return m
 }
 
 // MsgType would return type of this message
@@ -35,6 +105,37 @@ func (m *Message) MsgType() MessageType {
 	return m.msgType
 }
 
+// threadKey would return key of object message is about,
+// all messages about same object would go into same Slack thread
+func (m *Message) threadKey() string {
+	if len(m.kind) == 0 && len(m.name) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/%s", m.kind, m.namespace, m.name)
+}
+
 func (m *Message) String() string {
 	return fmt.Sprintf("%s: %s", m.time.Format("2006-01-02 15:04:05"), m.message)
 }
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, valueOrNone(c.Old), valueOrNone(c.New))
+}
+
+// valueOrNone would make empty values visible in diffs
+func valueOrNone(value string) string {
+	if len(strings.TrimSpace(value)) == 0 {
+		return "<none>"
+	}
+	return value
+}
+
+// IsReport would return true if message is scheduled report
+func (m *Message) IsReport() bool {
+	return len(m.report) != 0
+}
+
+// HasChanges would return true if message is about changed object
+func (m *Message) HasChanges() bool {
+	return len(m.diff) != 0
+}
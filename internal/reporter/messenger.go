@@ -5,35 +5,65 @@ import (
 	"fmt"
 	"net/http"
 	"reflect"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/slack-go/slack"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
-type Messenger struct {
-	tickInterval time.Duration
-	httpClient   *http.Client
-	slackChannel string
-	slackClient  Slack
-	slackChan    chan Message
-}
+type (
+	Messenger struct {
+		tickInterval time.Duration
+		bufferSize   int
+		cluster      string
+		level        Level
+		httpClient   *http.Client
+		slackChannel string
+		slackClient  Slack
+		slackChan    chan Message
+		// dropped counts messages which didn't fit into the buffer
+		dropped atomic.Uint64
+		// mu guards stopped, messages posted after Messenger stopped are logged
+		mu      sync.RWMutex
+		stopped bool
+		// reported is amount of dropped messages we already told Slack about,
+		// it is only touched from the run loop
+		reported uint64
+		// threads are Slack threads of objects, only touched from the run loop
+		threads map[string]*thread
+	}
+	// thread is Slack thread where all messages about one object go
+	thread struct {
+		ts       string
+		lastSeen time.Time
+	}
+)
+
+// Messenger is started and stopped by controller manager
+var _ manager.Runnable = &Messenger{}
 
 const (
-	FlushInterval   = 30 * time.Second
-	BotName         = "Kafka objects operator"
-	MsgColorOK      = "#00CC00"
-	MsgColorWarning = "#F5EC1E"
-	MsgColorError   = "#EE0000"
-	BotLogo         = "https://90poe-tools-infrastructure.s3.eu-west-1.amazonaws.com/images/k8s-logo.png"
+	FlushInterval     = 30 * time.Second
+	DefaultBufferSize = 100
+	// ThreadTTL is time after which we would start a new thread for object,
+	// if there were no messages about it
+	ThreadTTL    = 24 * time.Hour
+	MaxBatchSize = 10
+	BotName      = "Kafka objects operator"
+	BotLogo      = "https://90poe-tools-infrastructure.s3.eu-west-1.amazonaws.com/images/k8s-logo.png"
 )
 
+// New would make Messenger. Messenger is not sending anything until it is started,
+// add it to controller manager with mgr.Add() or call Start() directly.
 func New(token string, options ...Options) (*Messenger, error) {
 	mess := &Messenger{
 		tickInterval: FlushInterval,
+		bufferSize:   DefaultBufferSize,
 		httpClient:   &http.Client{},
-		slackChan:    make(chan Message),
+		threads:      make(map[string]*thread),
 	}
 	var err error
 	for _, option := range options {
@@ -42,6 +72,7 @@ func New(token string, options ...Options) (*Messenger, error) {
 			return nil, fmt.Errorf("can't make new Messenger object: %w", err)
 		}
 	}
+	mess.slackChan = make(chan Message, mess.bufferSize)
 	// Set default http client timeout if not specified
 	if mess.httpClient.Timeout == 0 {
 		mess.httpClient.Timeout = 10 * time.Second
@@ -54,98 +85,170 @@ func New(token string, options ...Options) (*Messenger, error) {
 			mess.slackClient = slack.New(token, slack.OptionHTTPClient(mess.httpClient))
 		}
 	}
-	// Run messenger
-	go mess.run()
 	return mess, nil
 }
 
-// Send will send message to slack
-func (m *Messenger) Send(msg string, msgType MessageType) {
-	m.slackChan <- Message{
-		time:    time.Now(),
-		message: msg,
-		msgType: msgType,
+// Send will queue message to be sent to slack
+func (m *Messenger) Send(msg string, msgType MessageType, fields ...MessageField) {
+	m.Post(NewMessage(msg, msgType, fields...))
+}
+
+// Post will queue message to be sent to slack. It never blocks: if buffer
+// is full message is dropped and counted, so slow Slack API can't block reconcile.
+// Messages below notification level of Messenger are ignored, messages posted
+// after Messenger stopped are logged, as they are sent only if it is started again.
+func (m *Messenger) Post(msg *Message) {
+	if !m.level.Allows(msg) {
+		return
 	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.stopped {
+		log.FromContext(context.Background()).WithValues("reporter", "slack").
+			Info("message is posted after messenger stopped", "message", msg.String())
+	}
+	select {
+	case m.slackChan <- *msg:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+// Dropped would return number of messages dropped due to buffer overflow
+func (m *Messenger) Dropped() uint64 {
+	return m.dropped.Load()
 }
 
-// run will send all messages from channel to slack
-// We will close if close channel is closed
-// We also flush every FlushInterval seconds
-func (m *Messenger) run() {
+// NeedLeaderElection is false, as every replica should report its own errors
+func (m *Messenger) NeedLeaderElection() bool {
+	return false
+}
+
+// Start will send all messages from channel to slack until context is cancelled.
+// We flush every FlushInterval seconds or when we have MaxBatchSize messages.
+// All queued messages are flushed on context cancellation.
+func (m *Messenger) Start(ctx context.Context) error {
+	m.setStopped(false)
 	messages := []Message{}
 	ticker := time.NewTicker(m.tickInterval)
-	defer func() {
-		close(m.slackChan)
-		m.flush(messages)
-	}()
+	defer ticker.Stop()
 	for {
 		select {
 		case msg := <-m.slackChan:
 			messages = append(messages, msg)
-			if len(messages) >= 10 {
+			if len(messages) >= MaxBatchSize {
 				m.flush(messages)
 				messages = []Message{}
 			}
 		case <-ticker.C:
 			m.flush(messages)
 			messages = []Message{}
+		case <-ctx.Done():
+			// drain whatever is left in buffer and flush it in batches,
+			// messages posted after that are only logged
+			m.setStopped(true)
+			for {
+				select {
+				case msg := <-m.slackChan:
+					messages = append(messages, msg)
+					if len(messages) >= MaxBatchSize {
+						m.flush(messages)
+						messages = []Message{}
+					}
+				default:
+					m.flush(messages)
+					return nil
+				}
+			}
 		}
 	}
 }
 
-// flush will prepare and sort all messages to be sent to slack
+// setStopped would mark Messenger as stopped or running
+func (m *Messenger) setStopped(stopped bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopped = stopped
+}
+
+// flush will group messages by object and send them to slack,
+// messages about object which we already reported are sent to its thread
 func (m *Messenger) flush(messages []Message) {
+	// report overflow, if we had any since last flush
+	dropped := m.dropped.Load()
+	if dropped > m.reported {
+		messages = append(messages, Message{
+			time: time.Now(),
+			message: fmt.Sprintf("%d message(s) were dropped as reporter buffer of %d is full",
+				dropped-m.reported, m.bufferSize),
+			msgType: WarnMessage,
+		})
+		m.reported = dropped
+	}
 	if len(messages) == 0 {
 		return
 	}
-	// divide messages by type
-	okMessages := []string{}
-	warningMessages := []string{}
-	errorMessages := []string{}
-	for _, msg := range messages {
-		switch msg.MsgType() {
-		case OKMessage:
-			okMessages = append(okMessages, msg.String())
-		case WarnMessage:
-			warningMessages = append(warningMessages, msg.String())
-		default:
-			errorMessages = append(errorMessages, msg.String())
+	now := time.Now()
+	for _, group := range groupMessages(messages) {
+		// messages without object are not threaded
+		if len(group.key) == 0 {
+			m.send(group, "")
+			continue
 		}
+		th, ok := m.threads[group.key]
+		if !ok || now.Sub(th.lastSeen) > ThreadTTL {
+			ts := m.send(group, "")
+			if len(ts) == 0 {
+				continue
+			}
+			th = &thread{ts: ts}
+			m.threads[group.key] = th
+		} else {
+			m.send(group, th.ts)
+		}
+		th.lastSeen = now
 	}
-	// send messages to slack
-	if len(okMessages) != 0 {
-		m.send(strings.Join(okMessages, "\n"), "OK", MsgColorOK)
-	}
-	if len(warningMessages) != 0 {
-		m.send(strings.Join(warningMessages, "\n"), "Warning", MsgColorWarning)
-	}
-	if len(errorMessages) != 0 {
-		m.send(strings.Join(errorMessages, "\n"), "OK", MsgColorError)
+	// forget threads we didn't hear about for long time
+	for key, th := range m.threads {
+		if now.Sub(th.lastSeen) > ThreadTTL {
+			delete(m.threads, key)
+		}
 	}
 }
 
-// send will send message to slack or to log
-func (m *Messenger) send(msg, title, color string) {
+// send will send group of messages to slack or to log, if threadTS is not empty
+// messages would be sent as reply to thread. Group with more than MaxBlocks blocks is sent
+// as several messages, the rest of them as reply to the first one. It returns ts of the first posted message.
+func (m *Messenger) send(group *messageGroup, threadTS string) string {
 	// system logger
 	reqLogger := log.FromContext(context.Background()).WithValues("reporter", "slack")
 	// Check if slack client is initialised
 	if m.slackClient == nil {
-		reqLogger.V(1).Info("Slack client is not initialised, can't send messages: %s", msg)
-		return
+		reqLogger.V(1).Info("Slack client is not initialised, can't send messages", "messages", group.text())
+		return ""
 	}
 	// Send message to slack
-	attachment := slack.Attachment{
-		Color:      color,
-		Title:      title,
-		Text:       msg,
-		AuthorName: BotName,
-		Footer:     BotName,
-		FooterIcon: BotLogo,
-	}
-	_, _, err := m.slackClient.PostMessage(m.slackChannel,
-		slack.MsgOptionUsername(BotName),
-		slack.MsgOptionAttachments(attachment))
-	if err != nil {
-		reqLogger.V(1).Info(fmt.Sprintf("can't send errors message to Slack: %v", err))
+	firstTS := ""
+	for _, blocks := range pages(group.blocks(m.cluster), MaxBlocks) {
+		options := []slack.MsgOption{
+			slack.MsgOptionUsername(BotName),
+			slack.MsgOptionText(group.text(), false),
+			slack.MsgOptionBlocks(blocks...),
+		}
+		if len(threadTS) != 0 {
+			options = append(options, slack.MsgOptionTS(threadTS))
+		}
+		_, ts, err := m.slackClient.PostMessage(m.slackChannel, options...)
+		if err != nil {
+			reqLogger.V(1).Info(fmt.Sprintf("can't send message to Slack: %v", err))
+			return firstTS
+		}
+		if len(firstTS) == 0 {
+			firstTS = ts
+		}
+		if len(threadTS) == 0 {
+			threadTS = ts
+		}
 	}
+	return firstTS
 }
@@ -1,11 +1,16 @@
 package reporter_test
 
 import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/90poe/kafkaobjects-operator/internal/reporter"
 	"github.com/90poe/kafkaobjects-operator/internal/reporter/mock_slack"
+	"github.com/slack-go/slack"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -23,8 +28,14 @@ func TestMessenger_Send(t *testing.T) {
 		reporter.SlackChannel("test-channel"),
 		reporter.SlackClient(mSlack),
 		reporter.TickInterval(5*time.Second),
+		reporter.NotificationLevel("all"),
 	)
 	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = m.Start(ctx)
+	}()
 	mSlack.EXPECT().PostMessage("test-channel", gomock.Any()).Return("", "", nil)
 	m.Send("test message 1", reporter.OKMessage)
 	time.Sleep(7 * time.Second)
@@ -32,3 +43,225 @@ func TestMessenger_Send(t *testing.T) {
 	m.Send("test message 2", reporter.OKMessage)
 	time.Sleep(7 * time.Second)
 }
+
+func TestMessenger_FlushOnStop(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mSlack := mock_slack.NewMockSlack(ctrl)
+	m, err := reporter.New(
+		"",
+		reporter.SlackChannel("test-channel"),
+		reporter.SlackClient(mSlack),
+		reporter.TickInterval(time.Hour),
+	)
+	require.NoError(t, err)
+	// messages are queued before messenger is even started
+	m.Send("test message 1", reporter.ErrorMessage)
+	m.Send("test message 2", reporter.ErrorMessage)
+	mSlack.EXPECT().PostMessage("test-channel", gomock.Any()).Return("", "", nil).Times(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Start(ctx)
+	}()
+	cancel()
+	select {
+	case err = <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("messenger didn't stop on context cancellation")
+	}
+}
+
+func TestMessenger_SendOverflow(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mSlack := mock_slack.NewMockSlack(ctrl)
+	m, err := reporter.New(
+		"",
+		reporter.SlackChannel("test-channel"),
+		reporter.SlackClient(mSlack),
+		reporter.BufferSize(2),
+	)
+	require.NoError(t, err)
+	// nobody reads the buffer, Send must not block
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			m.Send("test message", reporter.ErrorMessage)
+		}
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send blocked on full buffer")
+	}
+	require.Equal(t, uint64(3), m.Dropped())
+
+	// errors and overflow warning are flushed on stop
+	mSlack.EXPECT().PostMessage("test-channel", gomock.Any()).Return("", "", nil).Times(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, m.Start(ctx))
+}
+
+func TestMessenger_FlushOnStopInBatches(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mSlack := mock_slack.NewMockSlack(ctrl)
+	m, err := reporter.New(
+		"",
+		reporter.SlackChannel("test-channel"),
+		reporter.SlackClient(mSlack),
+		reporter.BufferSize(25),
+	)
+	require.NoError(t, err)
+	for i := 0; i < 25; i++ {
+		m.Send("test message", reporter.ErrorMessage)
+	}
+	// buffer is drained in batches of MaxBatchSize, so that each of them fits into Slack message
+	mSlack.EXPECT().PostMessage("test-channel", gomock.Any()).Return("", "", nil).Times(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, m.Start(ctx))
+}
+
+func TestMessenger_LongReport(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mSlack := mock_slack.NewMockSlack(ctrl)
+	m, err := reporter.New(
+		"",
+		reporter.SlackChannel("test-channel"),
+		reporter.SlackClient(mSlack),
+	)
+	require.NoError(t, err)
+	// every line is a section of its own, so report doesn't fit into one Slack message
+	line := strings.Repeat("x", reporter.MaxTextLength-10)
+	m.Send(strings.TrimSuffix(strings.Repeat(line+"\n", 60), "\n"), reporter.OKMessage, reporter.Report("Digest"))
+
+	posted := []url.Values{}
+	mSlack.EXPECT().PostMessage("test-channel", gomock.Any()).DoAndReturn(
+		func(channel string, options ...slack.MsgOption) (string, string, error) {
+			_, values, err := slack.UnsafeApplyMsgOptions("", channel, "", options...)
+			require.NoError(t, err)
+			posted = append(posted, values)
+			return channel, "1700000000.000100", nil
+		}).Times(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, m.Start(ctx))
+	for _, values := range posted {
+		blocks := []json.RawMessage{}
+		require.NoError(t, json.Unmarshal([]byte(values.Get("blocks")), &blocks))
+		require.LessOrEqual(t, len(blocks), reporter.MaxBlocks)
+	}
+	require.Empty(t, posted[0].Get("thread_ts"))
+	// rest of report is a reply to its first part
+	require.Equal(t, "1700000000.000100", posted[1].Get("thread_ts"))
+}
+
+func TestNew_BufferSize(t *testing.T) {
+	t.Parallel()
+
+	_, err := reporter.New("", reporter.BufferSize(0))
+	require.ErrorContains(t, err, "buffer size must be positive")
+}
+
+func TestMessenger_Threads(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mSlack := mock_slack.NewMockSlack(ctrl)
+	m, err := reporter.New(
+		"",
+		reporter.SlackChannel("test-channel"),
+		reporter.SlackClient(mSlack),
+		reporter.Cluster("test-cluster"),
+	)
+	require.NoError(t, err)
+
+	posted := []url.Values{}
+	mSlack.EXPECT().PostMessage("test-channel", gomock.Any()).DoAndReturn(
+		func(channel string, options ...slack.MsgOption) (string, string, error) {
+			_, values, err := slack.UnsafeApplyMsgOptions("", channel, "", options...)
+			require.NoError(t, err)
+			posted = append(posted, values)
+			return channel, "1700000000.000100", nil
+		}).Times(3)
+
+	sendAndStop := func(msgs ...*reporter.Message) {
+		for _, msg := range msgs {
+			m.Post(msg)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, m.Start(ctx))
+	}
+	topic := []reporter.MessageField{
+		reporter.Object("KafkaTopic", "default", "test"),
+		reporter.Topic("test-topic"),
+		reporter.Reason("UpdateTopic"),
+	}
+	sendAndStop(reporter.NewMessage("can't update topic", reporter.ErrorMessage,
+		append(topic, reporter.Diff(reporter.Change{Field: "retention.ms", Old: "1000", New: "2000"}))...))
+	// follow up about the same object goes to thread, other object starts a new one
+	sendAndStop(
+		reporter.NewMessage("still can't update topic", reporter.ErrorMessage, topic...),
+		reporter.NewMessage("can't create schema", reporter.ErrorMessage,
+			reporter.Object("KafkaSchema", "default", "test"),
+			reporter.Subject("test-value")),
+	)
+
+	require.Len(t, posted, 3)
+	require.Empty(t, posted[0].Get("thread_ts"))
+	blocks := posted[0].Get("blocks")
+	for _, expected := range []string{"test-cluster", "default", "KafkaTopic/test", "test-topic",
+		"UpdateTopic", "retention.ms: 1000"} {
+		require.True(t, strings.Contains(blocks, expected), "blocks must contain %q: %s", expected, blocks)
+	}
+	require.Equal(t, "1700000000.000100", posted[1].Get("thread_ts"))
+	require.Empty(t, posted[2].Get("thread_ts"))
+}
+
+func TestLevel_Allows(t *testing.T) {
+	t.Parallel()
+
+	changed := reporter.Diff(reporter.Change{Field: "retention.ms", Old: "1000", New: "2000"})
+	tests := []struct {
+		level   string
+		msg     *reporter.Message
+		allowed bool
+	}{
+		{level: "errors", msg: reporter.NewMessage("error", reporter.ErrorMessage), allowed: true},
+		{level: "errors", msg: reporter.NewMessage("drift", reporter.WarnMessage, changed), allowed: false},
+		{level: "errors", msg: reporter.NewMessage("changed", reporter.OKMessage, changed), allowed: false},
+		{level: "changes", msg: reporter.NewMessage("drift", reporter.WarnMessage, changed), allowed: true},
+		{level: "changes", msg: reporter.NewMessage("changed", reporter.OKMessage, changed), allowed: true},
+		{level: "changes", msg: reporter.NewMessage("in sync", reporter.OKMessage), allowed: false},
+		{level: "all", msg: reporter.NewMessage("in sync", reporter.OKMessage), allowed: true},
+	}
+	for _, test := range tests {
+		level, err := reporter.ParseLevel(test.level)
+		require.NoError(t, err)
+		require.Equal(t, test.allowed, level.Allows(test.msg), "level %s", test.level)
+	}
+	_, err := reporter.ParseLevel("verbose")
+	require.ErrorContains(t, err, "unknown notification level")
+}
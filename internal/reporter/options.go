@@ -31,6 +31,23 @@ func SlackChannel(sl string) Options {
 	}
 }
 
+// Cluster will add name of cluster we are reporting from
+func Cluster(name string) Options {
+	return func(s *Messenger) error {
+		s.cluster = strings.Trim(name, " \t")
+		return nil
+	}
+}
+
+// NotificationLevel will set which messages are sent: errors, changes or all
+func NotificationLevel(level string) Options {
+	return func(s *Messenger) error {
+		var err error
+		s.level, err = ParseLevel(level)
+		return err
+	}
+}
+
 // SlackClient will add slack client for mocking purposes
 // only use it in tests
 func SlackClient(sl Slack) Options {
@@ -40,8 +57,18 @@ func SlackClient(sl Slack) Options {
 	}
 }
 
-// SlackClient will add slack client for mocking purposes
-// only use it in tests
+// BufferSize will set how many messages could be queued before we start to drop them
+func BufferSize(size int) Options {
+	return func(s *Messenger) error {
+		if size <= 0 {
+			return fmt.Errorf("buffer size must be positive, got %d", size)
+		}
+		s.bufferSize = size
+		return nil
+	}
+}
+
+// TickInterval will set how often we flush messages to slack
 func TickInterval(tk time.Duration) Options {
 	return func(s *Messenger) error {
 		s.tickInterval = tk
@@ -0,0 +1,107 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentType of Schema Registry API
+const ContentType = "application/vnd.schemaregistry.v1+json"
+
+// Errors of Schema Registry API callers would check with errors.Is
+var (
+	ErrSubjectNotFound = errors.New("subject not found")
+	ErrVersionNotFound = errors.New("version not found")
+	ErrSchemaNotFound  = errors.New("schema not found")
+)
+
+// APIError is error returned by Schema Registry API
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("schema registry error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Is would match APIError with ErrSubjectNotFound, ErrVersionNotFound and ErrSchemaNotFound by error code
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrSubjectNotFound:
+		return e.Code == ErrorCodeSubjectNotFound
+	case ErrVersionNotFound:
+		return e.Code == ErrorCodeVersionNotFound
+	case ErrSchemaNotFound:
+		return e.Code == ErrorCodeSchemaNotFound
+	}
+	return false
+}
+
+// do would call Schema Registry API, in and out are JSON bodies of request and response
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("can't marshal request to %s: %w", path, err)
+		}
+	}
+	status, data, err := c.send(ctx, method, strings.TrimSuffix(c.schemaRegURL, "/")+path,
+		map[string]string{"Accept": ContentType, "Content-Type": ContentType}, body)
+	if err != nil {
+		return err
+	}
+	if status >= http.StatusBadRequest {
+		apiErr := &APIError{StatusCode: status}
+		if json.Unmarshal(data, apiErr) != nil || len(apiErr.Message) == 0 {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return apiErr
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("can't parse response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// send would make HTTP request with credentials of Client, returning status and body of response.
+// Content-Type header is set only if request has body.
+func (c *Client) send(ctx context.Context, method, url string, headers map[string]string, body []byte) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't make request to %s: %w", url, err)
+	}
+	for name, value := range headers {
+		if name == "Content-Type" && body == nil {
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't %s %s: %w", method, url, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("can't read response of %s %s: %w", method, url, err)
+	}
+	return resp.StatusCode, data, nil
+}
@@ -0,0 +1,833 @@
+package schemaregistry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+)
+
+// Apicurio Registry API versions, see https://www.apicur.io/registry/docs/
+const (
+	ApicurioAPIv2 = "v2"
+	ApicurioAPIv3 = "v3"
+	// apicurioPageSize is how many artifacts or versions are listed by one request
+	apicurioPageSize = 500
+	// apicurioCompatibilityRule is rule of artifact or global rule checking compatibility of new versions
+	apicurioCompatibilityRule = "COMPATIBILITY"
+	// apicurioDisabled is state of soft deleted versions
+	apicurioDisabled = "DISABLED"
+	// apicurioRuleViolation is name of error returned for incompatible or invalid content
+	apicurioRuleViolation = "RuleViolationException"
+	// apicurioExtendedContent is content type of v2 requests with references
+	apicurioExtendedContent = "application/create.extended+json"
+)
+
+// ApicurioClient is Backend using native API of Apicurio Registry. Subjects are artifacts of one group,
+// subjects qualified with context are artifacts of group named by context. IDs are content IDs and
+// compatibility levels are COMPATIBILITY rules. Soft deleted versions are disabled.
+// Apicurio Registry doesn't have modes, so subjects are always writable and IDs can't be pinned.
+type ApicurioClient struct {
+	// client makes requests with credentials and TLS config of options and audits changes
+	client     *Client
+	apiVersion string
+	group      string
+}
+
+type (
+	// apicurioReference is reference to version of artifact
+	apicurioReference struct {
+		GroupID    string `json:"groupId,omitempty"`
+		ArtifactID string `json:"artifactId"`
+		Version    string `json:"version"`
+		Name       string `json:"name"`
+	}
+	// apicurioContent is content of version with its references, content type is set only in v3
+	apicurioContent struct {
+		Content     string              `json:"content"`
+		ContentType string              `json:"contentType,omitempty"`
+		References  []apicurioReference `json:"references,omitempty"`
+	}
+	// apicurioVersion is metadata of artifact or its version, artifact ID is id in v2 and artifactId in v3
+	apicurioVersion struct {
+		GroupID    string `json:"groupId"`
+		ID         string `json:"id"`
+		ArtifactID string `json:"artifactId"`
+		Version    string `json:"version"`
+		GlobalID   int64  `json:"globalId"`
+		ContentID  int64  `json:"contentId"`
+		State      string `json:"state"`
+	}
+	// apicurioList is page of artifacts or versions
+	apicurioList struct {
+		Count     int               `json:"count"`
+		Artifacts []apicurioVersion `json:"artifacts"`
+		Versions  []apicurioVersion `json:"versions"`
+	}
+	// apicurioRule is rule of artifact, type of rule is type in v2 and ruleType in v3
+	apicurioRule struct {
+		Type     string `json:"type,omitempty"`
+		RuleType string `json:"ruleType,omitempty"`
+		Config   string `json:"config"`
+	}
+	// apicurioError is error of Apicurio Registry, v3 returns problem details with status and detail
+	apicurioError struct {
+		ErrorCode int    `json:"error_code"`
+		Status    int    `json:"status"`
+		Message   string `json:"message"`
+		Detail    string `json:"detail"`
+		Name      string `json:"name"`
+		Causes    []struct {
+			Description string `json:"description"`
+			Context     string `json:"context"`
+		} `json:"causes"`
+	}
+)
+
+// NewApicurioClient would make client of Apicurio Registry API v2 or v3 managing artifacts of group.
+// URL of options is URL of registry, without /apis path.
+func NewApicurioClient(apiVersion, group string, options ...Option) (*ApicurioClient, error) {
+	if apiVersion != ApicurioAPIv2 && apiVersion != ApicurioAPIv3 {
+		return nil, fmt.Errorf("error creating new apicurio registry client: unknown API version %s, must be %s or %s",
+			apiVersion, ApicurioAPIv2, ApicurioAPIv3)
+	}
+	if len(group) == 0 {
+		return nil, fmt.Errorf("error creating new apicurio registry client: group must be set")
+	}
+	client, err := NewClient(options...)
+	if err != nil {
+		return nil, err
+	}
+	return &ApicurioClient{client: client, apiVersion: apiVersion, group: group}, nil
+}
+
+// CreateSchema creates or updates a schema and returns its registration or an error.
+// Schema which is already registered under subject isn't registered again.
+func (a *ApicurioClient) CreateSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error) {
+	_, err := parseSchemaType(schema)
+	if err != nil {
+		return nil, err
+	}
+	reg, err := a.LookupSchema(ctx, schema, references)
+	if err != nil && !errors.Is(err, ErrSubjectNotFound) && !errors.Is(err, ErrSchemaNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return a.registerSchema(ctx, schema, references, errors.Is(err, ErrSubjectNotFound))
+	}
+	return reg, nil
+}
+
+// ImportSchema would fail, as Apicurio Registry assigns IDs itself
+func (a *ApicurioClient) ImportSchema(_ context.Context, schema *v1alpha1.KafkaSchemaSpec, _ []v1alpha1.ResolvedReference) (*Registration, error) {
+	return nil, fmt.Errorf("can't import schema %s: IDs can't be pinned in Apicurio Registry", schema.Name)
+}
+
+// registerSchema would create new version of artifact, auditing it. Compatibility rule of spec is set
+// once artifact is created, as rules can't be set before artifact exists.
+func (a *ApicurioClient) registerSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference, newArtifact bool) (*Registration, error) {
+	// Previous version is needed only for audit
+	old := map[string]string{}
+	if !newArtifact {
+		latest, err := a.LatestSchema(ctx, schema.Name)
+		if err != nil {
+			return nil, err
+		}
+		version, err := a.LatestVersion(ctx, schema.Name)
+		if err != nil {
+			return nil, err
+		}
+		old["schema"] = latest
+		old["version"] = strconv.Itoa(version)
+	}
+	var err error
+	group, artifact := a.locate(schema.Name)
+	if a.apiVersion == ApicurioAPIv2 {
+		contentType, body := a.content(schema, references)
+		err = a.do(ctx, http.MethodPost, fmt.Sprintf("/groups/%s/artifacts?ifExists=UPDATE&canonical=true", url.PathEscape(group)),
+			map[string]string{
+				"Content-Type":            contentType,
+				"X-Registry-ArtifactId":   artifact,
+				"X-Registry-ArtifactType": SchemaType(schema),
+			}, body, nil)
+	} else {
+		err = a.do(ctx, http.MethodPost, fmt.Sprintf("/groups/%s/artifacts?ifExists=CREATE_VERSION&canonical=true", url.PathEscape(group)),
+			nil, map[string]any{
+				"artifactId":   artifact,
+				"artifactType": SchemaType(schema),
+				"firstVersion": map[string]any{"content": a.contentV3(schema, references)},
+			}, nil)
+	}
+	if err != nil {
+		err = fmt.Errorf("can't register schema %s: %w", schema.Name, err)
+	}
+	if err == nil && newArtifact && len(schema.Compatibility) != 0 {
+		_, err = a.ReconcileCompatibility(ctx, schema)
+	}
+	var reg *Registration
+	if err == nil {
+		reg, err = a.LookupSchema(ctx, schema, references)
+	}
+	action := audit.ActionCreate
+	if len(old) != 0 {
+		action = audit.ActionAlter
+	}
+	new := map[string]string{
+		"schema":     schema.Schema,
+		"schemaType": SchemaType(schema),
+	}
+	if err == nil {
+		new["version"] = strconv.Itoa(reg.Version)
+	}
+	if len(references) != 0 {
+		new["references"] = FormatReferences(references)
+	}
+	a.client.audit(ctx, action, schema.Name, old, new, err)
+	return reg, err
+}
+
+// LookupSchema would find version of artifact with the same canonical content, disabled versions are skipped.
+// Errors wrap ErrSubjectNotFound or ErrSchemaNotFound if schema isn't registered.
+// Schema with metadata or rule set can't be found, as Apicurio Registry doesn't support them.
+func (a *ApicurioClient) LookupSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error) {
+	if len(FormatMetadata(schema.Metadata))+len(FormatRuleSet(schema.RuleSet)) != 0 {
+		return nil, fmt.Errorf("can't lookup schema %s: metadata and rule set aren't supported by Apicurio Registry", schema.Name)
+	}
+	version := &apicurioVersion{}
+	var err error
+	if a.apiVersion == ApicurioAPIv2 {
+		contentType, body := a.content(schema, references)
+		err = a.do(ctx, http.MethodPost, a.artifactPath(schema.Name)+"/meta?canonical=true",
+			map[string]string{"Content-Type": contentType}, body, version)
+		if err == nil && version.State == apicurioDisabled {
+			err = &APIError{StatusCode: http.StatusNotFound, Code: ErrorCodeSchemaNotFound, Message: "Schema not found."}
+		}
+	} else {
+		found := &apicurioList{}
+		group, artifact := a.locate(schema.Name)
+		query := url.Values{
+			"canonical":  {"true"},
+			"groupId":    {group},
+			"artifactId": {artifact},
+			"orderby":    {"globalId"},
+			"order":      {"desc"},
+		}
+		err = a.do(ctx, http.MethodPost, "/search/versions?"+query.Encode(),
+			map[string]string{"Content-Type": apicurioContentType(schema)}, []byte(schema.Schema), found)
+		for i := range found.Versions {
+			if found.Versions[i].State != apicurioDisabled {
+				version = &found.Versions[i]
+				break
+			}
+		}
+		if err == nil && len(version.Version) == 0 {
+			err = &APIError{StatusCode: http.StatusNotFound, Code: ErrorCodeSchemaNotFound, Message: "Schema not found."}
+		}
+	}
+	// artifact without the same content is told apart from missing one
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound && !errors.Is(err, ErrSubjectNotFound) {
+		exists, eErr := a.SchemaExists(ctx, schema.Name)
+		switch {
+		case eErr != nil:
+			err = eErr
+		case exists:
+			err = &APIError{StatusCode: http.StatusNotFound, Code: ErrorCodeSchemaNotFound, Message: apiErr.Message}
+		default:
+			err = &APIError{StatusCode: http.StatusNotFound, Code: ErrorCodeSubjectNotFound, Message: apiErr.Message}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't lookup schema %s: %w", schema.Name, err)
+	}
+	reg := &Registration{
+		Subject:     schema.Name,
+		ID:          int(version.ContentID),
+		SchemaType:  SchemaType(schema),
+		Fingerprint: Fingerprint(schema),
+	}
+	reg.Version, err = parseVersion(schema.Name, version.Version)
+	if err != nil {
+		return nil, err
+	}
+	reg.CompatibilityLevel, err = a.CompatibilityLevel(ctx, schema.Name)
+	if err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// CheckCompatibility would check if schema is compatible with versions of artifact by its rules,
+// without creating new version. Schema of artifact without versions is always compatible.
+func (a *ApicurioClient) CheckCompatibility(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Compatibility, error) {
+	var status int
+	var data []byte
+	var err error
+	if a.apiVersion == ApicurioAPIv2 {
+		contentType, body := a.content(schema, references)
+		status, data, err = a.send(ctx, http.MethodPut, a.artifactPath(schema.Name)+"/test",
+			map[string]string{"Content-Type": contentType}, body)
+	} else {
+		status, data, err = a.send(ctx, http.MethodPost, a.artifactPath(schema.Name)+"/versions?dryRun=true",
+			nil, map[string]any{"content": a.contentV3(schema, references)})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't check compatibility of schema %s: %w", schema.Name, err)
+	}
+	if status < http.StatusBadRequest || status == http.StatusNotFound {
+		return &Compatibility{IsCompatible: true}, nil
+	}
+	apiErr := &apicurioError{}
+	_ = json.Unmarshal(data, apiErr)
+	if apiErr.Name != apicurioRuleViolation {
+		return nil, fmt.Errorf("can't check compatibility of schema %s: %w", schema.Name, newApicurioAPIError(status, data))
+	}
+	result := &Compatibility{}
+	for _, cause := range apiErr.Causes {
+		message := cause.Description
+		if len(cause.Context) != 0 {
+			message = fmt.Sprintf("%s at %s", cause.Description, cause.Context)
+		}
+		result.Messages = append(result.Messages, message)
+	}
+	if len(result.Messages) == 0 {
+		result.Messages = []string{apiErr.message()}
+	}
+	return result, nil
+}
+
+// SchemaExists will check if artifact has versions which aren't disabled or return an error
+func (a *ApicurioClient) SchemaExists(ctx context.Context, subject string) (bool, error) {
+	_, err := a.latest(ctx, subject)
+	if errors.Is(err, ErrSubjectNotFound) || errors.Is(err, ErrVersionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LatestSchema would return content of latest version of artifact
+func (a *ApicurioClient) LatestSchema(ctx context.Context, subject string) (string, error) {
+	path := a.artifactPath(subject)
+	if a.apiVersion == ApicurioAPIv3 {
+		path += "/versions/branch=latest/content"
+	}
+	content := ""
+	err := a.do(ctx, http.MethodGet, path, nil, nil, &content)
+	if err != nil {
+		return "", fmt.Errorf("can't get latest version of %s: %w", subject, err)
+	}
+	return content, nil
+}
+
+// LatestVersion would return latest version of artifact
+func (a *ApicurioClient) LatestVersion(ctx context.Context, subject string) (int, error) {
+	latest, err := a.latest(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+	return parseVersion(subject, latest.Version)
+}
+
+// latest would return metadata of latest version of artifact.
+// Error wraps ErrSubjectNotFound if artifact doesn't exist.
+func (a *ApicurioClient) latest(ctx context.Context, subject string) (*apicurioVersion, error) {
+	path := a.artifactPath(subject) + "/meta"
+	if a.apiVersion == ApicurioAPIv3 {
+		path = a.artifactPath(subject) + "/versions/branch=latest"
+	}
+	version := &apicurioVersion{}
+	err := a.do(ctx, http.MethodGet, path, nil, nil, version)
+	if err != nil {
+		return nil, fmt.Errorf("can't get latest version of %s: %w", subject, err)
+	}
+	return version, nil
+}
+
+// Close would close idle connections of Apicurio Registry client
+func (a *ApicurioClient) Close() {
+	a.client.Close()
+}
+
+// Subjects would return IDs of all artifacts, artifacts of other groups are qualified with group as context
+func (a *ApicurioClient) Subjects(ctx context.Context) ([]string, error) {
+	subjects := []string{}
+	for offset := 0; ; offset += apicurioPageSize {
+		page := &apicurioList{}
+		err := a.do(ctx, http.MethodGet, fmt.Sprintf("/search/artifacts?limit=%d&offset=%d",
+			apicurioPageSize, offset), nil, nil, page)
+		if err != nil {
+			return nil, fmt.Errorf("can't get artifacts: %w", err)
+		}
+		for _, artifact := range page.Artifacts {
+			subjects = append(subjects, a.subject(artifact.GroupID, artifact.artifactID()))
+		}
+		if len(page.Artifacts) < apicurioPageSize {
+			return subjects, nil
+		}
+	}
+}
+
+// Versions would return all versions of artifact which aren't disabled
+func (a *ApicurioClient) Versions(ctx context.Context, subject string) ([]int, error) {
+	listed, err := a.versions(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]int, 0, len(listed))
+	for _, version := range listed {
+		number, err := parseVersion(subject, version.Version)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, number)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
+
+// versions would return metadata of versions of artifact which aren't disabled
+func (a *ApicurioClient) versions(ctx context.Context, subject string) ([]apicurioVersion, error) {
+	versions := []apicurioVersion{}
+	for offset := 0; ; offset += apicurioPageSize {
+		page := &apicurioList{}
+		err := a.do(ctx, http.MethodGet, fmt.Sprintf("%s/versions?limit=%d&offset=%d",
+			a.artifactPath(subject), apicurioPageSize, offset), nil, nil, page)
+		if err != nil {
+			return nil, fmt.Errorf("can't get versions of %s: %w", subject, err)
+		}
+		for _, version := range page.Versions {
+			if version.State != apicurioDisabled {
+				versions = append(versions, version)
+			}
+		}
+		if len(page.Versions) < apicurioPageSize {
+			return versions, nil
+		}
+	}
+}
+
+// ReferencedBy would return artifacts and versions, formatted as artifact:version,
+// which reference any version of artifact
+func (a *ApicurioClient) ReferencedBy(ctx context.Context, subject string) ([]string, error) {
+	versions, err := a.versions(ctx, subject)
+	if errors.Is(err, ErrSubjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	referencing := map[string]bool{}
+	for _, version := range versions {
+		refs, err := a.referencing(ctx, subject, version)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			referencing[fmt.Sprintf("%s:%s", a.subject(ref.GroupID, ref.ArtifactID), ref.Version)] = true
+		}
+	}
+	result := make([]string, 0, len(referencing))
+	for ref := range referencing {
+		result = append(result, ref)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// referencing would return versions of artifacts referencing version
+func (a *ApicurioClient) referencing(ctx context.Context, subject string, version apicurioVersion) ([]apicurioReference, error) {
+	refs := []apicurioReference{}
+	err := a.do(ctx, http.MethodGet, fmt.Sprintf("/ids/globalIds/%d/references?refType=INBOUND", version.GlobalID), nil, nil, &refs)
+	if err != nil {
+		return nil, fmt.Errorf("can't get artifacts referencing %s version %s: %w", subject, version.Version, err)
+	}
+	return refs, nil
+}
+
+// DeleteSubject would disable all versions of artifact, and if permanent is true, delete artifact
+func (a *ApicurioClient) DeleteSubject(ctx context.Context, subject string, permanent bool) error {
+	versions, err := a.versions(ctx, subject)
+	if errors.Is(err, ErrSubjectNotFound) {
+		// nothing to delete
+		return nil
+	}
+	deleted := []string{}
+	if err == nil && permanent {
+		err = a.do(ctx, http.MethodDelete, a.artifactPath(subject), nil, nil, nil)
+		for _, version := range versions {
+			deleted = append(deleted, version.Version)
+		}
+	}
+	if err == nil && !permanent {
+		for _, version := range versions {
+			err = a.disable(ctx, subject, version.Version)
+			if err != nil {
+				break
+			}
+			deleted = append(deleted, version.Version)
+		}
+	}
+	policy := DeletionPolicySoftDelete
+	if permanent {
+		policy = DeletionPolicyHardDelete
+	}
+	a.client.audit(ctx, audit.ActionDelete, subject,
+		map[string]string{"versions": strings.Join(deleted, ",")},
+		map[string]string{"deletionPolicy": policy}, err)
+	if err != nil {
+		return fmt.Errorf("can't delete subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// PruneVersions would disable versions of artifact older than the last retention ones.
+// Versions referenced by other artifacts and version keep, which schema of spec is registered as,
+// are never disabled. Disabled versions are returned.
+func (a *ApicurioClient) PruneVersions(ctx context.Context, subject string, retention, keep int) ([]int, error) {
+	versions, err := a.versions(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) <= retention {
+		return nil, nil
+	}
+	numbers := make(map[string]int, len(versions))
+	for _, version := range versions {
+		numbers[version.Version], err = parseVersion(subject, version.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return numbers[versions[i].Version] < numbers[versions[j].Version]
+	})
+	pruned := []int{}
+	for _, version := range versions[:len(versions)-retention] {
+		if numbers[version.Version] == keep {
+			continue
+		}
+		refs, rErr := a.referencing(ctx, subject, version)
+		if rErr != nil {
+			err = rErr
+			break
+		}
+		if len(refs) != 0 {
+			continue
+		}
+		err = a.disable(ctx, subject, version.Version)
+		if err != nil {
+			break
+		}
+		pruned = append(pruned, numbers[version.Version])
+	}
+	if len(pruned) != 0 || err != nil {
+		deleted := make([]string, 0, len(pruned))
+		for _, version := range pruned {
+			deleted = append(deleted, strconv.Itoa(version))
+		}
+		a.client.audit(ctx, audit.ActionDelete, subject,
+			map[string]string{"versions": strings.Join(deleted, ",")},
+			map[string]string{"versionRetention": strconv.Itoa(retention)}, err)
+	}
+	return pruned, err
+}
+
+// disable would soft delete version of artifact
+func (a *ApicurioClient) disable(ctx context.Context, subject, version string) error {
+	err := a.do(ctx, http.MethodPut, fmt.Sprintf("%s/versions/%s/state", a.artifactPath(subject), url.PathEscape(version)),
+		nil, map[string]string{"state": apicurioDisabled}, nil)
+	if err != nil {
+		return fmt.Errorf("can't disable %s version %s: %w", subject, version, err)
+	}
+	return nil
+}
+
+// CompatibilityLevel would return effective compatibility level of artifact, which is global one
+// if artifact doesn't have its own. Artifacts without rules aren't checked, so their level is NONE.
+func (a *ApicurioClient) CompatibilityLevel(ctx context.Context, subject string) (string, error) {
+	level, err := a.rule(ctx, a.artifactPath(subject))
+	if err != nil || len(level) != 0 {
+		return level, err
+	}
+	level, err = a.rule(ctx, "/admin")
+	if err != nil || len(level) != 0 {
+		return level, err
+	}
+	return KafkaSchemaRegistryCompatibilityNone, nil
+}
+
+// ReconcileCompatibility would set compatibility rule of artifact from spec if it differs from current one,
+// rule is deleted if spec doesn't have it. Rule of missing artifact is set when it is registered.
+// Previous level of artifact is returned.
+func (a *ApicurioClient) ReconcileCompatibility(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec) (string, error) {
+	desired := strings.ToUpper(schema.Compatibility)
+	exists, err := a.SchemaExists(ctx, schema.Name)
+	if err != nil || !exists {
+		return "", err
+	}
+	current, err := a.rule(ctx, a.artifactPath(schema.Name))
+	if err != nil {
+		return "", err
+	}
+	if current == desired {
+		return current, nil
+	}
+	err = a.setRule(ctx, a.artifactPath(schema.Name), current, desired)
+	a.client.audit(ctx, audit.ActionCompatibility, schema.Name,
+		map[string]string{"compatibility": current},
+		map[string]string{"compatibility": desired}, err)
+	return current, err
+}
+
+// ReconcileGlobalCompatibility would set global compatibility rule if it differs from current one,
+// empty level leaves it as is. Previous global level is returned.
+func (a *ApicurioClient) ReconcileGlobalCompatibility(ctx context.Context, level string) (string, error) {
+	desired := strings.ToUpper(level)
+	current, err := a.rule(ctx, "/admin")
+	if err != nil {
+		return "", err
+	}
+	if len(desired) == 0 || current == desired {
+		return current, nil
+	}
+	err = a.setRule(ctx, "/admin", current, desired)
+	a.client.audit(ctx, audit.ActionCompatibility, globalResource,
+		map[string]string{"compatibility": current},
+		map[string]string{"compatibility": desired}, err)
+	return current, err
+}
+
+// rule would return config of compatibility rule of artifact or, for /admin path, global one.
+// Empty string is returned if rule isn't configured.
+func (a *ApicurioClient) rule(ctx context.Context, path string) (string, error) {
+	rule := &apicurioRule{}
+	err := a.do(ctx, http.MethodGet, path+"/rules/"+apicurioCompatibilityRule, nil, nil, rule)
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't get compatibility rule of %s: %w", path, err)
+	}
+	return rule.Config, nil
+}
+
+// setRule would create, update or, for empty level, delete compatibility rule
+func (a *ApicurioClient) setRule(ctx context.Context, path, current, level string) error {
+	rule := &apicurioRule{Config: level}
+	if a.apiVersion == ApicurioAPIv2 {
+		rule.Type = apicurioCompatibilityRule
+	} else {
+		rule.RuleType = apicurioCompatibilityRule
+	}
+	var err error
+	switch {
+	case len(level) == 0:
+		err = a.do(ctx, http.MethodDelete, path+"/rules/"+apicurioCompatibilityRule, nil, nil, nil)
+	case len(current) == 0:
+		err = a.do(ctx, http.MethodPost, path+"/rules", nil, rule, nil)
+	default:
+		err = a.do(ctx, http.MethodPut, path+"/rules/"+apicurioCompatibilityRule, nil, rule, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set compatibility %s for %s: %w", level, path, err)
+	}
+	return nil
+}
+
+// Mode would return READWRITE, artifacts of Apicurio Registry are always writable
+func (a *ApicurioClient) Mode(_ context.Context, _ string) (string, error) {
+	return ModeReadWrite, nil
+}
+
+// ReconcileMode would fail for any mode but READWRITE, Apicurio Registry doesn't have modes
+func (a *ApicurioClient) ReconcileMode(_ context.Context, schema *v1alpha1.KafkaSchemaSpec) (string, error) {
+	return reconcileApicurioMode(schema.Mode)
+}
+
+// ReconcileGlobalMode would fail for any mode but READWRITE, Apicurio Registry doesn't have modes
+func (a *ApicurioClient) ReconcileGlobalMode(_ context.Context, mode string) (string, error) {
+	current, err := reconcileApicurioMode(mode)
+	if len(current) == 0 {
+		current = ModeReadWrite
+	}
+	return current, err
+}
+
+func reconcileApicurioMode(mode string) (string, error) {
+	desired := strings.ToUpper(mode)
+	if len(desired) != 0 && desired != ModeReadWrite {
+		return "", fmt.Errorf("failed to set mode %s: Apicurio Registry doesn't support modes", desired)
+	}
+	return desired, nil
+}
+
+// artifactPath would return path of artifact of subject
+func (a *ApicurioClient) artifactPath(subject string) string {
+	group, artifact := a.locate(subject)
+	return fmt.Sprintf("/groups/%s/artifacts/%s", url.PathEscape(group), url.PathEscape(artifact))
+}
+
+// locate would return group and ID of artifact of subject, context of subject is its group
+func (a *ApicurioClient) locate(subject string) (string, string) {
+	group, artifact := SplitSubject(subject)
+	if len(group) == 0 {
+		group = a.group
+	}
+	return group, artifact
+}
+
+// subject would return subject of artifact, qualified with its group unless it is group of client
+func (a *ApicurioClient) subject(group, artifact string) string {
+	if len(group) == 0 || group == a.group {
+		return artifact
+	}
+	return ContextSubject(group, artifact)
+}
+
+// content would return content type and body of v2 request with schema,
+// references are sent in extended JSON body only
+func (a *ApicurioClient) content(schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (string, any) {
+	if len(references) == 0 {
+		return apicurioContentType(schema), []byte(schema.Schema)
+	}
+	return apicurioExtendedContent, &apicurioContent{Content: schema.Schema, References: a.references(references)}
+}
+
+// contentV3 would make content of v3 request with schema
+func (a *ApicurioClient) contentV3(schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) *apicurioContent {
+	return &apicurioContent{
+		Content:     schema.Schema,
+		ContentType: apicurioContentType(schema),
+		References:  a.references(references),
+	}
+}
+
+// references would make references to artifacts of subjects
+func (a *ApicurioClient) references(references []v1alpha1.ResolvedReference) []apicurioReference {
+	refs := make([]apicurioReference, 0, len(references))
+	for _, ref := range references {
+		group, artifact := a.locate(ref.Subject)
+		refs = append(refs, apicurioReference{
+			GroupID:    group,
+			ArtifactID: artifact,
+			Version:    strconv.Itoa(ref.Version),
+			Name:       ref.Name,
+		})
+	}
+	return refs
+}
+
+// do would call Apicurio Registry API. Body of request is sent as is if it is []byte and as JSON otherwise,
+// response is read as is into *string and parsed as JSON otherwise. Errors are APIError with Confluent
+// error codes, so callers can check them with ErrSubjectNotFound and ErrVersionNotFound.
+func (a *ApicurioClient) do(ctx context.Context, method, path string, headers map[string]string, in, out any) error {
+	status, data, err := a.send(ctx, method, path, headers, in)
+	if err != nil {
+		return err
+	}
+	if status >= http.StatusBadRequest {
+		return newApicurioAPIError(status, data)
+	}
+	switch out := out.(type) {
+	case nil:
+	case *string:
+		*out = string(data)
+	default:
+		if len(data) == 0 {
+			return nil
+		}
+		err = json.Unmarshal(data, out)
+		if err != nil {
+			return fmt.Errorf("can't parse response of %s %s: %w", method, path, err)
+		}
+	}
+	return nil
+}
+
+// send would make request to Apicurio Registry API, returning status and body of response
+func (a *ApicurioClient) send(ctx context.Context, method, path string, headers map[string]string, in any) (int, []byte, error) {
+	all := map[string]string{"Accept": "application/json", "Content-Type": "application/json"}
+	for name, value := range headers {
+		all[name] = value
+	}
+	var body []byte
+	switch in := in.(type) {
+	case nil:
+	case []byte:
+		body = in
+	default:
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return 0, nil, fmt.Errorf("can't marshal request to %s: %w", path, err)
+		}
+	}
+	return a.client.send(ctx, method,
+		fmt.Sprintf("%s/apis/registry/%s%s", strings.TrimSuffix(a.client.schemaRegURL, "/"), a.apiVersion, path), all, body)
+}
+
+// newApicurioAPIError would make APIError from error of Apicurio Registry
+func newApicurioAPIError(status int, data []byte) *APIError {
+	apiErr := &apicurioError{}
+	if json.Unmarshal(data, apiErr) != nil {
+		return &APIError{StatusCode: status, Code: status, Message: strings.TrimSpace(string(data))}
+	}
+	code := apiErr.ErrorCode
+	switch apiErr.Name {
+	case "ArtifactNotFoundException", "GroupNotFoundException":
+		code = ErrorCodeSubjectNotFound
+	case "VersionNotFoundException":
+		code = ErrorCodeVersionNotFound
+	case apicurioRuleViolation:
+		code = ErrorCodeInvalidSchema
+	}
+	if code == 0 {
+		code = status
+	}
+	return &APIError{StatusCode: status, Code: code, Message: apiErr.message()}
+}
+
+// message would return message of v2 error or detail of v3 problem
+func (e *apicurioError) message() string {
+	if len(e.Message) != 0 {
+		return e.Message
+	}
+	return e.Detail
+}
+
+// artifactID would return ID of artifact listed by v2 or v3 API
+func (v *apicurioVersion) artifactID() string {
+	if len(v.ArtifactID) != 0 {
+		return v.ArtifactID
+	}
+	return v.ID
+}
+
+// apicurioContentType would return content type of schema
+func apicurioContentType(schema *v1alpha1.KafkaSchemaSpec) string {
+	if SchemaType(schema) == SchemaTypeProtobuf {
+		return "application/x-protobuf"
+	}
+	return "application/json"
+}
+
+// parseVersion would parse version of artifact, versions of artifacts managed by operator are numbers
+func parseVersion(subject, version string) (int, error) {
+	number, err := strconv.Atoi(version)
+	if err != nil {
+		return 0, fmt.Errorf("version %s of %s isn't a number: %w", version, subject, err)
+	}
+	return number, nil
+}
@@ -0,0 +1,522 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeApicurioVersion is version of artifact in fakeApicurio
+type fakeApicurioVersion struct {
+	content    string
+	state      string
+	globalID   int
+	contentID  int
+	references []map[string]string
+}
+
+// fakeApicurio is in-memory Apicurio Registry serving API v2 or v3, content is canonicalized by removing whitespaces.
+// Artifacts are kept by subject, artifacts of groups other than default one are qualified with group as context.
+// Compatibility rules reject schemas with "int" type, global rule is kept under "".
+type fakeApicurio struct {
+	mu            sync.Mutex
+	apiVersion    string
+	artifacts     map[string][]*fakeApicurioVersion
+	rules         map[string]string
+	contentIDs    map[string]int
+	globalIDs     int
+	registrations int
+}
+
+func newFakeApicurio(t *testing.T, apiVersion string) (*fakeApicurio, *httptest.Server) {
+	t.Helper()
+	fake := &fakeApicurio{
+		apiVersion: apiVersion,
+		artifacts:  make(map[string][]*fakeApicurioVersion),
+		rules:      make(map[string]string),
+		contentIDs: make(map[string]int),
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeApicurio) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	prefix := "/apis/registry/" + f.apiVersion + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		f.writeError(w, http.StatusNotFound, "NotFoundException", "unknown API")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	data, _ := io.ReadAll(r.Body)
+
+	switch {
+	case parts[0] == "admin":
+		f.serveRule(w, r, "", parts[1:], data)
+	case parts[0] == "search" && parts[1] == "artifacts" && r.Method == http.MethodGet:
+		f.serveArtifacts(w)
+	case parts[0] == "search" && r.Method == http.MethodPost:
+		f.serveSearch(w, fakeSubject(r.URL.Query().Get("groupId"), r.URL.Query().Get("artifactId")), string(data))
+	case parts[0] == "ids" && len(parts) == 4:
+		f.serveReferences(w, parts[2])
+	case parts[0] == "groups" && len(parts) == 3 && r.Method == http.MethodPost:
+		f.serveCreate(w, r, parts[1], data)
+	case parts[0] == "groups" && len(parts) >= 4:
+		f.serveArtifact(w, r, fakeSubject(parts[1], parts[3]), parts[4:], data)
+	default:
+		f.writeError(w, http.StatusNotFound, "NotFoundException", "unknown path")
+	}
+}
+
+func (f *fakeApicurio) serveArtifacts(w http.ResponseWriter) {
+	names := []string{}
+	for name := range f.artifacts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	items := []map[string]any{}
+	for _, name := range names {
+		group, artifact := fakeArtifact(name)
+		if f.apiVersion == schemaregistry.ApicurioAPIv2 {
+			items = append(items, map[string]any{"groupId": group, "id": artifact})
+		} else {
+			items = append(items, map[string]any{"groupId": group, "artifactId": artifact})
+		}
+	}
+	writeJSON(w, map[string]any{"count": len(items), "artifacts": items})
+}
+
+func (f *fakeApicurio) serveCreate(w http.ResponseWriter, r *http.Request, group string, data []byte) {
+	name, content := r.Header.Get("X-Registry-ArtifactId"), string(data)
+	var references []map[string]string
+	if f.apiVersion == schemaregistry.ApicurioAPIv3 {
+		body := struct {
+			ArtifactID   string `json:"artifactId"`
+			FirstVersion struct {
+				Content struct {
+					Content     string              `json:"content"`
+					ContentType string              `json:"contentType"`
+					References  []map[string]string `json:"references"`
+				} `json:"content"`
+			} `json:"firstVersion"`
+		}{}
+		_ = json.Unmarshal(data, &body)
+		name, content, references = body.ArtifactID, body.FirstVersion.Content.Content, body.FirstVersion.Content.References
+	} else if r.Header.Get("Content-Type") == "application/create.extended+json" {
+		content, references = f.extendedContent(data)
+	}
+	name = fakeSubject(group, name)
+	if f.violates(w, name, content) {
+		return
+	}
+	f.registrations++
+	f.globalIDs++
+	canonical := strings.Join(strings.Fields(content), "")
+	if _, ok := f.contentIDs[canonical]; !ok {
+		f.contentIDs[canonical] = len(f.contentIDs) + 1
+	}
+	version := &fakeApicurioVersion{
+		content:    content,
+		state:      "ENABLED",
+		globalID:   f.globalIDs,
+		contentID:  f.contentIDs[canonical],
+		references: references,
+	}
+	f.artifacts[name] = append(f.artifacts[name], version)
+	writeJSON(w, f.meta(name, len(f.artifacts[name])))
+}
+
+// extendedContent would parse v2 request with references
+func (f *fakeApicurio) extendedContent(data []byte) (string, []map[string]string) {
+	body := struct {
+		Content    string              `json:"content"`
+		References []map[string]string `json:"references"`
+	}{}
+	_ = json.Unmarshal(data, &body)
+	return body.Content, body.References
+}
+
+// violates would write rule violation if artifact or global rule rejects content
+func (f *fakeApicurio) violates(w http.ResponseWriter, name, content string) bool {
+	level, ok := f.rules[name]
+	if !ok {
+		level = f.rules[""]
+	}
+	if len(level) == 0 || level == "NONE" || len(f.artifacts[name]) == 0 || !strings.Contains(content, `"int"`) {
+		return false
+	}
+	w.WriteHeader(http.StatusConflict)
+	writeJSON(w, map[string]any{
+		"error_code": http.StatusConflict,
+		"name":       "RuleViolationException",
+		"message":    "Incompatible artifact",
+		"causes":     []map[string]string{{"description": "type of field changed to int", "context": "/"}},
+	})
+	return true
+}
+
+func (f *fakeApicurio) serveArtifact(w http.ResponseWriter, r *http.Request, name string, rest []string, data []byte) {
+	versions, ok := f.artifacts[name]
+	if !ok {
+		f.writeError(w, http.StatusNotFound, "ArtifactNotFoundException", "No artifact with ID '"+name+"' was found.")
+		return
+	}
+	path := strings.Join(rest, "/")
+	switch {
+	case len(rest) >= 1 && rest[0] == "rules":
+		f.serveRule(w, r, name, rest, data)
+	case path == "" && r.Method == http.MethodDelete:
+		delete(f.artifacts, name)
+		delete(f.rules, name)
+		w.WriteHeader(http.StatusNoContent)
+	case path == "" && r.Method == http.MethodGet, path == "versions/branch=latest/content":
+		if latest := f.latest(name); latest != 0 {
+			_, _ = w.Write([]byte(versions[latest-1].content))
+			return
+		}
+		f.writeError(w, http.StatusNotFound, "VersionNotFoundException", "No enabled version")
+	case path == "meta" && r.Method == http.MethodGet, path == "versions/branch=latest":
+		if latest := f.latest(name); latest != 0 {
+			writeJSON(w, f.meta(name, latest))
+			return
+		}
+		f.writeError(w, http.StatusNotFound, "VersionNotFoundException", "No enabled version")
+	case path == "meta" && r.Method == http.MethodPost:
+		content := string(data)
+		if r.Header.Get("Content-Type") == "application/create.extended+json" {
+			content, _ = f.extendedContent(data)
+		}
+		found := f.find(name, content)
+		if len(found) == 0 {
+			f.writeError(w, http.StatusNotFound, "ContentNotFoundException", "No version with content was found.")
+			return
+		}
+		writeJSON(w, found[len(found)-1])
+	case path == "test" && r.Method == http.MethodPut, path == "versions" && r.Method == http.MethodPost:
+		content := string(data)
+		if f.apiVersion == schemaregistry.ApicurioAPIv3 {
+			body := struct {
+				Content struct {
+					Content string `json:"content"`
+				} `json:"content"`
+			}{}
+			_ = json.Unmarshal(data, &body)
+			content = body.Content.Content
+		} else if r.Header.Get("Content-Type") == "application/create.extended+json" {
+			content, _ = f.extendedContent(data)
+		}
+		if !f.violates(w, name, content) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	case path == "versions" && r.Method == http.MethodGet:
+		items := []map[string]any{}
+		for i := range versions {
+			items = append(items, f.meta(name, i+1))
+		}
+		writeJSON(w, map[string]any{"count": len(items), "versions": items})
+	case len(rest) == 3 && rest[0] == "versions" && rest[2] == "state" && r.Method == http.MethodPut:
+		number, err := strconv.Atoi(rest[1])
+		if err != nil || number < 1 || number > len(versions) {
+			f.writeError(w, http.StatusNotFound, "VersionNotFoundException", "No version "+rest[1])
+			return
+		}
+		state := map[string]string{}
+		_ = json.Unmarshal(data, &state)
+		versions[number-1].state = state["state"]
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.writeError(w, http.StatusNotFound, "NotFoundException", "unknown path")
+	}
+}
+
+func (f *fakeApicurio) serveRule(w http.ResponseWriter, r *http.Request, name string, rest []string, data []byte) {
+	level, ok := f.rules[name]
+	rule := map[string]string{}
+	_ = json.Unmarshal(data, &rule)
+	switch {
+	case len(rest) == 2 && r.Method == http.MethodGet && ok:
+		writeJSON(w, map[string]string{"config": level})
+	case len(rest) == 2 && r.Method == http.MethodPut && ok:
+		f.rules[name] = rule["config"]
+		writeJSON(w, rule)
+	case len(rest) == 2 && r.Method == http.MethodDelete && ok:
+		delete(f.rules, name)
+		w.WriteHeader(http.StatusNoContent)
+	case len(rest) == 1 && r.Method == http.MethodPost && !ok:
+		ruleType := rule["type"]
+		if f.apiVersion == schemaregistry.ApicurioAPIv3 {
+			ruleType = rule["ruleType"]
+		}
+		if ruleType != "COMPATIBILITY" {
+			f.writeError(w, http.StatusBadRequest, "BadRequestException", "unknown rule type "+ruleType)
+			return
+		}
+		f.rules[name] = rule["config"]
+		w.WriteHeader(http.StatusNoContent)
+	case len(rest) == 1 && r.Method == http.MethodPost:
+		f.writeError(w, http.StatusConflict, "RuleAlreadyExistsException", "A rule named 'COMPATIBILITY' already exists.")
+	default:
+		f.writeError(w, http.StatusNotFound, "RuleNotFoundException", "No rule named 'COMPATIBILITY' was found.")
+	}
+}
+
+func (f *fakeApicurio) serveSearch(w http.ResponseWriter, name, content string) {
+	found := f.find(name, content)
+	// newest first, as ordered by client
+	for i, j := 0, len(found)-1; i < j; i, j = i+1, j-1 {
+		found[i], found[j] = found[j], found[i]
+	}
+	writeJSON(w, map[string]any{"count": len(found), "versions": found})
+}
+
+func (f *fakeApicurio) serveReferences(w http.ResponseWriter, globalID string) {
+	refs := []map[string]string{}
+	for name, versions := range f.artifacts {
+		for _, version := range versions {
+			if strconv.Itoa(version.globalID) == globalID {
+				refs = append(refs, f.referencing(name, versions, version)...)
+			}
+		}
+	}
+	writeJSON(w, refs)
+}
+
+// referencing would return versions of other artifacts referencing version
+func (f *fakeApicurio) referencing(name string, versions []*fakeApicurioVersion, version *fakeApicurioVersion) []map[string]string {
+	number := 0
+	for i := range versions {
+		if versions[i] == version {
+			number = i + 1
+		}
+	}
+	refs := []map[string]string{}
+	for other, otherVersions := range f.artifacts {
+		for i, otherVersion := range otherVersions {
+			for _, ref := range otherVersion.references {
+				if fakeSubject(ref["groupId"], ref["artifactId"]) == name && ref["version"] == strconv.Itoa(number) {
+					group, artifact := fakeArtifact(other)
+					refs = append(refs, map[string]string{"groupId": group, "artifactId": artifact, "version": strconv.Itoa(i + 1)})
+				}
+			}
+		}
+	}
+	return refs
+}
+
+// find would return metadata of versions of artifact with the same canonical content, oldest first
+func (f *fakeApicurio) find(name, content string) []map[string]any {
+	canonical := strings.Join(strings.Fields(content), "")
+	found := []map[string]any{}
+	for i, version := range f.artifacts[name] {
+		if strings.Join(strings.Fields(version.content), "") == canonical {
+			found = append(found, f.meta(name, i+1))
+		}
+	}
+	return found
+}
+
+// latest would return latest enabled version of artifact, 0 if there is none
+func (f *fakeApicurio) latest(name string) int {
+	versions := f.artifacts[name]
+	for i := len(versions); i > 0; i-- {
+		if versions[i-1].state != "DISABLED" {
+			return i
+		}
+	}
+	return 0
+}
+
+func (f *fakeApicurio) meta(name string, number int) map[string]any {
+	version := f.artifacts[name][number-1]
+	group, artifact := fakeArtifact(name)
+	meta := map[string]any{
+		"groupId":   group,
+		"version":   strconv.Itoa(number),
+		"globalId":  version.globalID,
+		"contentId": version.contentID,
+		"state":     version.state,
+	}
+	if f.apiVersion == schemaregistry.ApicurioAPIv2 {
+		meta["id"] = artifact
+	} else {
+		meta["artifactId"] = artifact
+	}
+	return meta
+}
+
+// fakeSubject would return subject artifact is kept by
+func fakeSubject(group, artifact string) string {
+	if group == "default" {
+		return artifact
+	}
+	return schemaregistry.ContextSubject(group, artifact)
+}
+
+// fakeArtifact would return group and ID of artifact kept by subject
+func fakeArtifact(subject string) (string, string) {
+	group, artifact := schemaregistry.SplitSubject(subject)
+	if len(group) == 0 {
+		group = "default"
+	}
+	return group, artifact
+}
+
+func (f *fakeApicurio) writeError(w http.ResponseWriter, status int, name, message string) {
+	w.WriteHeader(status)
+	if f.apiVersion == schemaregistry.ApicurioAPIv2 {
+		writeJSON(w, map[string]any{"error_code": status, "name": name, "message": message})
+		return
+	}
+	writeJSON(w, map[string]any{"status": status, "name": name, "detail": message})
+}
+
+func TestNewApicurioClient(t *testing.T) {
+	t.Parallel()
+
+	_, err := schemaregistry.NewApicurioClient("v1", "default", schemaregistry.URL("http://localhost"))
+	require.ErrorContains(t, err, "unknown API version v1")
+	_, err = schemaregistry.NewApicurioClient(schemaregistry.ApicurioAPIv3, "", schemaregistry.URL("http://localhost"))
+	require.ErrorContains(t, err, "group must be set")
+}
+
+func TestApicurioClient(t *testing.T) {
+	t.Parallel()
+
+	for _, apiVersion := range []string{schemaregistry.ApicurioAPIv2, schemaregistry.ApicurioAPIv3} {
+		t.Run(apiVersion, func(t *testing.T) {
+			t.Parallel()
+
+			fake, server := newFakeApicurio(t, apiVersion)
+			c, err := schemaregistry.NewApicurioClient(apiVersion, "default", schemaregistry.URL(server.URL))
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			exists, err := c.SchemaExists(ctx, "test-value")
+			require.NoError(t, err)
+			require.False(t, exists)
+
+			schema := &v1alpha1.KafkaSchemaSpec{
+				Name:          "test-value",
+				Schema:        `{"type": "string"}`,
+				Compatibility: "backward",
+			}
+			reg, err := c.CreateSchema(ctx, schema, nil)
+			require.NoError(t, err)
+			require.Equal(t, 1, reg.Version)
+			require.Equal(t, 1, reg.ID)
+			require.Equal(t, "BACKWARD", reg.CompatibilityLevel)
+
+			// formatting of schema doesn't make new version
+			schema.Schema = "{\n  \"type\": \"string\"\n}"
+			reg, err = c.CreateSchema(ctx, schema, nil)
+			require.NoError(t, err)
+			require.Equal(t, 1, reg.Version)
+			require.Equal(t, 1, fake.registrations)
+
+			// rule violations are incompatibilities, not errors
+			compatibility, err := c.CheckCompatibility(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "int"}`}, nil)
+			require.NoError(t, err)
+			require.False(t, compatibility.IsCompatible)
+			require.Equal(t, []string{"type of field changed to int at /"}, compatibility.Messages)
+			compatibility, err = c.CheckCompatibility(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "long"}`}, nil)
+			require.NoError(t, err)
+			require.True(t, compatibility.IsCompatible)
+			compatibility, err = c.CheckCompatibility(ctx, &v1alpha1.KafkaSchemaSpec{Name: "other-value", Schema: `{"type": "int"}`}, nil)
+			require.NoError(t, err)
+			require.True(t, compatibility.IsCompatible)
+
+			for _, next := range []string{`{"type": "long"}`, `{"type": "double"}`} {
+				schema.Schema = next
+				_, err = c.CreateSchema(ctx, schema, nil)
+				require.NoError(t, err)
+			}
+			versions, err := c.Versions(ctx, "test-value")
+			require.NoError(t, err)
+			require.Equal(t, []int{1, 2, 3}, versions)
+			latest, err := c.LatestSchema(ctx, "test-value")
+			require.NoError(t, err)
+			require.JSONEq(t, `{"type": "double"}`, latest)
+
+			_, err = c.CreateSchema(ctx, &v1alpha1.KafkaSchemaSpec{Name: "ref-value", Schema: `{"type": "string"}`},
+				[]v1alpha1.ResolvedReference{{Name: "Test", Subject: "test-value", Version: 2}})
+			require.NoError(t, err)
+			referencedBy, err := c.ReferencedBy(ctx, "test-value")
+			require.NoError(t, err)
+			require.Equal(t, []string{"ref-value:1"}, referencedBy)
+			// context is group of artifact
+			_, err = c.CreateSchema(ctx, &v1alpha1.KafkaSchemaSpec{Name: ":.tenant:test-value", Schema: `{"type": "string"}`}, nil)
+			require.NoError(t, err)
+			versions, err = c.Versions(ctx, ":.tenant:test-value")
+			require.NoError(t, err)
+			require.Equal(t, []int{1}, versions)
+			subjects, err := c.Subjects(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, []string{":.tenant:test-value", "ref-value", "test-value"}, subjects)
+
+			// referenced version 2 is kept
+			pruned, err := c.PruneVersions(ctx, "test-value", 1, 0)
+			require.NoError(t, err)
+			require.Equal(t, []int{1}, pruned)
+			versions, err = c.Versions(ctx, "test-value")
+			require.NoError(t, err)
+			require.Equal(t, []int{2, 3}, versions)
+
+			previous, err := c.ReconcileCompatibility(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", Compatibility: "FULL"})
+			require.NoError(t, err)
+			require.Equal(t, "BACKWARD", previous)
+			previous, err = c.ReconcileCompatibility(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value"})
+			require.NoError(t, err)
+			require.Equal(t, "FULL", previous)
+			level, err := c.CompatibilityLevel(ctx, "test-value")
+			require.NoError(t, err)
+			require.Equal(t, "NONE", level)
+			previous, err = c.ReconcileGlobalCompatibility(ctx, "FORWARD")
+			require.NoError(t, err)
+			require.Empty(t, previous)
+			level, err = c.CompatibilityLevel(ctx, "test-value")
+			require.NoError(t, err)
+			require.Equal(t, "FORWARD", level)
+
+			// registry doesn't have modes and IDs
+			mode, err := c.Mode(ctx, "test-value")
+			require.NoError(t, err)
+			require.Equal(t, schemaregistry.ModeReadWrite, mode)
+			_, err = c.ReconcileMode(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", Mode: schemaregistry.ModeReadOnly})
+			require.ErrorContains(t, err, "doesn't support modes")
+			_, err = c.ImportSchema(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", ID: 10}, nil)
+			require.Error(t, err)
+			_, err = c.CreateSchema(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "string"}`,
+				Metadata: &v1alpha1.SchemaMetadata{Properties: map[string]string{"owner": "payments"}}}, nil)
+			require.ErrorContains(t, err, "aren't supported")
+
+			// soft deleted artifact is registered again as new version
+			require.NoError(t, c.DeleteSubject(ctx, "test-value", false))
+			exists, err = c.SchemaExists(ctx, "test-value")
+			require.NoError(t, err)
+			require.False(t, exists)
+			reg, err = c.CreateSchema(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "double"}`}, nil)
+			require.NoError(t, err)
+			require.Equal(t, 4, reg.Version)
+
+			require.NoError(t, c.DeleteSubject(ctx, "test-value", true))
+			subjects, err = c.Subjects(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, []string{":.tenant:test-value", "ref-value"}, subjects)
+			require.NoError(t, c.DeleteSubject(ctx, "test-value", true))
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package schemaregistry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+)
+
+// ConfigOptions would return options of Client with URL, credentials and TLS from config
+func ConfigOptions(config *env.Config) []Option {
+	options := []Option{URL(config.SchemaRegistryURL)}
+	if len(config.SchemaRegistryUsername) != 0 || len(config.SchemaRegistryPassword) != 0 {
+		options = append(options, BasicAuth(config.SchemaRegistryUsername, config.SchemaRegistryPassword))
+	}
+	if len(config.SchemaRegistryTokenFile) != 0 {
+		options = append(options, BearerTokenFile(config.SchemaRegistryTokenFile))
+	}
+	if len(config.SchemaRegistryCAFile) != 0 {
+		options = append(options, CAFile(config.SchemaRegistryCAFile))
+	}
+	if len(config.SchemaRegistryCertFile) != 0 || len(config.SchemaRegistryKeyFile) != 0 {
+		options = append(options, ClientCertificate(config.SchemaRegistryCertFile, config.SchemaRegistryKeyFile))
+	}
+	return options
+}
+
+// BasicAuth is option function to authenticate to Schema Registry with username and password,
+// i.e. API key and secret of Confluent Cloud
+func BasicAuth(username, password string) Option {
+	return func(m *Client) error {
+		if len(username) == 0 || len(password) == 0 {
+			return fmt.Errorf("both username and password must be set for basic auth")
+		}
+		m.username = username
+		m.password = password
+		return nil
+	}
+}
+
+// BearerTokenFile is option function to authenticate to Schema Registry with bearer token read from file.
+// File is read on every request, so token rotated by other process is picked up.
+func BearerTokenFile(path string) Option {
+	return func(m *Client) error {
+		_, err := readToken(path)
+		if err != nil {
+			return err
+		}
+		m.tokenFile = path
+		return nil
+	}
+}
+
+// BearerToken is option function to authenticate to Schema Registry with bearer token, i.e. read from Secret
+func BearerToken(token string) Option {
+	return func(m *Client) error {
+		token = strings.TrimSpace(token)
+		if len(token) == 0 {
+			return fmt.Errorf("bearer token is empty")
+		}
+		m.token = token
+		return nil
+	}
+}
+
+// CAFile is option function to trust CA certificates from PEM file in addition to system ones
+func CAFile(path string) Option {
+	return func(m *Client) error {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("can't read CA file %s: %w", path, err)
+		}
+		err = CA(pem)(m)
+		if err != nil {
+			return fmt.Errorf("CA file %s: %w", path, err)
+		}
+		return nil
+	}
+}
+
+// CA is option function to trust PEM CA certificates in addition to system ones, i.e. read from Secret
+func CA(pem []byte) Option {
+	return func(m *Client) error {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates of CA")
+		}
+		m.tlsConfig().RootCAs = pool
+		return nil
+	}
+}
+
+// ClientCertificate is option function to authenticate to Schema Registry with TLS client certificate
+func ClientCertificate(certFile, keyFile string) Option {
+	return func(m *Client) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("can't load client certificate %s: %w", certFile, err)
+		}
+		m.tlsConfig().Certificates = []tls.Certificate{cert}
+		return nil
+	}
+}
+
+// ClientKeyPair is option function to authenticate to Schema Registry with PEM TLS client certificate and key, i.e. read from Secret
+func ClientKeyPair(certPEM, keyPEM []byte) Option {
+	return func(m *Client) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("can't load client certificate: %w", err)
+		}
+		m.tlsConfig().Certificates = []tls.Certificate{cert}
+		return nil
+	}
+}
+
+// tlsConfig would return TLS config of client, creating it if needed
+func (c *Client) tlsConfig() *tls.Config {
+	if c.tls == nil {
+		c.tls = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return c.tls
+}
+
+// authTransport would add credentials to every request made to Schema Registry
+type authTransport struct {
+	base      http.RoundTripper
+	username  string
+	password  string
+	tokenFile string
+	token     string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case len(t.username) != 0:
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(t.username, t.password)
+	case len(t.tokenFile) != 0:
+		token, err := readToken(t.tokenFile)
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+	case len(t.token) != 0:
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// readToken would read bearer token from file
+func readToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("can't read bearer token file %s: %w", path, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if len(token) == 0 {
+		return "", fmt.Errorf("bearer token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// newHTTPClient would return copy of base client with TLS config and credentials of Client
+func (c *Client) newHTTPClient(base *http.Client) (*http.Client, error) {
+	httpClient := *base
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if c.tls != nil {
+		defaultTransport, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("TLS options can't be used with custom transport of HTTP client")
+		}
+		defaultTransport = defaultTransport.Clone()
+		defaultTransport.TLSClientConfig = c.tls
+		transport = defaultTransport
+	}
+	if len(c.username) != 0 || len(c.tokenFile) != 0 || len(c.token) != 0 {
+		transport = &authTransport{
+			base:      transport,
+			username:  c.username,
+			password:  c.password,
+			tokenFile: c.tokenFile,
+			token:     c.token,
+		}
+	}
+	httpClient.Transport = transport
+	return &httpClient, nil
+}
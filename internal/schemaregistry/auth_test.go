@@ -0,0 +1,149 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+// authRegistry would accept only requests with Authorization header
+func authRegistry(t *testing.T, authorization string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", schemaregistry.ContentType)
+		if r.Header.Get("Authorization") != authorization {
+			writeError(w, http.StatusUnauthorized, 40101, "Unauthorized")
+			return
+		}
+		if r.URL.Path == "/subjects" {
+			writeJSON(w, []string{"test-value"})
+			return
+		}
+		writeJSON(w, []int{1})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_BasicAuth(t *testing.T) {
+	t.Parallel()
+
+	// base64 of user:secret
+	server := authRegistry(t, "Basic dXNlcjpzZWNyZXQ=")
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BasicAuth("user", "secret"))
+	require.NoError(t, err)
+
+	// all API calls are authenticated
+	subjects, err := c.Subjects(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"test-value"}, subjects)
+	versions, err := c.Versions(context.Background(), "test-value")
+	require.NoError(t, err)
+	require.Equal(t, []int{1}, versions)
+
+	c, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BasicAuth("user", "wrong"))
+	require.NoError(t, err)
+	_, err = c.Versions(context.Background(), "test-value")
+	require.Error(t, err)
+
+	_, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BasicAuth("user", ""))
+	require.Error(t, err)
+}
+
+func TestClient_BearerTokenFile(t *testing.T) {
+	t.Parallel()
+
+	server := authRegistry(t, "Bearer second")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("first\n"), 0o600))
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BearerTokenFile(tokenFile))
+	require.NoError(t, err)
+	_, err = c.Subjects(context.Background())
+	require.Error(t, err)
+
+	// rotated token is read on next request
+	require.NoError(t, os.WriteFile(tokenFile, []byte("second\n"), 0o600))
+	_, err = c.Subjects(context.Background())
+	require.NoError(t, err)
+	_, err = c.Versions(context.Background(), "test-value")
+	require.NoError(t, err)
+
+	_, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BearerTokenFile(filepath.Join(t.TempDir(), "missing")))
+	require.Error(t, err)
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	t.Parallel()
+
+	server := authRegistry(t, "Bearer token")
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BearerToken("token\n"))
+	require.NoError(t, err)
+	_, err = c.Subjects(context.Background())
+	require.NoError(t, err)
+
+	_, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BearerToken(" "))
+	require.Error(t, err)
+	_, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.BasicAuth("user", "secret"), schemaregistry.BearerToken("token"))
+	require.Error(t, err)
+}
+
+func TestClient_TLS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", schemaregistry.ContentType)
+		writeJSON(w, []string{"test-value"})
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	// certificate of test server is used both as CA and as client certificate
+	cert := server.TLS.Certificates[0]
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]})
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key})
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	// unknown CA
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	_, err = c.Subjects(context.Background())
+	require.Error(t, err)
+
+	// no client certificate
+	c, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.CAFile(certFile))
+	require.NoError(t, err)
+	_, err = c.Subjects(context.Background())
+	require.Error(t, err)
+
+	c, err = schemaregistry.NewClient(schemaregistry.URL(server.URL),
+		schemaregistry.CAFile(certFile),
+		schemaregistry.ClientCertificate(certFile, keyFile))
+	require.NoError(t, err)
+	subjects, err := c.Subjects(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"test-value"}, subjects)
+
+	// certificate and key read from Secret
+	c, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.CA(certPEM), schemaregistry.ClientKeyPair(certPEM, keyPEM))
+	require.NoError(t, err)
+	_, err = c.Subjects(context.Background())
+	require.NoError(t, err)
+	_, err = schemaregistry.NewClient(schemaregistry.URL(server.URL), schemaregistry.ClientKeyPair(certPEM, nil))
+	require.Error(t, err)
+}
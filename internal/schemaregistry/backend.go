@@ -0,0 +1,60 @@
+package schemaregistry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+)
+
+// Backends of schema registry
+const (
+	BackendConfluent = "confluent"
+	BackendApicurio  = "apicurio"
+)
+
+// Backend is schema registry KafkaSchema objects are reconciled with. Client implements it with
+// Confluent Schema Registry API and ApicurioClient with native API of Apicurio Registry,
+// errors of both can be checked with ErrSubjectNotFound, ErrVersionNotFound and ErrSchemaNotFound.
+type Backend interface {
+	CreateSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error)
+	ImportSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error)
+	LookupSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error)
+	CheckCompatibility(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Compatibility, error)
+	SchemaExists(ctx context.Context, subject string) (bool, error)
+	LatestSchema(ctx context.Context, subject string) (string, error)
+	LatestVersion(ctx context.Context, subject string) (int, error)
+	Subjects(ctx context.Context) ([]string, error)
+	Versions(ctx context.Context, subject string) ([]int, error)
+	ReferencedBy(ctx context.Context, subject string) ([]string, error)
+	DeleteSubject(ctx context.Context, subject string, permanent bool) error
+	PruneVersions(ctx context.Context, subject string, retention, keep int) ([]int, error)
+	CompatibilityLevel(ctx context.Context, subject string) (string, error)
+	ReconcileCompatibility(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec) (string, error)
+	ReconcileGlobalCompatibility(ctx context.Context, level string) (string, error)
+	Mode(ctx context.Context, subject string) (string, error)
+	ReconcileMode(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec) (string, error)
+	ReconcileGlobalMode(ctx context.Context, mode string) (string, error)
+	// Close would close idle connections of client, it can be used again after it
+	Close()
+}
+
+var (
+	_ Backend = &Client{}
+	_ Backend = &ApicurioClient{}
+)
+
+// NewBackend would make client of schema registry backend selected by config,
+// options are applied after ones made from config
+func NewBackend(config *env.Config, options ...Option) (Backend, error) {
+	options = append(ConfigOptions(config), options...)
+	switch config.SchemaRegistryBackend {
+	case "", BackendConfluent:
+		return NewClient(options...)
+	case BackendApicurio:
+		return NewApicurioClient(config.ApicurioAPIVersion, config.ApicurioGroup, options...)
+	}
+	return nil, fmt.Errorf("unknown schema registry backend %s, must be %s or %s",
+		config.SchemaRegistryBackend, BackendConfluent, BackendApicurio)
+}
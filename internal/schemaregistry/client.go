@@ -1,13 +1,18 @@
 package schemaregistry
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
-	"github.com/riferrei/srclient"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
 )
 
 const (
@@ -25,8 +30,15 @@ const (
 )
 
 type Client struct {
-	c            *srclient.SchemaRegistryClient
 	schemaRegURL string
+	httpClient   *http.Client
+	auditor      *audit.Auditor
+	// credentials and TLS config are applied to httpClient all API calls are made with
+	username  string
+	password  string
+	tokenFile string
+	token     string
+	tls       *tls.Config
 }
 
 // Option is a type of options for Client
@@ -35,12 +47,23 @@ type Option func(*Client) error
 // SchemeRegistryURL allows the Schema Registry URL to be set
 func URL(url string) Option {
 	return func(m *Client) error {
-
-		// schemaregistry.NewClient usefully does a lot of
-		// validation on the url - probably worth doing here
-		m.c = srclient.CreateSchemaRegistryClient(url)
 		m.schemaRegURL = url
+		return nil
+	}
+}
 
+// Auditor is option function to set Auditor recording all changes made to Schema Registry
+func Auditor(auditor *audit.Auditor) Option {
+	return func(m *Client) error {
+		m.auditor = auditor
+		return nil
+	}
+}
+
+// HTTPClient is option function to set HTTP client used for API calls
+func HTTPClient(httpClient *http.Client) Option {
+	return func(m *Client) error {
+		m.httpClient = httpClient
 		return nil
 	}
 }
@@ -55,49 +78,173 @@ func NewClient(options ...Option) (*Client, error) {
 			return nil, fmt.Errorf("error creating new schema registry client: %w", err)
 		}
 	}
+	if len(client.username) != 0 && (len(client.tokenFile) != 0 || len(client.token) != 0) {
+		return nil, fmt.Errorf("error creating new schema registry client: basic auth and bearer token can't be used together")
+	}
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{Timeout: CacheConnTimeout}
+	}
+	var err error
+	client.httpClient, err = client.newHTTPClient(client.httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new schema registry client: %w", err)
+	}
 
 	return client, nil
 }
 
-// CreateSchema creates or updates a schema or returns an error
-func (c *Client) CreateSchema(schema *v1alpha1.KafkaSchemaSpec) error {
-	// Create Schema itself
-	_, err := c.c.CreateSchema(schema.Name, schema.Schema, srclient.Avro)
+// CreateSchema creates or updates a schema and returns its registration or an error.
+// Schema which is already registered under subject isn't registered again.
+func (c *Client) CreateSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error) {
+	return c.createSchema(ctx, schema, references, false)
+}
+
+// ImportSchema is CreateSchema registering schema with ID and version pinned by spec,
+// Schema Registry accepts them only if subject is in IMPORT mode
+func (c *Client) ImportSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error) {
+	return c.createSchema(ctx, schema, references, true)
+}
+
+func (c *Client) createSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference, pinned bool) (*Registration, error) {
+	_, err := parseSchemaType(schema)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	// Amend default compatibility mode
-	if schema.Compatibility == KafkaSchemaRegistryCompatibilityBackward {
-		// default compatibility nothing to do
-		return nil
+	reg, err := c.LookupSchema(ctx, schema, references)
+	if err != nil && !errors.Is(err, ErrSubjectNotFound) && !errors.Is(err, ErrSchemaNotFound) {
+		return nil, err
 	}
-	// curl -X PUT -H "Content-Type: application/vnd.schemaregistry.v1+json" --data '{"compatibility": "FULL"}' http://localhost:8081/config/my-kafka-value
-	// https://docs.confluent.io/platform/current/schema-registry/develop/using.html#update-compatibility-requirements-on-a-subject
-	data := []byte(fmt.Sprintf(`{"compatibility": "%s"}`, schema.Compatibility))
-	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/config/%s", c.schemaRegURL, schema.Name), bytes.NewBuffer(data))
 	if err != nil {
-		return fmt.Errorf("failed to register request for compatibility %s for %s: %w", schema.Compatibility, schema.Name, err)
+		return c.registerSchema(ctx, schema, references, pinned)
 	}
-	// Set headers
-	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	return reg, nil
+}
 
-	// Set client timeout
-	client := &http.Client{Timeout: CacheConnTimeout}
-	// Send request
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to register compatibility %s for %s: %w", schema.Compatibility, schema.Name, err)
+// registerSchema would register new version of schema, auditing it.
+// ID and version of spec are sent only if pinned is true.
+func (c *Client) registerSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference, pinned bool) (*Registration, error) {
+	// Previous version is needed only for audit
+	old := map[string]string{}
+	latest, err := c.latest(ctx, schema.Name)
+	if err != nil && !errors.Is(err, ErrSubjectNotFound) {
+		return nil, err
+	}
+	if err == nil {
+		old["schema"] = latest.Schema
+		old["version"] = strconv.Itoa(latest.Version)
+		if len(latest.References) != 0 {
+			old["references"] = FormatReferences(latest.References)
+		}
+		if metadata := FormatMetadata(latest.Metadata); len(metadata) != 0 {
+			old["metadata"] = metadata
+		}
+		if ruleSet := FormatRuleSet(latest.RuleSet); len(ruleSet) != 0 {
+			old["ruleSet"] = ruleSet
+		}
+	}
+	req := newSchemaRequest(schema, references)
+	if pinned {
+		req.ID = schema.ID
+		req.Version = schema.Version
+	}
+	_, err = c.register(ctx, schema.Name, req)
+	var reg *Registration
+	if err == nil {
+		reg, err = c.LookupSchema(ctx, schema, references)
+	}
+	action := audit.ActionCreate
+	if len(old) != 0 {
+		action = audit.ActionAlter
+	}
+	new := map[string]string{
+		"schema":     schema.Schema,
+		"schemaType": SchemaType(schema),
 	}
-	defer resp.Body.Close()
+	if err == nil {
+		new["version"] = strconv.Itoa(reg.Version)
+	}
+	if pinned {
+		new["id"] = strconv.Itoa(schema.ID)
+	}
+	if len(references) != 0 {
+		new["references"] = FormatReferences(references)
+	}
+	if metadata := FormatMetadata(schema.Metadata); len(metadata) != 0 {
+		new["metadata"] = metadata
+	}
+	if ruleSet := FormatRuleSet(schema.RuleSet); len(ruleSet) != 0 {
+		new["ruleSet"] = ruleSet
+	}
+	c.audit(ctx, action, schema.Name, old, new, err)
+	return reg, err
+}
 
-	return err
+// audit would record mutating call to Schema Registry
+func (c *Client) audit(ctx context.Context, action, subject string, old, new map[string]string, err error) {
+	rec := &audit.Record{
+		System:   audit.SystemSchemaRegistry,
+		Action:   action,
+		Resource: subject,
+		Old:      old,
+		New:      new,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	c.auditor.Record(ctx, rec)
 }
 
 // SchemaExists will check if a schema exists or return an error
-func (c *Client) SchemaExists(schemaName string) (bool, error) {
-	_, err := c.c.GetLatestSchema(schemaName)
-	if err != nil {
+func (c *Client) SchemaExists(ctx context.Context, subject string) (bool, error) {
+	_, err := c.latest(ctx, subject)
+	if errors.Is(err, ErrSubjectNotFound) {
 		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
 	return true, nil
 }
+
+// LatestSchema would return latest registered version of schema
+func (c *Client) LatestSchema(ctx context.Context, subject string) (string, error) {
+	latest, err := c.latest(ctx, subject)
+	if err != nil {
+		return "", err
+	}
+	return latest.Schema, nil
+}
+
+// LatestVersion would return latest registered version of subject
+func (c *Client) LatestVersion(ctx context.Context, subject string) (int, error) {
+	latest, err := c.latest(ctx, subject)
+	if err != nil {
+		return 0, err
+	}
+	return latest.Version, nil
+}
+
+// Subjects would return all subjects registered in Schema Registry,
+// subjects of contexts other than default one are qualified with their context
+func (c *Client) Subjects(ctx context.Context) ([]string, error) {
+	subjects := []string{}
+	err := c.do(ctx, http.MethodGet, "/subjects?subjectPrefix="+url.QueryEscape(allContexts), nil, &subjects)
+	if err != nil {
+		return nil, fmt.Errorf("can't get subjects: %w", err)
+	}
+	return subjects, nil
+}
+
+// Close would close idle connections of Schema Registry client
+func (c *Client) Close() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// FormatReferences would format references as one line, i.e. for notifications
+func FormatReferences(references []v1alpha1.ResolvedReference) string {
+	refs := make([]string, 0, len(references))
+	for _, ref := range references {
+		refs = append(refs, fmt.Sprintf("%s=%s:%d", ref.Name, ref.Subject, ref.Version))
+	}
+	return strings.Join(refs, ", ")
+}
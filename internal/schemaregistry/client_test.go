@@ -0,0 +1,639 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry is in-memory Schema Registry, schemas are normalized by removing whitespaces.
+// Soft deleted versions are kept as empty schemas, referencedBy is keyed by subject or subject:version.
+// ID of version is its number, unless it was imported with pinned ID kept in ids by subject:version.
+// Metadata and rule set of versions are kept in contracts by subject:version.
+type fakeRegistry struct {
+	mu            sync.Mutex
+	subjects      map[string][]string
+	compatibility map[string]string
+	modes         map[string]string
+	softDeleted   map[string]bool
+	referencedBy  map[string][]string
+	ids           map[string]int
+	contracts     map[string]map[string]any
+	registrations int
+	failures      int
+}
+
+func newFakeRegistry(t *testing.T) (*fakeRegistry, *httptest.Server) {
+	t.Helper()
+	fake := &fakeRegistry{
+		subjects:      make(map[string][]string),
+		compatibility: make(map[string]string),
+		modes:         make(map[string]string),
+		softDeleted:   make(map[string]bool),
+		referencedBy:  make(map[string][]string),
+		ids:           make(map[string]int),
+		contracts:     make(map[string]map[string]any),
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", schemaregistry.ContentType)
+	if f.failures > 0 {
+		f.failures--
+		writeError(w, http.StatusInternalServerError, 50001, "Error in the backend data store")
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// requests in context are served as in default one, subjects in referencedBy are qualified already
+	if parts[0] == "contexts" && len(parts) > 2 {
+		parts = parts[2:]
+	}
+	body := map[string]any{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	schema := strings.Join(strings.Fields(fmt.Sprint(body["schema"])), "")
+	contract := map[string]any{}
+	for _, key := range []string{"metadata", "ruleSet"} {
+		if value, ok := body[key]; ok {
+			contract[key] = value
+		}
+	}
+	// global config is kept under empty subject
+	if len(parts) == 1 {
+		parts = append(parts, "")
+	}
+	if parts[0] == "subjects" && f.softDeleted[parts[1]] && r.Method != http.MethodDelete {
+		writeError(w, http.StatusNotFound, 40401, "Subject not found.")
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && parts[0] == "subjects" && len(parts[1]) == 0:
+		subjects := []string{}
+		for subject := range f.subjects {
+			if !f.softDeleted[subject] {
+				subjects = append(subjects, subject)
+			}
+		}
+		sort.Strings(subjects)
+		writeJSON(w, subjects)
+	case r.Method == http.MethodGet && parts[0] == "mode":
+		mode, ok := f.modes[parts[1]]
+		switch {
+		case !ok && len(parts[1]) != 0:
+			writeError(w, http.StatusNotFound, 40409, "Subject does not have subject-level mode configured")
+			return
+		case !ok:
+			mode = "READWRITE"
+		}
+		writeJSON(w, map[string]any{"mode": mode})
+	case r.Method == http.MethodPut && parts[0] == "mode":
+		f.modes[parts[1]] = fmt.Sprint(body["mode"])
+		writeJSON(w, body)
+	case r.Method == http.MethodDelete && parts[0] == "mode":
+		mode, ok := f.modes[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, 40401, "Subject not found.")
+			return
+		}
+		delete(f.modes, parts[1])
+		writeJSON(w, map[string]any{"mode": mode})
+	case r.Method == http.MethodGet && parts[0] == "config":
+		level, ok := f.compatibility[parts[1]]
+		if !ok && len(parts[1]) != 0 && r.URL.Query().Get("defaultToGlobal") != "true" {
+			writeError(w, http.StatusNotFound, 40408, "Subject does not have subject-level compatibility configured")
+			return
+		}
+		if !ok {
+			level = "BACKWARD"
+			if global, ok := f.compatibility[""]; ok {
+				level = global
+			}
+		}
+		writeJSON(w, map[string]any{"compatibilityLevel": level})
+	case r.Method == http.MethodPut && parts[0] == "config":
+		f.compatibility[parts[1]] = fmt.Sprint(body["compatibility"])
+		writeJSON(w, body)
+	case r.Method == http.MethodDelete && parts[0] == "config":
+		level, ok := f.compatibility[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, 40401, "Subject not found.")
+			return
+		}
+		delete(f.compatibility, parts[1])
+		writeJSON(w, map[string]any{"compatibilityLevel": level})
+	case r.Method == http.MethodPost && len(parts) == 2:
+		// lookup
+		versions, ok := f.subjects[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, 40401, "Subject not found.")
+			return
+		}
+		for i, registered := range versions {
+			key := fmt.Sprintf("%s:%d", parts[1], i+1)
+			if registered == schema && fmt.Sprint(f.contracts[key]) == fmt.Sprint(contract) {
+				writeJSON(w, f.version(parts[1], i+1, map[string]any{"subject": parts[1], "id": f.id(parts[1], i+1), "version": i + 1, "schema": registered}))
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, 40403, "Schema not found.")
+	case r.Method == http.MethodPost && len(parts) == 3:
+		// registration, ID and version can be pinned only in IMPORT mode
+		id, pinned := body["id"].(float64)
+		if pinned {
+			mode, ok := f.modes[parts[1]]
+			if !ok {
+				mode = f.modes[""]
+			}
+			if mode != "IMPORT" {
+				writeError(w, http.StatusUnprocessableEntity, 42205, "Subject "+parts[1]+" is not in import mode")
+				return
+			}
+		}
+		if version, ok := body["version"].(float64); ok {
+			for len(f.subjects[parts[1]]) < int(version)-1 {
+				f.subjects[parts[1]] = append(f.subjects[parts[1]], "")
+			}
+		}
+		f.registrations++
+		f.subjects[parts[1]] = append(f.subjects[parts[1]], schema)
+		if len(contract) != 0 {
+			f.contracts[fmt.Sprintf("%s:%d", parts[1], len(f.subjects[parts[1]]))] = contract
+		}
+		if pinned {
+			f.ids[fmt.Sprintf("%s:%d", parts[1], len(f.subjects[parts[1]]))] = int(id)
+		}
+		writeJSON(w, map[string]any{"id": f.id(parts[1], len(f.subjects[parts[1]]))})
+	case r.Method == http.MethodGet && len(parts) == 3:
+		versions, ok := f.subjects[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, 40401, "Subject not found.")
+			return
+		}
+		result := []int{}
+		for i, registered := range versions {
+			if len(registered) != 0 {
+				result = append(result, i+1)
+			}
+		}
+		writeJSON(w, result)
+	case r.Method == http.MethodGet && len(parts) == 5 && parts[4] == "referencedby":
+		// ID of referencing schema is its index in referencedBy of subject
+		ids := []int{}
+		for i := range append(f.referencedBy[parts[1]], f.referencedBy[parts[1]+":"+parts[3]]...) {
+			ids = append(ids, i+1)
+		}
+		writeJSON(w, ids)
+	case r.Method == http.MethodDelete && len(parts) == 4:
+		version, _ := strconv.Atoi(parts[3])
+		versions := f.subjects[parts[1]]
+		if version < 1 || version > len(versions) || len(versions[version-1]) == 0 {
+			writeError(w, http.StatusNotFound, 40402, "Version not found.")
+			return
+		}
+		versions[version-1] = ""
+		writeJSON(w, version)
+	case r.Method == http.MethodGet && parts[0] == "schemas":
+		id, _ := strconv.Atoi(parts[2])
+		users := []map[string]any{}
+		for _, referencing := range f.referencedBy {
+			if id <= len(referencing) {
+				users = append(users, map[string]any{"subject": referencing[id-1], "version": 1})
+			}
+		}
+		writeJSON(w, users)
+	case r.Method == http.MethodDelete && parts[0] == "subjects":
+		versions, ok := f.subjects[parts[1]]
+		permanent := r.URL.Query().Get("permanent") == "true"
+		switch {
+		case !ok:
+			writeError(w, http.StatusNotFound, 40401, "Subject not found.")
+			return
+		case !permanent && f.softDeleted[parts[1]]:
+			writeError(w, http.StatusNotFound, 40404, "Subject was soft deleted.")
+			return
+		case permanent && !f.softDeleted[parts[1]]:
+			writeError(w, http.StatusNotFound, 40405, "Subject was not deleted first before being permanently deleted.")
+			return
+		case permanent:
+			delete(f.subjects, parts[1])
+			delete(f.softDeleted, parts[1])
+		default:
+			f.softDeleted[parts[1]] = true
+		}
+		result := []int{}
+		for i := range versions {
+			result = append(result, i+1)
+		}
+		writeJSON(w, result)
+	case r.Method == http.MethodGet && len(parts) == 4:
+		versions, ok := f.subjects[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, 40401, "Subject not found.")
+			return
+		}
+		version := len(versions)
+		if parts[3] != "latest" {
+			version, _ = strconv.Atoi(parts[3])
+		}
+		if version < 1 || version > len(versions) || len(versions[version-1]) == 0 {
+			writeError(w, http.StatusNotFound, 40402, "Version not found.")
+			return
+		}
+		writeJSON(w, f.version(parts[1], version, map[string]any{"subject": parts[1], "id": f.id(parts[1], version), "version": version,
+			"schema": versions[version-1]}))
+	default:
+		writeError(w, http.StatusNotFound, 404, "Not found.")
+	}
+}
+
+// id would return ID of version of subject
+func (f *fakeRegistry) id(subject string, version int) int {
+	if id, ok := f.ids[fmt.Sprintf("%s:%d", subject, version)]; ok {
+		return id
+	}
+	return version
+}
+
+// version would add metadata and rule set of version of subject to its response
+func (f *fakeRegistry) version(subject string, version int, resp map[string]any) map[string]any {
+	for key, value := range f.contracts[fmt.Sprintf("%s:%d", subject, version)] {
+		resp[key] = value
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	w.WriteHeader(status)
+	writeJSON(w, map[string]any{"error_code": code, "message": message})
+}
+
+func TestClient_CreateSchema(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	exists, err := c.SchemaExists(ctx, "test-value")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	schema := &v1alpha1.KafkaSchemaSpec{
+		Name:   "test-value",
+		Schema: `{"type": "string"}`,
+	}
+	reg, err := c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, reg.Version)
+	require.Equal(t, "BACKWARD", reg.CompatibilityLevel)
+
+	// formatting of schema doesn't make new version
+	schema.Schema = "{\n  \"type\": \"string\"\n}"
+	reg, err = c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, reg.Version)
+	require.Equal(t, 1, fake.registrations)
+
+	schema.Schema = `{"type": "long"}`
+	reg, err = c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, reg.Version)
+	require.Equal(t, 2, fake.registrations)
+
+	exists, err = c.SchemaExists(ctx, "test-value")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// registry errors are not "not found"
+	fake.mu.Lock()
+	fake.failures = 1
+	fake.mu.Unlock()
+	exists, err = c.SchemaExists(ctx, "test-value")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, schemaregistry.ErrSubjectNotFound)
+	require.False(t, exists)
+}
+
+func TestClient_ImportSchema(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	schema := &v1alpha1.KafkaSchemaSpec{
+		Name:    "test-value",
+		Schema:  `{"type": "string"}`,
+		ID:      100,
+		Version: 3,
+		Mode:    schemaregistry.ModeImport,
+	}
+
+	// pinned ID is rejected unless subject is in IMPORT mode
+	_, err = c.ImportSchema(ctx, schema, nil)
+	require.ErrorContains(t, err, "is not in import mode")
+	require.Zero(t, fake.registrations)
+
+	_, err = c.ReconcileMode(ctx, schema)
+	require.NoError(t, err)
+	reg, err := c.ImportSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 100, reg.ID)
+	require.Equal(t, 3, reg.Version)
+
+	// imported schema isn't registered again
+	reg, err = c.ImportSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 100, reg.ID)
+	require.Equal(t, 1, fake.registrations)
+}
+
+func TestClient_Contract(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	schema := &v1alpha1.KafkaSchemaSpec{
+		Name:     "test-value",
+		Schema:   `{"type": "string"}`,
+		Metadata: &v1alpha1.SchemaMetadata{Properties: map[string]string{"owner": "payments"}},
+	}
+	reg, err := c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, reg.Version)
+
+	// the same metadata and rule set aren't registered again
+	reg, err = c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, reg.Version)
+	require.Equal(t, 1, fake.registrations)
+
+	// changed metadata or rule set make new version of the same schema
+	schema.Metadata.Properties["owner"] = "billing"
+	_, err = c.LookupSchema(ctx, schema, nil)
+	require.ErrorIs(t, err, schemaregistry.ErrSchemaNotFound)
+	reg, err = c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, reg.Version)
+
+	schema.RuleSet = &v1alpha1.SchemaRuleSet{DomainRules: []v1alpha1.SchemaRule{{
+		Name: "checkAmount",
+		Kind: "CONDITION",
+		Mode: "WRITE",
+		Type: "CEL",
+		Expr: "message.amount > 0",
+	}}}
+	reg, err = c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, reg.Version)
+	require.Equal(t, 3, fake.registrations)
+
+	// registry ignoring metadata on lookup returns version with other metadata, which isn't the same schema
+	fake.mu.Lock()
+	fake.contracts["test-value:3"]["metadata"] = map[string]any{"properties": map[string]any{"owner": "payments"}}
+	fake.mu.Unlock()
+	_, err = c.LookupSchema(ctx, schema, nil)
+	require.ErrorIs(t, err, schemaregistry.ErrSchemaNotFound)
+}
+
+func TestClient_ReconcileCompatibility(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	schema := &v1alpha1.KafkaSchemaSpec{Name: "test-value", Compatibility: "full"}
+
+	previous, err := c.ReconcileCompatibility(ctx, schema)
+	require.NoError(t, err)
+	require.Empty(t, previous)
+	require.Equal(t, "FULL", fake.compatibility["test-value"])
+
+	// nothing to change
+	previous, err = c.ReconcileCompatibility(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, "FULL", previous)
+
+	// drifted level is restored
+	fake.mu.Lock()
+	fake.compatibility["test-value"] = "NONE"
+	fake.mu.Unlock()
+	previous, err = c.ReconcileCompatibility(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, "NONE", previous)
+	require.Equal(t, "FULL", fake.compatibility["test-value"])
+
+	// removed level is reset to global one
+	schema.Compatibility = ""
+	previous, err = c.ReconcileCompatibility(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, "FULL", previous)
+	require.NotContains(t, fake.compatibility, "test-value")
+	level, err := c.CompatibilityLevel(ctx, "test-value")
+	require.NoError(t, err)
+	require.Equal(t, "BACKWARD", level)
+}
+
+func TestClient_ReconcileMode(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	schema := &v1alpha1.KafkaSchemaSpec{Name: "test-value", Mode: schemaregistry.ModeReadOnly}
+
+	mode, err := c.Mode(ctx, "test-value")
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeReadWrite, mode)
+
+	previous, err := c.ReconcileMode(ctx, schema)
+	require.NoError(t, err)
+	require.Empty(t, previous)
+	mode, err = c.Mode(ctx, "test-value")
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeReadOnly, mode)
+	require.False(t, schemaregistry.Writable(mode))
+
+	// nothing to change
+	previous, err = c.ReconcileMode(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeReadOnly, previous)
+
+	// removed mode is reset to global one
+	schema.Mode = ""
+	previous, err = c.ReconcileMode(ctx, schema)
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeReadOnly, previous)
+	require.NotContains(t, fake.modes, "test-value")
+
+	// global mode applies to subjects without their own
+	previous, err = c.ReconcileGlobalMode(ctx, schemaregistry.ModeImport)
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeReadWrite, previous)
+	mode, err = c.Mode(ctx, "test-value")
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeImport, mode)
+
+	// empty global mode is left as is
+	previous, err = c.ReconcileGlobalMode(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeImport, previous)
+	require.Equal(t, schemaregistry.ModeImport, fake.modes[""])
+}
+
+func TestClient_ReconcileGlobalCompatibility(t *testing.T) {
+	t.Parallel()
+
+	_, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	previous, err := c.ReconcileGlobalCompatibility(ctx, "full_transitive")
+	require.NoError(t, err)
+	require.Equal(t, "BACKWARD", previous)
+	level, err := c.CompatibilityLevel(ctx, "test-value")
+	require.NoError(t, err)
+	require.Equal(t, "FULL_TRANSITIVE", level)
+
+	previous, err = c.ReconcileGlobalCompatibility(ctx, "")
+	require.NoError(t, err)
+	require.Equal(t, "FULL_TRANSITIVE", previous)
+}
+
+func TestClient_PruneVersions(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	for _, schemaType := range []string{"string", "long", "int", "float", "double"} {
+		_, err = c.CreateSchema(ctx, &v1alpha1.KafkaSchemaSpec{
+			Name:   "test-value",
+			Schema: fmt.Sprintf(`{"type": "%s"}`, schemaType),
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	// referenced version and version of spec are kept
+	fake.mu.Lock()
+	fake.referencedBy["test-value:2"] = []string{"other-value"}
+	fake.mu.Unlock()
+	pruned, err := c.PruneVersions(ctx, "test-value", 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, []int{3}, pruned)
+	versions, err := c.Versions(ctx, "test-value")
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 4, 5}, versions)
+
+	// nothing to prune
+	pruned, err = c.PruneVersions(ctx, "test-value", 4, 0)
+	require.NoError(t, err)
+	require.Empty(t, pruned)
+
+	// version is pruned once it is not referenced anymore
+	fake.mu.Lock()
+	delete(fake.referencedBy, "test-value:2")
+	fake.mu.Unlock()
+	pruned, err = c.PruneVersions(ctx, "test-value", 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, []int{1, 2, 4}, pruned)
+}
+
+func TestClient_DeleteSubject(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = c.CreateSchema(ctx, &v1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "string"}`}, nil)
+	require.NoError(t, err)
+
+	// referenced subject can't be deleted
+	fake.mu.Lock()
+	fake.referencedBy["test-value"] = []string{"other-value"}
+	fake.mu.Unlock()
+	referencedBy, err := c.ReferencedBy(ctx, "test-value")
+	require.NoError(t, err)
+	require.Equal(t, []string{"other-value:1"}, referencedBy)
+
+	fake.mu.Lock()
+	delete(fake.referencedBy, "test-value")
+	fake.mu.Unlock()
+	referencedBy, err = c.ReferencedBy(ctx, "test-value")
+	require.NoError(t, err)
+	require.Empty(t, referencedBy)
+
+	require.NoError(t, c.DeleteSubject(ctx, "test-value", false))
+	require.True(t, fake.softDeleted["test-value"])
+	exists, err := c.SchemaExists(ctx, "test-value")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// soft deleted subject is deleted permanently
+	require.NoError(t, c.DeleteSubject(ctx, "test-value", true))
+	require.NotContains(t, fake.subjects, "test-value")
+
+	// missing subject is deleted already
+	require.NoError(t, c.DeleteSubject(ctx, "test-value", true))
+	referencedBy, err = c.ReferencedBy(ctx, "test-value")
+	require.NoError(t, err)
+	require.Empty(t, referencedBy)
+}
+
+func TestClient_Context(t *testing.T) {
+	t.Parallel()
+
+	fake, server := newFakeRegistry(t)
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	schema := &v1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "string"}`}
+	_, err = c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	schema.Name = schemaregistry.ContextSubject("tenant", schema.Name)
+	reg, err := c.CreateSchema(ctx, schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, ":.tenant:test-value", reg.Subject)
+	require.Equal(t, 1, reg.Version)
+	require.Len(t, fake.subjects[":.tenant:test-value"], 1)
+
+	subjects, err := c.Subjects(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{":.tenant:test-value", "test-value"}, subjects)
+
+	// schemas referencing subject are in its context
+	fake.mu.Lock()
+	fake.referencedBy[":.tenant:test-value"] = []string{"other-value"}
+	fake.mu.Unlock()
+	referencedBy, err := c.ReferencedBy(ctx, ":.tenant:test-value")
+	require.NoError(t, err)
+	require.Equal(t, []string{":.tenant:other-value:1"}, referencedBy)
+}
@@ -0,0 +1,143 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+// Error codes of Schema Registry API
+const (
+	ErrorCodeSubjectNotFound = 40401
+	ErrorCodeVersionNotFound = 40402
+	ErrorCodeSchemaNotFound  = 40403
+	ErrorCodeInvalidSchema   = 42201
+	// ErrorCodeSubjectConfigNotFound is returned when subject doesn't have its own compatibility level
+	ErrorCodeSubjectConfigNotFound = 40408
+)
+
+type (
+	// schemaRequest is body of requests with schema
+	schemaRequest struct {
+		Schema     string                       `json:"schema"`
+		SchemaType string                       `json:"schemaType,omitempty"`
+		References []v1alpha1.ResolvedReference `json:"references,omitempty"`
+		Metadata   *v1alpha1.SchemaMetadata     `json:"metadata,omitempty"`
+		RuleSet    *v1alpha1.SchemaRuleSet      `json:"ruleSet,omitempty"`
+		// ID and Version are accepted by Schema Registry only in IMPORT mode
+		ID      int `json:"id,omitempty"`
+		Version int `json:"version,omitempty"`
+	}
+	// Compatibility is verdict of Schema Registry on schema compatibility
+	Compatibility struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages,omitempty"`
+	}
+)
+
+// newSchemaRequest would make request body from schema spec
+func newSchemaRequest(schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) *schemaRequest {
+	req := &schemaRequest{
+		Schema:     schema.Schema,
+		References: references,
+		Metadata:   schema.Metadata,
+		RuleSet:    schema.RuleSet,
+	}
+	// AVRO is default, older registries don't know schemaType at all
+	if SchemaType(schema) != SchemaTypeAvro {
+		req.SchemaType = SchemaType(schema)
+	}
+	return req
+}
+
+// CheckCompatibility would check if schema is compatible with latest registered version of subject.
+// Schema of subject without versions is always compatible.
+func (c *Client) CheckCompatibility(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Compatibility, error) {
+	result := &Compatibility{}
+	err := c.do(ctx, http.MethodPost,
+		fmt.Sprintf("/compatibility/subjects/%s/versions/latest?verbose=true", url.PathEscape(schema.Name)),
+		newSchemaRequest(schema, references), result)
+	if errors.Is(err, ErrSubjectNotFound) || errors.Is(err, ErrVersionNotFound) {
+		return &Compatibility{IsCompatible: true}, nil
+	}
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) && apiErr.Code == ErrorCodeInvalidSchema {
+		// invalid schema can't be compatible, message explains why it is invalid
+		return &Compatibility{Messages: []string{apiErr.Message}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't check compatibility of schema %s: %w", schema.Name, err)
+	}
+	return result, nil
+}
+
+// SubjectCompatibility would return compatibility level set on subject itself, or empty string
+// if subject uses global level
+func (c *Client) SubjectCompatibility(ctx context.Context, subject string) (string, error) {
+	resp := &configResponse{}
+	err := c.do(ctx, http.MethodGet, "/config/"+url.PathEscape(subject), nil, resp)
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) &&
+		(apiErr.Code == ErrorCodeSubjectConfigNotFound || apiErr.Code == ErrorCodeSubjectNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't get compatibility level of %s: %w", subject, err)
+	}
+	return resp.CompatibilityLevel, nil
+}
+
+// ReconcileCompatibility would set compatibility level of subject from spec if it differs from current one,
+// subject is reset to global level if spec doesn't have it. Previous level of subject is returned.
+func (c *Client) ReconcileCompatibility(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec) (string, error) {
+	desired := strings.ToUpper(schema.Compatibility)
+	current, err := c.SubjectCompatibility(ctx, schema.Name)
+	if err != nil {
+		return "", err
+	}
+	if current == desired {
+		return current, nil
+	}
+	if len(desired) == 0 {
+		err = c.deleteCompatibility(ctx, schema.Name)
+	} else {
+		err = c.setCompatibility(ctx, schema.Name, desired)
+	}
+	c.audit(ctx, audit.ActionCompatibility, schema.Name,
+		map[string]string{"compatibility": current},
+		map[string]string{"compatibility": desired}, err)
+	return current, err
+}
+
+// setCompatibility would set compatibility level of subject
+// https://docs.confluent.io/platform/current/schema-registry/develop/using.html#update-compatibility-requirements-on-a-subject
+func (c *Client) setCompatibility(ctx context.Context, subject, level string) error {
+	resp := &struct {
+		Compatibility string `json:"compatibility"`
+	}{}
+	err := c.do(ctx, http.MethodPut, "/config/"+url.PathEscape(subject),
+		map[string]string{"compatibility": level}, resp)
+	if err != nil {
+		return fmt.Errorf("failed to set compatibility %s for %s: %w", level, subject, err)
+	}
+	if resp.Compatibility != level {
+		return fmt.Errorf("failed to set compatibility %s for %s: registry responded with %s", level, subject, resp.Compatibility)
+	}
+	return nil
+}
+
+// deleteCompatibility would reset compatibility level of subject to global one
+func (c *Client) deleteCompatibility(ctx context.Context, subject string) error {
+	err := c.do(ctx, http.MethodDelete, "/config/"+url.PathEscape(subject), nil, nil)
+	if err != nil && !errors.Is(err, ErrSubjectNotFound) {
+		return fmt.Errorf("failed to reset compatibility of %s to global: %w", subject, err)
+	}
+	return nil
+}
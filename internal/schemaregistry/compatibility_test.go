@@ -0,0 +1,88 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CheckCompatibility(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "true", r.URL.Query().Get("verbose"))
+		body := map[string]any{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", schemaregistry.ContentType)
+		switch r.URL.Path {
+		case "/compatibility/subjects/compatible-value/versions/latest":
+			// AVRO is default type and is not sent
+			require.NotContains(t, body, "schemaType")
+			_, _ = w.Write([]byte(`{"is_compatible": true}`))
+		case "/compatibility/subjects/incompatible-value/versions/latest":
+			require.Equal(t, "PROTOBUF", body["schemaType"])
+			_, _ = w.Write([]byte(`{"is_compatible": false, "messages": ["field removed", "type changed"]}`))
+		case "/compatibility/subjects/new-value/versions/latest":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code": 40401, "message": "Subject 'new-value' not found."}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"error_code": 50001, "message": "Error in the backend data store"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		schema   *v1alpha1.KafkaSchemaSpec
+		want     *schemaregistry.Compatibility
+		wantCode int
+	}{
+		{
+			name:   "compatible",
+			schema: &v1alpha1.KafkaSchemaSpec{Name: "compatible-value", Schema: `{"type": "string"}`},
+			want:   &schemaregistry.Compatibility{IsCompatible: true},
+		},
+		{
+			name: "incompatible",
+			schema: &v1alpha1.KafkaSchemaSpec{Name: "incompatible-value", SchemaType: "PROTOBUF",
+				Schema: `syntax = "proto3"; message Test {}`},
+			want: &schemaregistry.Compatibility{Messages: []string{"field removed", "type changed"}},
+		},
+		{
+			name:   "no versions yet",
+			schema: &v1alpha1.KafkaSchemaSpec{Name: "new-value", Schema: `{"type": "string"}`},
+			want:   &schemaregistry.Compatibility{IsCompatible: true},
+		},
+		{
+			name:     "registry error",
+			schema:   &v1alpha1.KafkaSchemaSpec{Name: "broken-value", Schema: `{"type": "string"}`},
+			wantCode: 50001,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := c.CheckCompatibility(context.Background(), tt.schema, nil)
+			if tt.wantCode != 0 {
+				apiErr := &schemaregistry.APIError{}
+				require.ErrorAs(t, err, &apiErr)
+				require.Equal(t, tt.wantCode, apiErr.Code)
+				require.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+// registryPropertyPrefix is prefix of metadata properties Schema Registry sets itself, i.e. confluent:version
+const registryPropertyPrefix = "confluent:"
+
+// sameContract is true if registered version has metadata and rule set of spec.
+// Properties set by Schema Registry itself and empty blocks are ignored.
+func sameContract(schema *v1alpha1.KafkaSchemaSpec, registered *schemaResponse) bool {
+	return FormatMetadata(schema.Metadata) == FormatMetadata(registered.Metadata) &&
+		FormatRuleSet(schema.RuleSet) == FormatRuleSet(registered.RuleSet)
+}
+
+// FormatMetadata would format metadata as compact JSON with sorted keys, i.e. for notifications.
+// Properties set by Schema Registry itself are omitted, metadata without tags and properties is empty.
+func FormatMetadata(metadata *v1alpha1.SchemaMetadata) string {
+	if metadata == nil {
+		return ""
+	}
+	metadata = metadata.DeepCopy()
+	for name := range metadata.Properties {
+		if strings.HasPrefix(name, registryPropertyPrefix) {
+			delete(metadata.Properties, name)
+		}
+	}
+	if len(metadata.Tags)+len(metadata.Properties)+len(metadata.Sensitive) == 0 {
+		return ""
+	}
+	return formatJSON(metadata)
+}
+
+// FormatRuleSet would format rule set as compact JSON with sorted keys, rule set without rules is empty
+func FormatRuleSet(ruleSet *v1alpha1.SchemaRuleSet) string {
+	if ruleSet == nil || len(ruleSet.DomainRules)+len(ruleSet.MigrationRules) == 0 {
+		return ""
+	}
+	return formatJSON(ruleSet)
+}
+
+// formatJSON would marshal value, maps are marshaled with sorted keys
+func formatJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,59 @@
+package schemaregistry_test
+
+import (
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		metadata *v1alpha1.SchemaMetadata
+		expected string
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name:     "empty metadata",
+			metadata: &v1alpha1.SchemaMetadata{Properties: map[string]string{}},
+		},
+		{
+			name: "keys are sorted",
+			metadata: &v1alpha1.SchemaMetadata{
+				Tags:       map[string][]string{"Order.customer": {"PII"}},
+				Properties: map[string]string{"owner_email": "payments@example.com", "owner": "payments"},
+			},
+			expected: `{"tags":{"Order.customer":["PII"]},"properties":{"owner":"payments","owner_email":"payments@example.com"}}`,
+		},
+		{
+			name:     "properties of registry are omitted",
+			metadata: &v1alpha1.SchemaMetadata{Properties: map[string]string{"confluent:version": "2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.expected, schemaregistry.FormatMetadata(tt.metadata))
+		})
+	}
+}
+
+func TestFormatRuleSet(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, schemaregistry.FormatRuleSet(&v1alpha1.SchemaRuleSet{}))
+	require.Equal(t, `{"migrationRules":[{"name":"rename","kind":"TRANSFORM","mode":"UPGRADE","type":"JSONATA","expr":"$"}]}`,
+		schemaregistry.FormatRuleSet(&v1alpha1.SchemaRuleSet{MigrationRules: []v1alpha1.SchemaRule{{
+			Name: "rename",
+			Kind: "TRANSFORM",
+			Mode: "UPGRADE",
+			Type: "JSONATA",
+			Expr: "$",
+		}}}))
+}
@@ -0,0 +1,161 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+)
+
+// Deletion policies of subjects
+const (
+	DeletionPolicyRetain     = "Retain"
+	DeletionPolicySoftDelete = "SoftDelete"
+	DeletionPolicyHardDelete = "HardDelete"
+	// ErrorCodeSubjectSoftDeleted is returned when subject was already soft deleted
+	ErrorCodeSubjectSoftDeleted = 40404
+)
+
+// subjectVersion is subject and version schema ID is registered under
+type subjectVersion struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// Versions would return all versions registered under subject
+func (c *Client) Versions(ctx context.Context, subject string) ([]int, error) {
+	versions := []int{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/subjects/%s/versions", url.PathEscape(subject)), nil, &versions)
+	if err != nil {
+		return nil, fmt.Errorf("can't get versions of %s: %w", subject, err)
+	}
+	return versions, nil
+}
+
+// ReferencedBy would return subjects and versions, formatted as subject:version,
+// which reference any version of subject
+func (c *Client) ReferencedBy(ctx context.Context, subject string) ([]string, error) {
+	versions, err := c.Versions(ctx, subject)
+	if errors.Is(err, ErrSubjectNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// IDs are unique only in context, so schemas are looked up in context of subject
+	schemaContext, _ := SplitSubject(subject)
+	idsPath := "/schemas/ids/%d/versions"
+	if len(schemaContext) != 0 {
+		idsPath = "/contexts/." + url.PathEscape(schemaContext) + idsPath
+	}
+	referencing := map[string]bool{}
+	for _, version := range versions {
+		ids := []int{}
+		err = c.do(ctx, http.MethodGet, fmt.Sprintf("/subjects/%s/versions/%d/referencedby", url.PathEscape(subject), version), nil, &ids)
+		if err != nil {
+			return nil, fmt.Errorf("can't get schemas referencing %s version %d: %w", subject, version, err)
+		}
+		for _, id := range ids {
+			users := []subjectVersion{}
+			err = c.do(ctx, http.MethodGet, fmt.Sprintf(idsPath, id), nil, &users)
+			if err != nil {
+				return nil, fmt.Errorf("can't get subjects of schema %d: %w", id, err)
+			}
+			for _, user := range users {
+				referencing[fmt.Sprintf("%s:%d", ContextSubject(schemaContext, user.Subject), user.Version)] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(referencing))
+	for ref := range referencing {
+		result = append(result, ref)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// DeleteSubject would soft delete all versions of subject, and if permanent is true, delete them permanently.
+// Permanent deletion in Schema Registry is possible only after soft deletion.
+func (c *Client) DeleteSubject(ctx context.Context, subject string, permanent bool) error {
+	versions := []int{}
+	err := c.do(ctx, http.MethodDelete, "/subjects/"+url.PathEscape(subject), nil, &versions)
+	apiErr := &APIError{}
+	switch {
+	case errors.Is(err, ErrSubjectNotFound):
+		// nothing to delete
+		return nil
+	case errors.As(err, &apiErr) && apiErr.Code == ErrorCodeSubjectSoftDeleted:
+		// soft deleted already, we might need to delete it permanently
+		err = nil
+	}
+	if err == nil && permanent {
+		versions = []int{}
+		err = c.do(ctx, http.MethodDelete, fmt.Sprintf("/subjects/%s?permanent=true", url.PathEscape(subject)), nil, &versions)
+	}
+	policy := DeletionPolicySoftDelete
+	if permanent {
+		policy = DeletionPolicyHardDelete
+	}
+	deleted := make([]string, 0, len(versions))
+	for _, version := range versions {
+		deleted = append(deleted, strconv.Itoa(version))
+	}
+	c.audit(ctx, audit.ActionDelete, subject,
+		map[string]string{"versions": strings.Join(deleted, ",")},
+		map[string]string{"deletionPolicy": policy}, err)
+	if err != nil {
+		return fmt.Errorf("can't delete subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// PruneVersions would soft delete versions of subject older than the last retention ones.
+// Versions referenced by other schemas and version keep, which schema of spec is registered as,
+// are never deleted. Deleted versions are returned.
+func (c *Client) PruneVersions(ctx context.Context, subject string, retention, keep int) ([]int, error) {
+	versions, err := c.Versions(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) <= retention {
+		return nil, nil
+	}
+	sort.Ints(versions)
+	pruned := []int{}
+	for _, version := range versions[:len(versions)-retention] {
+		if version == keep {
+			continue
+		}
+		ids := []int{}
+		err = c.do(ctx, http.MethodGet, fmt.Sprintf("/subjects/%s/versions/%d/referencedby", url.PathEscape(subject), version), nil, &ids)
+		if err != nil {
+			err = fmt.Errorf("can't get schemas referencing %s version %d: %w", subject, version, err)
+			break
+		}
+		if len(ids) != 0 {
+			continue
+		}
+		err = c.do(ctx, http.MethodDelete, fmt.Sprintf("/subjects/%s/versions/%d", url.PathEscape(subject), version), nil, nil)
+		if err != nil {
+			err = fmt.Errorf("can't delete %s version %d: %w", subject, version, err)
+			break
+		}
+		pruned = append(pruned, version)
+	}
+	if len(pruned) != 0 || err != nil {
+		deleted := make([]string, 0, len(pruned))
+		for _, version := range pruned {
+			deleted = append(deleted, strconv.Itoa(version))
+		}
+		c.audit(ctx, audit.ActionDelete, subject,
+			map[string]string{"versions": strings.Join(deleted, ",")},
+			map[string]string{"versionRetention": strconv.Itoa(retention)}, err)
+	}
+	return pruned, err
+}
@@ -0,0 +1,382 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of structural changes of schema
+const (
+	SchemaChangeAdded   = "added"
+	SchemaChangeRemoved = "removed"
+	SchemaChangeType    = "type changed"
+	SchemaChangeDefault = "default changed"
+	SchemaChangeSymbols = "symbols changed"
+)
+
+// SchemaChange is structural change of field between two versions of schema.
+// Old and New are types of field, its defaults or enum symbols, depending on Kind.
+type SchemaChange struct {
+	Kind string
+	Path string
+	Old  string
+	New  string
+}
+
+func (c SchemaChange) String() string {
+	switch c.Kind {
+	case SchemaChangeAdded:
+		return fmt.Sprintf("added %s: %s", c.Path, c.New)
+	case SchemaChangeRemoved:
+		return fmt.Sprintf("removed %s: %s", c.Path, c.Old)
+	}
+	return fmt.Sprintf("%s of %s: %s -> %s", c.Kind, c.Path, noneIfEmpty(c.Old), noneIfEmpty(c.New))
+}
+
+// noneIfEmpty would make absent defaults visible
+func noneIfEmpty(value string) string {
+	if len(value) == 0 {
+		return "<none>"
+	}
+	return value
+}
+
+// DiffSchemas would return structural changes from old to new schema: fields added, removed,
+// types and defaults changed. Only Avro and JSON schemas are compared, nil is returned for Protobuf.
+func DiffSchemas(schemaType, old, new string) ([]SchemaChange, error) {
+	if schemaType != SchemaTypeAvro && schemaType != SchemaTypeJSON {
+		return nil, nil
+	}
+	var oldDoc, newDoc any
+	err := json.Unmarshal([]byte(old), &oldDoc)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse previous schema: %w", err)
+	}
+	err = json.Unmarshal([]byte(new), &newDoc)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse new schema: %w", err)
+	}
+	d := &schemaDiff{}
+	if schemaType == SchemaTypeJSON {
+		oldNode, _ := oldDoc.(map[string]any)
+		newNode, _ := newDoc.(map[string]any)
+		d.diffJSON("", oldNode, newNode)
+		return d.changes, nil
+	}
+	d.oldNames = avroNamedTypes(oldDoc, "", map[string]map[string]any{})
+	d.newNames = avroNamedTypes(newDoc, "", map[string]map[string]any{})
+	d.visiting = map[string]bool{}
+	d.diffAvro("", oldDoc, newDoc)
+	return d.changes, nil
+}
+
+// SummarizeChanges would count changes by kind, i.e. "2 added, 1 type changed"
+func SummarizeChanges(changes []SchemaChange) string {
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Kind]++
+	}
+	summary := []string{}
+	for _, kind := range []string{SchemaChangeAdded, SchemaChangeRemoved, SchemaChangeType, SchemaChangeDefault, SchemaChangeSymbols} {
+		if counts[kind] != 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	return strings.Join(summary, ", ")
+}
+
+// schemaDiff collects changes while schemas are walked
+type schemaDiff struct {
+	changes []SchemaChange
+	// named Avro types of old and new schema by full name
+	oldNames map[string]map[string]any
+	newNames map[string]map[string]any
+	// records being compared, named types can be recursive
+	visiting map[string]bool
+}
+
+func (d *schemaDiff) add(kind, path, old, new string) {
+	d.changes = append(d.changes, SchemaChange{Kind: kind, Path: path, Old: old, New: new})
+}
+
+// diffFields would compare fields by name, calling diffField for fields of both schemas
+func (d *schemaDiff) diffFields(path string, old, new map[string]map[string]any, typeOf func(map[string]any) string,
+	diffField func(path string, old, new map[string]any)) {
+	names := make([]string, 0, len(old)+len(new))
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fieldPath := joinPath(path, name)
+		oldField, inOld := old[name]
+		newField, inNew := new[name]
+		switch {
+		case !inOld:
+			d.add(SchemaChangeAdded, fieldPath, "", typeOf(newField))
+		case !inNew:
+			d.add(SchemaChangeRemoved, fieldPath, typeOf(oldField), "")
+		default:
+			if oldType, newType := typeOf(oldField), typeOf(newField); oldType != newType {
+				d.add(SchemaChangeType, fieldPath, oldType, newType)
+			}
+			if oldDefault, newDefault := defaultOf(oldField), defaultOf(newField); oldDefault != newDefault {
+				d.add(SchemaChangeDefault, fieldPath, oldDefault, newDefault)
+			}
+			diffField(fieldPath, oldField, newField)
+		}
+	}
+}
+
+// diffAvro would compare Avro types at path, descending into records, arrays, maps and unions
+func (d *schemaDiff) diffAvro(path string, old, new any) {
+	oldNode, newNode := d.resolveAvro(old, d.oldNames), d.resolveAvro(new, d.newNames)
+	oldUnion, oldIsUnion := old.([]any)
+	newUnion, newIsUnion := new.([]any)
+	if oldIsUnion || newIsUnion {
+		// branches of unions are matched by type name, i.e. records in ["null", "Address"]
+		if !oldIsUnion {
+			oldUnion = []any{old}
+		}
+		if !newIsUnion {
+			newUnion = []any{new}
+		}
+		for _, oldBranch := range oldUnion {
+			for _, newBranch := range newUnion {
+				if avroTypeName(oldBranch) == avroTypeName(newBranch) {
+					d.diffAvro(path, oldBranch, newBranch)
+				}
+			}
+		}
+		return
+	}
+	if oldNode == nil || newNode == nil || oldNode["type"] != newNode["type"] {
+		return
+	}
+	switch oldNode["type"] {
+	case "record", "error":
+		name := avroFullName(oldNode, "")
+		if len(path) == 0 {
+			path, _ = oldNode["name"].(string)
+		}
+		if d.visiting[name] {
+			return
+		}
+		d.visiting[name] = true
+		defer delete(d.visiting, name)
+		d.diffFields(path, avroFields(oldNode), avroFields(newNode),
+			func(field map[string]any) string { return avroTypeName(field["type"]) },
+			func(path string, old, new map[string]any) { d.diffAvro(path, old["type"], new["type"]) })
+	case "enum":
+		oldSymbols, newSymbols := joinValues(oldNode["symbols"]), joinValues(newNode["symbols"])
+		if oldSymbols != newSymbols {
+			d.add(SchemaChangeSymbols, path, oldSymbols, newSymbols)
+		}
+	case "array":
+		d.diffAvro(path+"[]", oldNode["items"], newNode["items"])
+	case "map":
+		d.diffAvro(path+"{}", oldNode["values"], newNode["values"])
+	}
+}
+
+// resolveAvro would return definition of Avro type, named types are looked up by name
+func (d *schemaDiff) resolveAvro(node any, names map[string]map[string]any) map[string]any {
+	switch node := node.(type) {
+	case map[string]any:
+		if nested, ok := node["type"].(map[string]any); ok {
+			return nested
+		}
+		return node
+	case string:
+		if named, ok := names[node]; ok {
+			return named
+		}
+		// short name of type in the same namespace
+		for fullName, named := range names {
+			if strings.HasSuffix(fullName, "."+node) {
+				return named
+			}
+		}
+	}
+	return nil
+}
+
+// avroNamedTypes would collect records, enums and fixed types of schema by full name
+func avroNamedTypes(node any, namespace string, names map[string]map[string]any) map[string]map[string]any {
+	switch node := node.(type) {
+	case []any:
+		for _, branch := range node {
+			avroNamedTypes(branch, namespace, names)
+		}
+	case map[string]any:
+		switch node["type"] {
+		case "record", "error", "enum", "fixed":
+			fullName := avroFullName(node, namespace)
+			names[fullName] = node
+			if i := strings.LastIndex(fullName, "."); i > 0 {
+				namespace = fullName[:i]
+			}
+			for _, field := range avroFields(node) {
+				avroNamedTypes(field["type"], namespace, names)
+			}
+		case "array":
+			avroNamedTypes(node["items"], namespace, names)
+		case "map":
+			avroNamedTypes(node["values"], namespace, names)
+		default:
+			avroNamedTypes(node["type"], namespace, names)
+		}
+	}
+	return names
+}
+
+// avroFullName would return name of named type with its namespace
+func avroFullName(node map[string]any, namespace string) string {
+	name, _ := node["name"].(string)
+	if ns, ok := node["namespace"].(string); ok {
+		namespace = ns
+	}
+	if strings.Contains(name, ".") || len(namespace) == 0 {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// avroFields would return fields of record by name
+func avroFields(node map[string]any) map[string]map[string]any {
+	fields := map[string]map[string]any{}
+	list, _ := node["fields"].([]any)
+	for _, field := range list {
+		if field, ok := field.(map[string]any); ok {
+			name, _ := field["name"].(string)
+			fields[name] = field
+		}
+	}
+	return fields
+}
+
+// avroTypeName would describe Avro type in one line, i.e. null|string or array<Address>
+func avroTypeName(node any) string {
+	switch node := node.(type) {
+	case string:
+		return node
+	case []any:
+		branches := make([]string, 0, len(node))
+		for _, branch := range node {
+			branches = append(branches, avroTypeName(branch))
+		}
+		return strings.Join(branches, "|")
+	case map[string]any:
+		switch node["type"] {
+		case "record", "error", "enum", "fixed":
+			name, _ := node["name"].(string)
+			return name
+		case "array":
+			return "array<" + avroTypeName(node["items"]) + ">"
+		case "map":
+			return "map<" + avroTypeName(node["values"]) + ">"
+		}
+		name := avroTypeName(node["type"])
+		if logicalType, ok := node["logicalType"].(string); ok {
+			name += "(" + logicalType + ")"
+		}
+		return name
+	}
+	return fmt.Sprint(node)
+}
+
+// diffJSON would compare JSON schemas at path, descending into properties and items
+func (d *schemaDiff) diffJSON(path string, old, new map[string]any) {
+	if old == nil || new == nil {
+		return
+	}
+	d.diffFields(path, jsonProperties(old), jsonProperties(new), jsonTypeName, d.diffJSON)
+	oldItems, _ := old["items"].(map[string]any)
+	newItems, _ := new["items"].(map[string]any)
+	d.diffJSON(path+"[]", oldItems, newItems)
+}
+
+// jsonProperties would return properties of object schema by name
+func jsonProperties(node map[string]any) map[string]map[string]any {
+	properties := map[string]map[string]any{}
+	list, _ := node["properties"].(map[string]any)
+	for name, property := range list {
+		if property, ok := property.(map[string]any); ok {
+			properties[name] = property
+		}
+	}
+	return properties
+}
+
+// jsonTypeName would describe JSON schema type in one line, i.e. string(date-time) or array<object>
+func jsonTypeName(node map[string]any) string {
+	if ref, ok := node["$ref"].(string); ok {
+		return ref
+	}
+	name := joinValues(node["type"])
+	switch {
+	case name == "array":
+		items, _ := node["items"].(map[string]any)
+		if items != nil {
+			name = "array<" + jsonTypeName(items) + ">"
+		}
+	case len(name) == 0:
+		for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+			if branches, ok := node[keyword].([]any); ok {
+				names := make([]string, 0, len(branches))
+				for _, branch := range branches {
+					branch, _ := branch.(map[string]any)
+					names = append(names, jsonTypeName(branch))
+				}
+				return keyword + "<" + strings.Join(names, "|") + ">"
+			}
+		}
+	}
+	if format, ok := node["format"].(string); ok {
+		name += "(" + format + ")"
+	}
+	return name
+}
+
+// defaultOf would return default value of field as JSON, empty if field doesn't have default
+func defaultOf(field map[string]any) string {
+	value, ok := field["default"]
+	if !ok {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// joinValues would join string or list of strings, i.e. enum symbols or JSON types
+func joinValues(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []any:
+		values := make([]string, 0, len(value))
+		for _, v := range value {
+			values = append(values, fmt.Sprint(v))
+		}
+		return strings.Join(values, "|")
+	}
+	return ""
+}
+
+// joinPath would append name to dotted path
+func joinPath(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,139 @@
+package schemaregistry_test
+
+import (
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		schemaType string
+		old        string
+		new        string
+		want       []string
+		wantErr    string
+	}{
+		{
+			name:       "Avro fields",
+			schemaType: schemaregistry.SchemaTypeAvro,
+			old: `{"type": "record", "name": "Order", "fields": [
+				{"name": "id", "type": "string"},
+				{"name": "total", "type": "int"},
+				{"name": "status", "type": "string", "default": "NEW"},
+				{"name": "comment", "type": "string"}]}`,
+			new: `{"type": "record", "name": "Order", "fields": [
+				{"name": "id", "type": "string"},
+				{"name": "total", "type": "long"},
+				{"name": "status", "type": "string", "default": "OPEN"},
+				{"name": "notes", "type": ["null", "string"], "default": null}]}`,
+			want: []string{
+				"removed Order.comment: string",
+				"added Order.notes: null|string",
+				`default changed of Order.status: "NEW" -> "OPEN"`,
+				"type changed of Order.total: int -> long",
+			},
+		},
+		{
+			name:       "Avro nested and named types",
+			schemaType: schemaregistry.SchemaTypeAvro,
+			old: `{"type": "record", "name": "Order", "namespace": "com.example", "fields": [
+				{"name": "billing", "type": {"type": "record", "name": "Address", "fields": [{"name": "city", "type": "string"}]}},
+				{"name": "shipping", "type": ["null", "Address"]},
+				{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+					{"name": "sku", "type": "string"}]}}},
+				{"name": "state", "type": {"type": "enum", "name": "State", "symbols": ["NEW", "PAID"]}},
+				{"name": "next", "type": ["null", "Order"]}]}`,
+			new: `{"type": "record", "name": "Order", "namespace": "com.example", "fields": [
+				{"name": "billing", "type": {"type": "record", "name": "Address", "fields": [
+					{"name": "city", "type": "string"}, {"name": "zip", "type": "string", "default": ""}]}},
+				{"name": "shipping", "type": ["null", "Address"]},
+				{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+					{"name": "sku", "type": "string"}, {"name": "quantity", "type": "int", "default": 1}]}}},
+				{"name": "state", "type": {"type": "enum", "name": "State", "symbols": ["NEW", "PAID", "SHIPPED"]}},
+				{"name": "next", "type": ["null", "Order"]}]}`,
+			want: []string{
+				"added Order.billing.zip: string",
+				"added Order.lines[].quantity: int",
+				// recursive Order isn't compared again
+				"added Order.shipping.zip: string",
+				"symbols changed of Order.state: NEW|PAID -> NEW|PAID|SHIPPED",
+			},
+		},
+		{
+			name:       "Avro logical type",
+			schemaType: schemaregistry.SchemaTypeAvro,
+			old:        `{"type": "record", "name": "Event", "fields": [{"name": "at", "type": "long"}]}`,
+			new:        `{"type": "record", "name": "Event", "fields": [{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}}]}`,
+			want:       []string{"type changed of Event.at: long -> long(timestamp-millis)"},
+		},
+		{
+			name:       "JSON Schema properties",
+			schemaType: schemaregistry.SchemaTypeJSON,
+			old: `{"type": "object", "properties": {
+				"id": {"type": "string"},
+				"total": {"type": "integer"},
+				"tags": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string"}}}},
+				"customer": {"type": "object", "properties": {"email": {"type": "string"}}}}}`,
+			new: `{"type": "object", "properties": {
+				"id": {"type": "string", "format": "uuid"},
+				"total": {"type": "number", "default": 0},
+				"tags": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string"}, "color": {"type": "string"}}}},
+				"customer": {"type": "object", "properties": {}}}}`,
+			want: []string{
+				"removed customer.email: string",
+				"type changed of id: string -> string(uuid)",
+				"added tags[].color: string",
+				"type changed of total: integer -> number",
+				"default changed of total: <none> -> 0",
+			},
+		},
+		{
+			name:       "Protobuf isn't compared",
+			schemaType: schemaregistry.SchemaTypeProtobuf,
+			old:        `syntax = "proto3"; message Order { string id = 1; }`,
+			new:        `syntax = "proto3"; message Order { int64 id = 1; }`,
+		},
+		{
+			name:       "invalid previous schema",
+			schemaType: schemaregistry.SchemaTypeAvro,
+			old:        `{`,
+			new:        `"string"`,
+			wantErr:    "can't parse previous schema",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			changes, err := schemaregistry.DiffSchemas(tt.schemaType, tt.old, tt.new)
+			if len(tt.wantErr) != 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			got := []string{}
+			for _, change := range changes {
+				got = append(got, change.String())
+			}
+			if len(tt.want) == 0 {
+				require.Empty(t, got)
+				return
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSummarizeChanges(t *testing.T) {
+	t.Parallel()
+
+	changes, err := schemaregistry.DiffSchemas(schemaregistry.SchemaTypeAvro,
+		`{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "int"}, {"name": "a", "type": "int"}]}`,
+		`{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "long"}, {"name": "b", "type": "int"}, {"name": "c", "type": "int"}]}`)
+	require.NoError(t, err)
+	require.Equal(t, "2 added, 1 removed, 1 type changed", schemaregistry.SummarizeChanges(changes))
+}
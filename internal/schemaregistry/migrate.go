@@ -0,0 +1,315 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+)
+
+// Migration copies schemas from one Schema Registry to another keeping their IDs and versions,
+// as IDs are embedded in every message serialized with them. Soft deleted versions aren't copied.
+type Migration struct {
+	Source *Client
+	Target *Client
+	// Subjects selects subjects to copy, all subjects are copied if not set
+	Subjects *regexp.Regexp
+	// Force switches target to IMPORT mode even if it has subjects already
+	Force bool
+}
+
+// MigrationResult is what was copied by Migration, versions are formatted as subject:version
+type MigrationResult struct {
+	Imported []string
+	// Skipped versions were in target already with the same ID and schema
+	Skipped []string
+	// Configs are compatibility levels and modes changed in target, formatted as subject:config=value
+	Configs []string
+}
+
+// Run would copy versions of subjects from source to target registry in order of their IDs, so that
+// referenced schemas are copied before schemas referencing them, and then global compatibility level,
+// compatibility levels and modes of subjects. Target is switched to IMPORT mode while schemas are copied
+// and its previous global mode is restored afterwards. Versions target has already are skipped,
+// so interrupted migration can be run again.
+func (m *Migration) Run(ctx context.Context) (_ *MigrationResult, retErr error) {
+	versions, err := m.sourceVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := m.Target.GlobalMode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mode != ModeImport {
+		err = m.setTargetMode(ctx, mode, ModeImport, m.Force)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			retErr = errors.Join(retErr, m.setTargetMode(ctx, ModeImport, mode, false))
+		}()
+	}
+
+	result := &MigrationResult{}
+	subjects := []string{}
+	copied := map[string]bool{}
+	for _, version := range versions {
+		imported, iErr := m.importVersion(ctx, version)
+		if iErr != nil {
+			return result, iErr
+		}
+		if imported {
+			result.Imported = append(result.Imported, fmt.Sprintf("%s:%d", version.Subject, version.Version))
+		} else {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s:%d", version.Subject, version.Version))
+		}
+		if !copied[version.Subject] {
+			copied[version.Subject] = true
+			subjects = append(subjects, version.Subject)
+		}
+	}
+	sort.Strings(subjects)
+	result.Configs, err = m.copyConfigs(ctx, subjects)
+	return result, err
+}
+
+// Verify would compare subjects of source registry with target one: IDs, schemas and references of
+// their versions, compatibility levels and modes. Differences are returned, empty if there are none.
+func (m *Migration) Verify(ctx context.Context) ([]string, error) {
+	mismatches := []string{}
+	sourceLevel, err := m.Source.GlobalCompatibility(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targetLevel, err := m.Target.GlobalCompatibility(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sourceLevel != targetLevel {
+		mismatches = append(mismatches, fmt.Sprintf("global compatibility: %s in source, %s in target", sourceLevel, targetLevel))
+	}
+	versions, err := m.sourceVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subjects := []string{}
+	seen := map[string]bool{}
+	for _, version := range versions {
+		target, vErr := m.Target.version(ctx, version.Subject, version.Version)
+		switch {
+		case errors.Is(vErr, ErrSubjectNotFound) || errors.Is(vErr, ErrVersionNotFound):
+			mismatches = append(mismatches, fmt.Sprintf("%s:%d: missing in target", version.Subject, version.Version))
+		case vErr != nil:
+			return nil, vErr
+		case target.ID != version.ID:
+			mismatches = append(mismatches, fmt.Sprintf("%s:%d: ID %d in source, %d in target",
+				version.Subject, version.Version, version.ID, target.ID))
+		case !sameSchema(version, target):
+			mismatches = append(mismatches, fmt.Sprintf("%s:%d: schema differs", version.Subject, version.Version))
+		}
+		if !seen[version.Subject] {
+			seen[version.Subject] = true
+			subjects = append(subjects, version.Subject)
+		}
+	}
+	sort.Strings(subjects)
+	for _, subject := range subjects {
+		for _, config := range []struct {
+			name string
+			get  func(*Client) (string, error)
+		}{
+			{"compatibility", func(c *Client) (string, error) { return c.SubjectCompatibility(ctx, subject) }},
+			{"mode", func(c *Client) (string, error) { return c.SubjectMode(ctx, subject) }},
+		} {
+			source, sErr := config.get(m.Source)
+			if sErr != nil {
+				return nil, sErr
+			}
+			target, tErr := config.get(m.Target)
+			if tErr != nil {
+				return nil, tErr
+			}
+			if source != target {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s %s in source, %s in target",
+					subject, config.name, noneIfEmpty(source), noneIfEmpty(target)))
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+// subjects would return sorted subjects of registry selected by Subjects
+func (m *Migration) subjects(ctx context.Context, c *Client) ([]string, error) {
+	all, err := c.Subjects(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subjects := make([]string, 0, len(all))
+	for _, subject := range all {
+		if m.Subjects == nil || m.Subjects.MatchString(subject) {
+			subjects = append(subjects, subject)
+		}
+	}
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+// sourceVersions would return all versions of subjects in source registry ordered by ID
+func (m *Migration) sourceVersions(ctx context.Context) ([]*schemaResponse, error) {
+	subjects, err := m.subjects(ctx, m.Source)
+	if err != nil {
+		return nil, err
+	}
+	versions := []*schemaResponse{}
+	for _, subject := range subjects {
+		numbers, nErr := m.Source.Versions(ctx, subject)
+		if nErr != nil {
+			return nil, nErr
+		}
+		for _, number := range numbers {
+			version, vErr := m.Source.version(ctx, subject, number)
+			if vErr != nil {
+				return nil, vErr
+			}
+			version.Subject = subject
+			versions = append(versions, version)
+		}
+	}
+	// the same schema can be registered under several subjects, they keep order of subjects
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].ID < versions[j].ID
+	})
+	return versions, nil
+}
+
+// importVersion would register version in target registry with its ID and version, unless target has it already.
+// It is true if version was registered.
+func (m *Migration) importVersion(ctx context.Context, version *schemaResponse) (bool, error) {
+	existing, err := m.Target.version(ctx, version.Subject, version.Version)
+	switch {
+	case err == nil && existing.ID == version.ID && sameSchema(version, existing):
+		return false, nil
+	case err == nil:
+		return false, fmt.Errorf("can't import %s version %d with ID %d: target has different version with ID %d",
+			version.Subject, version.Version, version.ID, existing.ID)
+	case !errors.Is(err, ErrSubjectNotFound) && !errors.Is(err, ErrVersionNotFound):
+		return false, err
+	}
+	// schema isn't normalized, so it is the same as one in source
+	resp := &schemaResponse{}
+	err = m.Target.do(ctx, http.MethodPost, fmt.Sprintf("/subjects/%s/versions", url.PathEscape(version.Subject)),
+		&schemaRequest{
+			Schema:     version.Schema,
+			SchemaType: version.SchemaType,
+			References: version.References,
+			Metadata:   version.Metadata,
+			RuleSet:    version.RuleSet,
+			ID:         version.ID,
+			Version:    version.Version,
+		}, resp)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("can't import %s version %d with ID %d: %w", version.Subject, version.Version, version.ID, err)
+	case resp.ID != version.ID:
+		err = fmt.Errorf("can't import %s version %d with ID %d: target registered it with ID %d",
+			version.Subject, version.Version, version.ID, resp.ID)
+	}
+	new := map[string]string{
+		"schema":     version.Schema,
+		"schemaType": SchemaType(version.spec()),
+		"id":         strconv.Itoa(version.ID),
+		"version":    strconv.Itoa(version.Version),
+	}
+	if len(version.References) != 0 {
+		new["references"] = FormatReferences(version.References)
+	}
+	if metadata := FormatMetadata(version.Metadata); len(metadata) != 0 {
+		new["metadata"] = metadata
+	}
+	if ruleSet := FormatRuleSet(version.RuleSet); len(ruleSet) != 0 {
+		new["ruleSet"] = ruleSet
+	}
+	m.Target.audit(ctx, audit.ActionCreate, version.Subject, nil, new, err)
+	return err == nil, err
+}
+
+// copyConfigs would set global compatibility level of target registry and compatibility levels and modes
+// of subjects as they are in source. Modes are copied after schemas, so read-only subjects accept them.
+func (m *Migration) copyConfigs(ctx context.Context, subjects []string) ([]string, error) {
+	configs := []string{}
+	level, err := m.Source.GlobalCompatibility(ctx)
+	if err != nil {
+		return configs, err
+	}
+	previous, err := m.Target.ReconcileGlobalCompatibility(ctx, level)
+	if err != nil {
+		return configs, err
+	}
+	if previous != level {
+		configs = append(configs, fmt.Sprintf("%s:compatibility=%s", globalResource, level))
+	}
+	for _, subject := range subjects {
+		spec := &v1alpha1.KafkaSchemaSpec{Name: subject}
+		spec.Compatibility, err = m.Source.SubjectCompatibility(ctx, subject)
+		if err != nil {
+			return configs, err
+		}
+		spec.Mode, err = m.Source.SubjectMode(ctx, subject)
+		if err != nil {
+			return configs, err
+		}
+		previous, err = m.Target.ReconcileCompatibility(ctx, spec)
+		if err != nil {
+			return configs, err
+		}
+		if previous != spec.Compatibility {
+			configs = append(configs, fmt.Sprintf("%s:compatibility=%s", subject, spec.Compatibility))
+		}
+		previous, err = m.Target.ReconcileMode(ctx, spec)
+		if err != nil {
+			return configs, err
+		}
+		if previous != spec.Mode {
+			configs = append(configs, fmt.Sprintf("%s:mode=%s", subject, spec.Mode))
+		}
+	}
+	return configs, nil
+}
+
+// setTargetMode would set global mode of target registry, force allows IMPORT mode in registry with subjects
+func (m *Migration) setTargetMode(ctx context.Context, current, mode string, force bool) error {
+	path := "/mode"
+	if force {
+		path += "?force=true"
+	}
+	err := m.Target.setMode(ctx, path, mode)
+	m.Target.audit(ctx, audit.ActionMode, globalResource,
+		map[string]string{"mode": current},
+		map[string]string{"mode": mode}, err)
+	return err
+}
+
+// spec would make spec of registered version, i.e. to get its fingerprint
+func (r *schemaResponse) spec() *v1alpha1.KafkaSchemaSpec {
+	return &v1alpha1.KafkaSchemaSpec{Name: r.Subject, Schema: r.Schema, SchemaType: r.SchemaType, Metadata: r.Metadata, RuleSet: r.RuleSet}
+}
+
+// sameSchema is true if versions have the same canonical schema, references, metadata and rule set
+func sameSchema(a, b *schemaResponse) bool {
+	if len(a.References)+len(b.References) != 0 && !reflect.DeepEqual(a.References, b.References) {
+		return false
+	}
+	if !sameContract(a.spec(), b) {
+		return false
+	}
+	return Fingerprint(a.spec()) == Fingerprint(b.spec())
+}
@@ -0,0 +1,98 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigration(t *testing.T) {
+	t.Parallel()
+
+	sourceFake, sourceServer := newFakeRegistry(t)
+	source, err := schemaregistry.NewClient(schemaregistry.URL(sourceServer.URL))
+	require.NoError(t, err)
+	targetFake, targetServer := newFakeRegistry(t)
+	target, err := schemaregistry.NewClient(schemaregistry.URL(targetServer.URL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// source has pinned IDs, deleted version and configs of subjects
+	sourceFake.mu.Lock()
+	sourceFake.subjects["order-value"] = []string{`{"type":"string"}`, "", `{"type":"long"}`}
+	sourceFake.ids["order-value:1"] = 10
+	sourceFake.ids["order-value:3"] = 12
+	sourceFake.subjects["user-value"] = []string{`{"type":"int"}`}
+	sourceFake.ids["user-value:1"] = 11
+	sourceFake.subjects["other-key"] = []string{`{"type":"int"}`}
+	sourceFake.compatibility[""] = "FULL"
+	sourceFake.compatibility["order-value"] = "NONE"
+	sourceFake.modes["order-value"] = schemaregistry.ModeReadOnly
+	sourceFake.mu.Unlock()
+
+	migration := &schemaregistry.Migration{
+		Source:   source,
+		Target:   target,
+		Subjects: regexp.MustCompile(`-value$`),
+	}
+	mismatches, err := migration.Verify(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"global compatibility: FULL in source, BACKWARD in target",
+		"order-value:1: missing in target",
+		"user-value:1: missing in target",
+		"order-value:3: missing in target",
+		"order-value: compatibility NONE in source, <none> in target",
+		"order-value: mode READONLY in source, <none> in target",
+	}, mismatches)
+
+	result, err := migration.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"order-value:1", "user-value:1", "order-value:3"}, result.Imported)
+	require.Empty(t, result.Skipped)
+	require.Equal(t, []string{
+		"__GLOBAL:compatibility=FULL",
+		"order-value:compatibility=NONE",
+		"order-value:mode=READONLY",
+	}, result.Configs)
+	require.Equal(t, 12, targetFake.id("order-value", 3))
+	require.NotContains(t, targetFake.subjects, "other-key")
+	// previous global mode of target is restored
+	mode, err := target.GlobalMode(ctx)
+	require.NoError(t, err)
+	require.Equal(t, schemaregistry.ModeReadWrite, mode)
+
+	mismatches, err = migration.Verify(ctx)
+	require.NoError(t, err)
+	require.Empty(t, mismatches)
+
+	// migration can be run again, versions target has are skipped
+	sourceFake.mu.Lock()
+	sourceFake.subjects["user-value"] = append(sourceFake.subjects["user-value"], `{"type":"double"}`)
+	sourceFake.ids["user-value:2"] = 13
+	sourceFake.mu.Unlock()
+	result, err = migration.Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"user-value:2"}, result.Imported)
+	require.Equal(t, []string{"order-value:1", "user-value:1", "order-value:3"}, result.Skipped)
+	require.Empty(t, result.Configs)
+
+	// version registered in target with other ID is reported
+	targetFake.mu.Lock()
+	targetFake.ids["user-value:2"] = 20
+	targetFake.mu.Unlock()
+	mismatches, err = migration.Verify(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"user-value:2: ID 13 in source, 20 in target"}, mismatches)
+	_, err = migration.Run(ctx)
+	require.ErrorContains(t, err, "can't import user-value version 2 with ID 13: target has different version with ID 20")
+
+	// registered schema isn't changed by migration
+	reg, err := target.LookupSchema(ctx, &v1alpha1.KafkaSchemaSpec{Name: "order-value", Schema: `{"type": "long"}`}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 12, reg.ID)
+}
@@ -0,0 +1,174 @@
+package schemaregistry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+)
+
+// Modes of Schema Registry and subjects, see https://docs.confluent.io/platform/current/schema-registry/develop/api.html#mode
+const (
+	ModeReadWrite        = "READWRITE"
+	ModeReadOnly         = "READONLY"
+	ModeReadOnlyOverride = "READONLY_OVERRIDE"
+	ModeImport           = "IMPORT"
+	// ErrorCodeSubjectModeNotFound is returned when subject doesn't have its own mode
+	ErrorCodeSubjectModeNotFound = 40409
+	// globalResource is how global config of Schema Registry is audited
+	globalResource = "__GLOBAL"
+)
+
+// modeRequest is body of mode requests and responses
+type modeRequest struct {
+	Mode string `json:"mode"`
+}
+
+// Writable is true if schemas can be registered in mode, empty mode is unknown and assumed writable
+func Writable(mode string) bool {
+	return len(mode) == 0 || mode == ModeReadWrite
+}
+
+// ReadOnly is true if subject in mode rejects all registrations,
+// unlike IMPORT mode which accepts schemas with pinned ID
+func ReadOnly(mode string) bool {
+	return mode == ModeReadOnly || mode == ModeReadOnlyOverride
+}
+
+// GlobalMode would return global mode of Schema Registry
+func (c *Client) GlobalMode(ctx context.Context) (string, error) {
+	resp := &modeRequest{}
+	err := c.do(ctx, http.MethodGet, "/mode", nil, resp)
+	if err != nil {
+		return "", fmt.Errorf("can't get global mode: %w", err)
+	}
+	return resp.Mode, nil
+}
+
+// SubjectMode would return mode set on subject itself, or empty string if subject uses global mode
+func (c *Client) SubjectMode(ctx context.Context, subject string) (string, error) {
+	resp := &modeRequest{}
+	err := c.do(ctx, http.MethodGet, "/mode/"+url.PathEscape(subject), nil, resp)
+	apiErr := &APIError{}
+	if errors.As(err, &apiErr) &&
+		(apiErr.Code == ErrorCodeSubjectModeNotFound || apiErr.Code == ErrorCodeSubjectNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("can't get mode of %s: %w", subject, err)
+	}
+	return resp.Mode, nil
+}
+
+// Mode would return effective mode of subject, which is global one if subject doesn't have its own
+func (c *Client) Mode(ctx context.Context, subject string) (string, error) {
+	mode, err := c.SubjectMode(ctx, subject)
+	if err != nil || len(mode) != 0 {
+		return mode, err
+	}
+	return c.GlobalMode(ctx)
+}
+
+// ReconcileMode would set mode of subject from spec if it differs from current one,
+// subject is reset to global mode if spec doesn't have it. Previous mode of subject is returned.
+func (c *Client) ReconcileMode(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec) (string, error) {
+	desired := strings.ToUpper(schema.Mode)
+	current, err := c.SubjectMode(ctx, schema.Name)
+	if err != nil {
+		return "", err
+	}
+	if current == desired {
+		return current, nil
+	}
+	if len(desired) == 0 {
+		err = c.deleteMode(ctx, schema.Name)
+	} else {
+		err = c.setMode(ctx, "/mode/"+url.PathEscape(schema.Name), desired)
+	}
+	c.audit(ctx, audit.ActionMode, schema.Name,
+		map[string]string{"mode": current},
+		map[string]string{"mode": desired}, err)
+	return current, err
+}
+
+// ReconcileGlobalMode would set global mode of Schema Registry if it differs from current one,
+// empty mode leaves it as is. Previous global mode is returned.
+func (c *Client) ReconcileGlobalMode(ctx context.Context, mode string) (string, error) {
+	desired := strings.ToUpper(mode)
+	current, err := c.GlobalMode(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(desired) == 0 || current == desired {
+		return current, nil
+	}
+	err = c.setMode(ctx, "/mode", desired)
+	c.audit(ctx, audit.ActionMode, globalResource,
+		map[string]string{"mode": current},
+		map[string]string{"mode": desired}, err)
+	return current, err
+}
+
+// setMode would set mode of subject or, for /mode path, global one
+func (c *Client) setMode(ctx context.Context, path, mode string) error {
+	resp := &modeRequest{}
+	err := c.do(ctx, http.MethodPut, path, &modeRequest{Mode: mode}, resp)
+	if err != nil {
+		return fmt.Errorf("failed to set mode %s of %s: %w", mode, path, err)
+	}
+	if resp.Mode != mode {
+		return fmt.Errorf("failed to set mode %s of %s: registry responded with %s", mode, path, resp.Mode)
+	}
+	return nil
+}
+
+// deleteMode would reset mode of subject to global one
+func (c *Client) deleteMode(ctx context.Context, subject string) error {
+	err := c.do(ctx, http.MethodDelete, "/mode/"+url.PathEscape(subject), nil, nil)
+	if err != nil && !errors.Is(err, ErrSubjectNotFound) {
+		return fmt.Errorf("failed to reset mode of %s to global: %w", subject, err)
+	}
+	return nil
+}
+
+// GlobalCompatibility would return global compatibility level of Schema Registry
+func (c *Client) GlobalCompatibility(ctx context.Context) (string, error) {
+	resp := &configResponse{}
+	err := c.do(ctx, http.MethodGet, "/config", nil, resp)
+	if err != nil {
+		return "", fmt.Errorf("can't get global compatibility level: %w", err)
+	}
+	return resp.CompatibilityLevel, nil
+}
+
+// ReconcileGlobalCompatibility would set global compatibility level of Schema Registry if it differs
+// from current one, empty level leaves it as is. Previous global level is returned.
+func (c *Client) ReconcileGlobalCompatibility(ctx context.Context, level string) (string, error) {
+	desired := strings.ToUpper(level)
+	current, err := c.GlobalCompatibility(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(desired) == 0 || current == desired {
+		return current, nil
+	}
+	resp := &struct {
+		Compatibility string `json:"compatibility"`
+	}{}
+	err = c.do(ctx, http.MethodPut, "/config", map[string]string{"compatibility": desired}, resp)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("failed to set global compatibility %s: %w", desired, err)
+	case resp.Compatibility != desired:
+		err = fmt.Errorf("failed to set global compatibility %s: registry responded with %s", desired, resp.Compatibility)
+	}
+	c.audit(ctx, audit.ActionCompatibility, globalResource,
+		map[string]string{"compatibility": current},
+		map[string]string{"compatibility": desired}, err)
+	return current, err
+}
@@ -0,0 +1,160 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+var (
+	// protobufComment matches line and block comments of Protobuf definition
+	protobufComment = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	// protobufString matches string literals of Protobuf definition
+	protobufString = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'`)
+	// protobufSyntax matches syntax statement of Protobuf definition
+	protobufSyntax = regexp.MustCompile(`^\s*syntax\s*=\s*("proto2"|"proto3"|'proto2'|'proto3')\s*;`)
+	// protobufTopLevel are statements allowed at top level of .proto file
+	protobufTopLevel = map[string]bool{
+		"syntax": true, "edition": true, "package": true, "import": true, "option": true,
+		"message": true, "enum": true, "service": true, "extend": true,
+	}
+	// protobufIdent matches name of message, enum or service
+	protobufIdent = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+)
+
+// ParseSchema would parse schema locally for its type, so that invalid schema is rejected before
+// it is sent to Schema Registry. Avro and JSON schemas with references are only checked to be JSON,
+// as referenced types aren't known locally.
+func ParseSchema(schema *v1alpha1.KafkaSchemaSpec) error {
+	switch SchemaType(schema) {
+	case SchemaTypeAvro:
+		if len(schema.References) != 0 {
+			return parseJSON(schema)
+		}
+		_, err := goavro.NewCodec(schema.Schema)
+		if err != nil {
+			return fmt.Errorf("invalid Avro schema %s: %w", schema.Name, err)
+		}
+	case SchemaTypeJSON:
+		if len(schema.References) != 0 {
+			return parseJSON(schema)
+		}
+		compiler := jsonschema.NewCompiler()
+		// remote documents are never loaded by operator
+		compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+			return nil, fmt.Errorf("can't load %s, only references registered in Schema Registry are supported", url)
+		}
+		err := compiler.AddResource("schema.json", strings.NewReader(schema.Schema))
+		if err == nil {
+			_, err = compiler.Compile("schema.json")
+		}
+		if err != nil {
+			return fmt.Errorf("invalid JSON schema %s: %w", schema.Name, err)
+		}
+	case SchemaTypeProtobuf:
+		err := parseProtobuf(schema.Schema)
+		if err != nil {
+			return fmt.Errorf("invalid Protobuf schema %s: %w", schema.Name, err)
+		}
+	}
+	return nil
+}
+
+// parseJSON would check that schema is JSON document
+func parseJSON(schema *v1alpha1.KafkaSchemaSpec) error {
+	var doc any
+	err := json.Unmarshal([]byte(schema.Schema), &doc)
+	if err != nil {
+		return fmt.Errorf("invalid %s schema %s: %w", SchemaType(schema), schema.Name, err)
+	}
+	return nil
+}
+
+// parseProtobuf is lightweight check of .proto file: syntax statement, known top level statements,
+// named definitions and balanced braces. Full parsing and linking is done by Schema Registry.
+func parseProtobuf(definition string) error {
+	// comments and strings are replaced by spaces, so line numbers are kept
+	blank := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '\n' {
+				return r
+			}
+			return ' '
+		}, s)
+	}
+	text := protobufComment.ReplaceAllStringFunc(definition, blank)
+	if strings.HasPrefix(strings.TrimSpace(text), "syntax") && !protobufSyntax.MatchString(text) {
+		return fmt.Errorf("line %d: syntax must be \"proto2\" or \"proto3\"", lineOf(text, strings.Index(text, "syntax")))
+	}
+	text = protobufString.ReplaceAllStringFunc(text, blank)
+
+	depth := 0
+	definitions := 0
+	// start of statement at top level
+	statementStart := true
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '{':
+			depth++
+			statementStart = false
+		case c == '}':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("line %d: unexpected }", lineOf(text, i))
+			}
+			statementStart = depth == 0
+		case c == ';':
+			statementStart = depth == 0
+		case depth == 0 && statementStart && isIdentStart(c):
+			word := readWord(text[i:])
+			if !protobufTopLevel[word] {
+				return fmt.Errorf("line %d: unexpected %s at top level", lineOf(text, i), word)
+			}
+			if word == "message" || word == "enum" || word == "service" {
+				name := readWord(strings.TrimLeft(text[i+len(word):], " \t\r\n"))
+				if !protobufIdent.MatchString(name) {
+					return fmt.Errorf("line %d: %s must have name", lineOf(text, i), word)
+				}
+				definitions++
+			}
+			statementStart = false
+			i += len(word) - 1
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced braces, %d not closed", depth)
+	}
+	if definitions == 0 {
+		return fmt.Errorf("no message, enum or service is defined")
+	}
+	return nil
+}
+
+// isIdentStart is true for first character of identifier
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// readWord would return identifier at start of text
+func readWord(text string) string {
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if !isIdentStart(c) && !(c >= '0' && c <= '9') {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// lineOf would return line number of offset in text
+func lineOf(text string, offset int) int {
+	return strings.Count(text[:offset], "\n") + 1
+}
@@ -0,0 +1,125 @@
+package schemaregistry_test
+
+import (
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		schema  *v1alpha1.KafkaSchemaSpec
+		wantErr string
+	}{
+		{
+			name:   "Avro record",
+			schema: &v1alpha1.KafkaSchemaSpec{Schema: `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "string"}]}`},
+		},
+		{
+			name:    "empty Avro schema",
+			schema:  &v1alpha1.KafkaSchemaSpec{Schema: `{}`},
+			wantErr: "invalid Avro schema",
+		},
+		{
+			name:    "Avro record with unknown type",
+			schema:  &v1alpha1.KafkaSchemaSpec{Schema: `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "Money"}]}`},
+			wantErr: "Money",
+		},
+		{
+			name: "Avro record with referenced type",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				Schema:     `{"type": "record", "name": "Order", "fields": [{"name": "id", "type": "Money"}]}`,
+				References: []v1alpha1.SchemaReference{{Name: "Money", Subject: "money-value"}},
+			},
+		},
+		{
+			name: "JSON Schema",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeJSON,
+				Schema:     `{"$schema": "http://json-schema.org/draft-07/schema#", "type": "object", "properties": {"id": {"type": "string"}}}`,
+			},
+		},
+		{
+			name: "JSON Schema with invalid keyword value",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeJSON,
+				Schema:     `{"type": "object", "properties": {"id": {"type": "text"}}}`,
+			},
+			wantErr: "invalid JSON schema",
+		},
+		{
+			name: "JSON Schema with remote reference",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeJSON,
+				Schema:     `{"type": "object", "properties": {"id": {"$ref": "https://example.com/id.json"}}}`,
+			},
+			wantErr: "only references registered in Schema Registry",
+		},
+		{
+			name: "Protobuf",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeProtobuf,
+				Schema: `syntax = "proto3";
+package com.example;
+
+import "common/money.proto";
+
+// Order is placed by customer { not a brace }
+message Order {
+  string id = 1;
+  com.example.Money total = 2;
+  map<string, string> labels = 3 [deprecated = true];
+}
+`,
+			},
+		},
+		{
+			name: "Protobuf with unknown syntax",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeProtobuf,
+				Schema:     `syntax = "proto4"; message Order { string id = 1; }`,
+			},
+			wantErr: "line 1: syntax",
+		},
+		{
+			name: "Protobuf with unbalanced braces",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeProtobuf,
+				Schema:     "syntax = \"proto3\";\nmessage Order {\n  string id = 1;\n",
+			},
+			wantErr: "unbalanced braces",
+		},
+		{
+			name: "Protobuf with unknown statement",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeProtobuf,
+				Schema:     "syntax = \"proto3\";\n\nmesage Order {\n  string id = 1;\n}\n",
+			},
+			wantErr: "line 3: unexpected mesage",
+		},
+		{
+			name: "Protobuf without definitions",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SchemaType: schemaregistry.SchemaTypeProtobuf,
+				Schema:     `syntax = "proto3"; package com.example;`,
+			},
+			wantErr: "no message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := schemaregistry.ParseSchema(tt.schema)
+			if len(tt.wantErr) != 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
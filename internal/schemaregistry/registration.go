@@ -0,0 +1,156 @@
+package schemaregistry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+type (
+	// Registration is schema as it is registered in Schema Registry
+	Registration struct {
+		Subject            string
+		ID                 int
+		Version            int
+		SchemaType         string
+		Fingerprint        string
+		CompatibilityLevel string
+	}
+	// schemaResponse is registered schema returned by Schema Registry
+	schemaResponse struct {
+		Subject    string                       `json:"subject"`
+		ID         int                          `json:"id"`
+		Version    int                          `json:"version"`
+		SchemaType string                       `json:"schemaType"`
+		Schema     string                       `json:"schema"`
+		References []v1alpha1.ResolvedReference `json:"references"`
+		Metadata   *v1alpha1.SchemaMetadata     `json:"metadata,omitempty"`
+		RuleSet    *v1alpha1.SchemaRuleSet      `json:"ruleSet,omitempty"`
+	}
+	// configResponse is compatibility config of subject
+	configResponse struct {
+		CompatibilityLevel string `json:"compatibilityLevel"`
+	}
+)
+
+// register would register schema under subject, returning its ID. Schema is normalized by
+// Schema Registry, so formatting of schema doesn't make new version.
+func (c *Client) register(ctx context.Context, subject string, req *schemaRequest) (int, error) {
+	resp := &schemaResponse{}
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/subjects/%s/versions?normalize=true", url.PathEscape(subject)),
+		req, resp)
+	if err != nil {
+		return 0, fmt.Errorf("can't register schema %s: %w", subject, err)
+	}
+	return resp.ID, nil
+}
+
+// LookupSchema would find schema registered under subject with the same normalized schema, references,
+// metadata and rule set. Errors wrap ErrSubjectNotFound or ErrSchemaNotFound if schema isn't registered.
+func (c *Client) LookupSchema(ctx context.Context, schema *v1alpha1.KafkaSchemaSpec, references []v1alpha1.ResolvedReference) (*Registration, error) {
+	resp := &schemaResponse{}
+	err := c.do(ctx, http.MethodPost, fmt.Sprintf("/subjects/%s?normalize=true", url.PathEscape(schema.Name)),
+		newSchemaRequest(schema, references), resp)
+	// older registries ignore metadata and rule set on lookup, version with other ones is new version
+	if err == nil && !sameContract(schema, resp) {
+		err = &APIError{StatusCode: http.StatusNotFound, Code: ErrorCodeSchemaNotFound, Message: "Schema not found."}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't lookup schema %s: %w", schema.Name, err)
+	}
+	reg := &Registration{
+		Subject:     resp.Subject,
+		ID:          resp.ID,
+		Version:     resp.Version,
+		SchemaType:  resp.SchemaType,
+		Fingerprint: Fingerprint(schema),
+	}
+	// AVRO is not returned by Schema Registry, as it is default type
+	if len(reg.SchemaType) == 0 {
+		reg.SchemaType = SchemaTypeAvro
+	}
+	reg.CompatibilityLevel, err = c.CompatibilityLevel(ctx, schema.Name)
+	if err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// latest would return latest version registered under subject.
+// Error wraps ErrSubjectNotFound if subject doesn't exist.
+func (c *Client) latest(ctx context.Context, subject string) (*schemaResponse, error) {
+	resp := &schemaResponse{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/subjects/%s/versions/latest", url.PathEscape(subject)), nil, resp)
+	if err != nil {
+		return nil, fmt.Errorf("can't get latest version of %s: %w", subject, err)
+	}
+	return resp, nil
+}
+
+// version would return version registered under subject.
+// Error wraps ErrSubjectNotFound or ErrVersionNotFound if it doesn't exist.
+func (c *Client) version(ctx context.Context, subject string, version int) (*schemaResponse, error) {
+	resp := &schemaResponse{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/subjects/%s/versions/%d", url.PathEscape(subject), version), nil, resp)
+	if err != nil {
+		return nil, fmt.Errorf("can't get version %d of %s: %w", version, subject, err)
+	}
+	return resp, nil
+}
+
+// CompatibilityLevel would return effective compatibility level of subject,
+// which is global one if subject doesn't have its own
+func (c *Client) CompatibilityLevel(ctx context.Context, subject string) (string, error) {
+	resp := &configResponse{}
+	err := c.do(ctx, http.MethodGet, fmt.Sprintf("/config/%s?defaultToGlobal=true", url.PathEscape(subject)), nil, resp)
+	if err != nil {
+		return "", fmt.Errorf("can't get compatibility level of %s: %w", subject, err)
+	}
+	return resp.CompatibilityLevel, nil
+}
+
+// Fingerprint would return SHA-256 of canonical form of schema, so that
+// formatting of schema in spec doesn't change it
+func Fingerprint(schema *v1alpha1.KafkaSchemaSpec) string {
+	sum := sha256.Sum256([]byte(canonicalSchema(schema)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// canonicalSchema would return canonical form of schema: Parsing Canonical Form for Avro,
+// compact JSON with sorted keys for JSON documents and whitespace normalized text for Protobuf
+func canonicalSchema(schema *v1alpha1.KafkaSchemaSpec) string {
+	switch SchemaType(schema) {
+	case SchemaTypeAvro:
+		// schema with references to other named types can't be parsed standalone
+		codec, err := goavro.NewCodec(schema.Schema)
+		if err == nil {
+			return codec.CanonicalSchema()
+		}
+		return canonicalJSON(schema.Schema)
+	case SchemaTypeJSON:
+		return canonicalJSON(schema.Schema)
+	}
+	return strings.Join(strings.Fields(schema.Schema), " ")
+}
+
+// canonicalJSON would return compact JSON with sorted keys or original document if it isn't JSON
+func canonicalJSON(doc string) string {
+	var value any
+	if json.Unmarshal([]byte(doc), &value) != nil {
+		return doc
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return doc
+	}
+	return string(data)
+}
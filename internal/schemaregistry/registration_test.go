@@ -0,0 +1,81 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	compact := &v1alpha1.KafkaSchemaSpec{
+		Schema: `{"type":"record","name":"Test","namespace":"com.example","doc":"test record","fields":[{"name":"id","type":"long"}]}`,
+	}
+	formatted := &v1alpha1.KafkaSchemaSpec{
+		Schema: `{
+  "namespace": "com.example",
+  "name": "Test",
+  "type": "record",
+  "fields": [
+    {"name": "id", "type": "long"}
+  ]
+}`,
+	}
+	changed := &v1alpha1.KafkaSchemaSpec{
+		Schema: `{"type":"record","name":"Test","namespace":"com.example","fields":[{"name":"id","type":"int"}]}`,
+	}
+	// docs and formatting are not part of Avro canonical form
+	require.Equal(t, schemaregistry.Fingerprint(compact), schemaregistry.Fingerprint(formatted))
+	require.NotEqual(t, schemaregistry.Fingerprint(compact), schemaregistry.Fingerprint(changed))
+	require.Regexp(t, `^sha256:[0-9a-f]{64}$`, schemaregistry.Fingerprint(compact))
+
+	jsonA := &v1alpha1.KafkaSchemaSpec{SchemaType: "JSON", Schema: `{"type": "object", "title": "Test"}`}
+	jsonB := &v1alpha1.KafkaSchemaSpec{SchemaType: "JSON", Schema: "{\n\"title\":\"Test\",\n\"type\":\"object\"\n}"}
+	require.Equal(t, schemaregistry.Fingerprint(jsonA), schemaregistry.Fingerprint(jsonB))
+
+	protoA := &v1alpha1.KafkaSchemaSpec{SchemaType: "PROTOBUF", Schema: "syntax = \"proto3\";\nmessage Test {\n  string name = 1;\n}"}
+	protoB := &v1alpha1.KafkaSchemaSpec{SchemaType: "PROTOBUF", Schema: `syntax = "proto3"; message Test { string name = 1; }`}
+	require.Equal(t, schemaregistry.Fingerprint(protoA), schemaregistry.Fingerprint(protoB))
+}
+
+func TestClient_LookupSchema(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", schemaregistry.ContentType)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/subjects/test-value":
+			_, _ = w.Write([]byte(`{"subject": "test-value", "id": 42, "version": 3, "schema": "\"string\""}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/config/test-value":
+			require.Equal(t, "true", r.URL.Query().Get("defaultToGlobal"))
+			_, _ = w.Write([]byte(`{"compatibilityLevel": "FULL"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code": 40401, "message": "Subject not found."}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	schema := &v1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `"string"`}
+	reg, err := c.LookupSchema(context.Background(), schema, nil)
+	require.NoError(t, err)
+	require.Equal(t, &schemaregistry.Registration{
+		Subject:            "test-value",
+		ID:                 42,
+		Version:            3,
+		SchemaType:         schemaregistry.SchemaTypeAvro,
+		Fingerprint:        schemaregistry.Fingerprint(schema),
+		CompatibilityLevel: "FULL",
+	}, reg)
+
+	_, err = c.LookupSchema(context.Background(), &v1alpha1.KafkaSchemaSpec{Name: "other-value", Schema: `"string"`}, nil)
+	require.Error(t, err)
+}
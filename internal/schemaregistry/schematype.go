@@ -0,0 +1,61 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/riferrei/srclient"
+)
+
+// Schema types supported by Schema Registry
+const (
+	SchemaTypeAvro     = "AVRO"
+	SchemaTypeProtobuf = "PROTOBUF"
+	SchemaTypeJSON     = "JSON"
+)
+
+// SchemaType would return type of schema, AVRO is default one
+func SchemaType(schema *v1alpha1.KafkaSchemaSpec) string {
+	if len(schema.SchemaType) == 0 {
+		return SchemaTypeAvro
+	}
+	return strings.ToUpper(schema.SchemaType)
+}
+
+// ValidateSchema would check that schema type is supported and schema can be parsed as one of its type
+func ValidateSchema(schema *v1alpha1.KafkaSchemaSpec) error {
+	_, err := parseSchemaType(schema)
+	if err != nil {
+		return err
+	}
+	if SchemaType(schema) == SchemaTypeProtobuf && json.Valid([]byte(schema.Schema)) {
+		return fmt.Errorf("schema %s is JSON document, not Protobuf definition", schema.Name)
+	}
+	err = ParseSchema(schema)
+	if err != nil {
+		return err
+	}
+	// references must point either to subject or to KafkaSchema object
+	for _, ref := range schema.References {
+		if (len(ref.Subject) == 0) == (ref.SchemaRef == nil) {
+			return fmt.Errorf("reference %s of schema %s must have either subject or schemaRef", ref.Name, schema.Name)
+		}
+	}
+	return nil
+}
+
+// parseSchemaType would return srclient type of schema
+func parseSchemaType(schema *v1alpha1.KafkaSchemaSpec) (srclient.SchemaType, error) {
+	switch SchemaType(schema) {
+	case SchemaTypeAvro:
+		return srclient.Avro, nil
+	case SchemaTypeProtobuf:
+		return srclient.Protobuf, nil
+	case SchemaTypeJSON:
+		return srclient.Json, nil
+	}
+	return "", fmt.Errorf("unsupported schema type %s of schema %s, must be one of %s, %s, %s",
+		schema.SchemaType, schema.Name, SchemaTypeAvro, SchemaTypeProtobuf, SchemaTypeJSON)
+}
@@ -0,0 +1,112 @@
+package schemaregistry_test
+
+import (
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schema   *v1alpha1.KafkaSchemaSpec
+		wantType string
+		wantErr  bool
+	}{
+		{
+			name: "default Avro",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				Name:   "test-value",
+				Schema: `{"type": "string"}`,
+			},
+			wantType: schemaregistry.SchemaTypeAvro,
+		},
+		{
+			name: "Protobuf",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				Name:       "test-value",
+				Schema:     `syntax = "proto3"; message Test { string name = 1; }`,
+				SchemaType: "PROTOBUF",
+			},
+			wantType: schemaregistry.SchemaTypeProtobuf,
+		},
+		{
+			name: "JSON in lower case",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				Name:       "test-value",
+				Schema:     `{"type": "object"}`,
+				SchemaType: "json",
+			},
+			wantType: schemaregistry.SchemaTypeJSON,
+		},
+		{
+			name: "unknown type",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				Name:       "test-value",
+				Schema:     `{"type": "string"}`,
+				SchemaType: "THRIFT",
+			},
+			wantType: "THRIFT",
+			wantErr:  true,
+		},
+		{
+			name: "Avro is not JSON",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				Name:   "test-value",
+				Schema: `syntax = "proto3";`,
+			},
+			wantType: schemaregistry.SchemaTypeAvro,
+			wantErr:  true,
+		},
+		{
+			name: "Protobuf is JSON",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				Name:       "test-value",
+				Schema:     `{}`,
+				SchemaType: "PROTOBUF",
+			},
+			wantType: schemaregistry.SchemaTypeProtobuf,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.wantType, schemaregistry.SchemaType(tt.schema))
+			err := schemaregistry.ValidateSchema(tt.schema)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestValidateSchema_References(t *testing.T) {
+	t.Parallel()
+
+	schema := &v1alpha1.KafkaSchemaSpec{
+		Name:   "test-value",
+		Schema: `{"type": "record", "name": "Test", "fields": [{"name": "id", "type": "com.example.Id"}]}`,
+		References: []v1alpha1.SchemaReference{
+			{Name: "com.example.Id", Subject: "id-value"},
+			{Name: "com.example.Name", SchemaRef: &v1alpha1.KafkaSchemaRef{Name: "name"}},
+		},
+	}
+	require.NoError(t, schemaregistry.ValidateSchema(schema))
+	// neither subject nor schemaRef
+	schema.References = append(schema.References, v1alpha1.SchemaReference{Name: "com.example.Type"})
+	require.Error(t, schemaregistry.ValidateSchema(schema))
+	// both subject and schemaRef
+	schema.References[2] = v1alpha1.SchemaReference{
+		Name:      "com.example.Type",
+		Subject:   "type-value",
+		SchemaRef: &v1alpha1.KafkaSchemaRef{Name: "type"},
+	}
+	require.Error(t, schemaregistry.ValidateSchema(schema))
+}
@@ -0,0 +1,135 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+)
+
+// Subject name strategies, see https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#subject-name-strategy
+const (
+	SubjectStrategyTopicName       = "TopicName"
+	SubjectStrategyRecordName      = "RecordName"
+	SubjectStrategyTopicRecordName = "TopicRecordName"
+	// Part of Kafka message schema describes
+	KeyOrValueKey   = "key"
+	KeyOrValueValue = "value"
+	// contextPrefix starts subject qualified with context, i.e. :.tenant:orders-value
+	contextPrefix = ":."
+	// allContexts is subject prefix matching subjects of all contexts
+	allContexts = ":*:"
+)
+
+var (
+	protobufPackage = regexp.MustCompile(`(?m)^\s*package\s+([\w.]+)\s*;`)
+	protobufMessage = regexp.MustCompile(`(?m)^\s*message\s+(\w+)`)
+)
+
+// Subject would return subject of schema derived by its subject strategy from topic and record name,
+// qualified with context of schema. Name of schema is subject if strategy isn't set.
+func Subject(schema *v1alpha1.KafkaSchemaSpec, topic string) (string, error) {
+	subject, err := strategySubject(schema, topic)
+	if err != nil {
+		return "", err
+	}
+	return ContextSubject(schema.Context, subject), nil
+}
+
+// ContextSubject would qualify subject with context, i.e. :.tenant:orders-value.
+// Subject is returned as is if context isn't set or subject is qualified already.
+func ContextSubject(context, subject string) string {
+	if len(context) == 0 || strings.HasPrefix(subject, contextPrefix) {
+		return subject
+	}
+	return contextPrefix + context + ":" + subject
+}
+
+// SplitSubject would return context and unqualified subject, context is empty for subjects of default context
+func SplitSubject(subject string) (string, string) {
+	if !strings.HasPrefix(subject, contextPrefix) {
+		return "", subject
+	}
+	context, name, found := strings.Cut(strings.TrimPrefix(subject, contextPrefix), ":")
+	if !found {
+		// context itself, i.e. :.tenant: is written without subject
+		return context, ""
+	}
+	return context, name
+}
+
+// strategySubject would derive subject by subject strategy of schema
+func strategySubject(schema *v1alpha1.KafkaSchemaSpec, topic string) (string, error) {
+	keyOrValue := schema.KeyOrValue
+	if len(keyOrValue) == 0 {
+		keyOrValue = KeyOrValueValue
+	}
+	if len(schema.SubjectStrategy) != 0 && len(schema.Name) != 0 {
+		return "", fmt.Errorf("name and subjectStrategy can't be set both")
+	}
+	switch schema.SubjectStrategy {
+	case "":
+		if len(schema.Name) == 0 {
+			return "", fmt.Errorf("either name or subjectStrategy must be set")
+		}
+		return schema.Name, nil
+	case SubjectStrategyTopicName:
+		if len(topic) == 0 {
+			return "", fmt.Errorf("topicRef must be set for %s subject strategy", schema.SubjectStrategy)
+		}
+		return topic + "-" + keyOrValue, nil
+	case SubjectStrategyRecordName:
+		return RecordName(schema)
+	case SubjectStrategyTopicRecordName:
+		if len(topic) == 0 {
+			return "", fmt.Errorf("topicRef must be set for %s subject strategy", schema.SubjectStrategy)
+		}
+		record, err := RecordName(schema)
+		if err != nil {
+			return "", err
+		}
+		return topic + "-" + record, nil
+	}
+	return "", fmt.Errorf("unsupported subject strategy %s, must be one of %s, %s, %s", schema.SubjectStrategy,
+		SubjectStrategyTopicName, SubjectStrategyRecordName, SubjectStrategyTopicRecordName)
+}
+
+// RecordName would return fully qualified name of record schema describes: namespace and name of Avro record,
+// package and first message of Protobuf definition or title of JSON Schema
+func RecordName(schema *v1alpha1.KafkaSchemaSpec) (string, error) {
+	switch SchemaType(schema) {
+	case SchemaTypeAvro:
+		record := struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		}{}
+		if json.Unmarshal([]byte(schema.Schema), &record) != nil || len(record.Name) == 0 {
+			return "", fmt.Errorf("schema must be named Avro record to derive subject from record name")
+		}
+		// name with dots is full name already
+		if len(record.Namespace) == 0 || strings.Contains(record.Name, ".") {
+			return record.Name, nil
+		}
+		return record.Namespace + "." + record.Name, nil
+	case SchemaTypeProtobuf:
+		message := protobufMessage.FindStringSubmatch(schema.Schema)
+		if message == nil {
+			return "", fmt.Errorf("schema must have Protobuf message to derive subject from record name")
+		}
+		if pkg := protobufPackage.FindStringSubmatch(schema.Schema); pkg != nil {
+			return pkg[1] + "." + message[1], nil
+		}
+		return message[1], nil
+	case SchemaTypeJSON:
+		doc := struct {
+			Title string `json:"title"`
+		}{}
+		if json.Unmarshal([]byte(schema.Schema), &doc) != nil || len(doc.Title) == 0 {
+			return "", fmt.Errorf("schema must have JSON Schema title to derive subject from record name")
+		}
+		return doc.Title, nil
+	}
+	return "", fmt.Errorf("unsupported schema type %s", schema.SchemaType)
+}
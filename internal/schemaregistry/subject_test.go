@@ -0,0 +1,147 @@
+package schemaregistry_test
+
+import (
+	"testing"
+
+	"github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubject(t *testing.T) {
+	t.Parallel()
+
+	avro := `{"type": "record", "name": "Order", "namespace": "com.example", "fields": []}`
+	tests := []struct {
+		name    string
+		schema  *v1alpha1.KafkaSchemaSpec
+		topic   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "name",
+			schema: &v1alpha1.KafkaSchemaSpec{Name: "orders-value", Schema: avro},
+			want:   "orders-value",
+		},
+		{
+			name:    "neither name nor strategy",
+			schema:  &v1alpha1.KafkaSchemaSpec{Schema: avro},
+			wantErr: true,
+		},
+		{
+			name:    "both name and strategy",
+			schema:  &v1alpha1.KafkaSchemaSpec{Name: "orders-value", SubjectStrategy: schemaregistry.SubjectStrategyTopicName, Schema: avro},
+			topic:   "orders",
+			wantErr: true,
+		},
+		{
+			name:   "topic name of value by default",
+			schema: &v1alpha1.KafkaSchemaSpec{SubjectStrategy: schemaregistry.SubjectStrategyTopicName, Schema: avro},
+			topic:  "orders",
+			want:   "orders-value",
+		},
+		{
+			name: "topic name of key",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SubjectStrategy: schemaregistry.SubjectStrategyTopicName,
+				KeyOrValue:      schemaregistry.KeyOrValueKey,
+				Schema:          `{"type": "string"}`,
+			},
+			topic: "orders",
+			want:  "orders-key",
+		},
+		{
+			name:    "topic name without topic",
+			schema:  &v1alpha1.KafkaSchemaSpec{SubjectStrategy: schemaregistry.SubjectStrategyTopicName, Schema: avro},
+			wantErr: true,
+		},
+		{
+			name:   "Avro record name",
+			schema: &v1alpha1.KafkaSchemaSpec{SubjectStrategy: schemaregistry.SubjectStrategyRecordName, Schema: avro},
+			want:   "com.example.Order",
+		},
+		{
+			name: "Avro full name",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SubjectStrategy: schemaregistry.SubjectStrategyRecordName,
+				Schema:          `{"type": "record", "name": "com.example.Order", "namespace": "other", "fields": []}`,
+			},
+			want: "com.example.Order",
+		},
+		{
+			name:    "Avro primitive has no record name",
+			schema:  &v1alpha1.KafkaSchemaSpec{SubjectStrategy: schemaregistry.SubjectStrategyRecordName, Schema: `{"type": "string"}`},
+			wantErr: true,
+		},
+		{
+			name: "Protobuf topic record name",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SubjectStrategy: schemaregistry.SubjectStrategyTopicRecordName,
+				SchemaType:      schemaregistry.SchemaTypeProtobuf,
+				Schema:          "syntax = \"proto3\";\npackage com.example;\n\nmessage Order {\n  string id = 1;\n}\n",
+			},
+			topic: "orders",
+			want:  "orders-com.example.Order",
+		},
+		{
+			name: "JSON Schema title",
+			schema: &v1alpha1.KafkaSchemaSpec{
+				SubjectStrategy: schemaregistry.SubjectStrategyRecordName,
+				SchemaType:      schemaregistry.SchemaTypeJSON,
+				Schema:          `{"title": "Order", "type": "object"}`,
+			},
+			want: "Order",
+		},
+		{
+			name:   "name in context",
+			schema: &v1alpha1.KafkaSchemaSpec{Name: "orders-value", Context: "tenant", Schema: avro},
+			want:   ":.tenant:orders-value",
+		},
+		{
+			name:   "topic name in context",
+			schema: &v1alpha1.KafkaSchemaSpec{SubjectStrategy: schemaregistry.SubjectStrategyTopicName, Context: "tenant", Schema: avro},
+			topic:  "orders",
+			want:   ":.tenant:orders-value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			subject, err := schemaregistry.Subject(tt.schema, tt.topic)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, subject)
+		})
+	}
+}
+
+func TestSplitSubject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		subject     string
+		wantContext string
+		wantName    string
+	}{
+		{subject: "orders-value", wantName: "orders-value"},
+		{subject: ":.tenant:orders-value", wantContext: "tenant", wantName: "orders-value"},
+		{subject: ":.tenant:", wantContext: "tenant"},
+		// colons of subject are kept
+		{subject: ":.tenant:orders:value", wantContext: "tenant", wantName: "orders:value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			t.Parallel()
+			context, name := schemaregistry.SplitSubject(tt.subject)
+			require.Equal(t, tt.wantContext, context)
+			require.Equal(t, tt.wantName, name)
+			if len(tt.wantName) != 0 {
+				require.Equal(t, tt.subject, schemaregistry.ContextSubject(context, name))
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+)
+
+// log is for logging in this package.
+var kafkaschemalog = logf.Log.WithName("kafkaschema-resource")
+
+// kafkaSchemaKind is kind of validation errors
+var kafkaSchemaKind = xov1alpha1.GroupVersion.WithKind("KafkaSchema").GroupKind()
+
+// SetupKafkaSchemaWebhookWithManager registers the webhook for KafkaSchema in the manager.
+// Compatibility with registered schemas is checked only if registry is not nil.
+func SetupKafkaSchemaWebhookWithManager(mgr ctrl.Manager, registry schemaregistry.Backend) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&xov1alpha1.KafkaSchema{}).
+		WithValidator(&KafkaSchemaCustomValidator{
+			Reader:   mgr.GetClient(),
+			Registry: registry,
+		}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-xo-90poe-io-v1alpha1-kafkaschema,mutating=false,failurePolicy=fail,sideEffects=None,groups=xo.90poe.io,resources=kafkaschemas,verbs=create;update,versions=v1alpha1,name=vkafkaschema-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// KafkaSchemaCustomValidator validates KafkaSchema when it is created or updated,
+// so that invalid schema is rejected by kubectl apply and not by Schema Registry during reconcile
+type KafkaSchemaCustomValidator struct {
+	// Reader is used to get KafkaTopic subject is derived from
+	Reader client.Reader
+	// Registry is used to check compatibility with latest registered version, check is skipped if nil
+	Registry schemaregistry.Backend
+}
+
+var _ admission.CustomValidator = &KafkaSchemaCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type KafkaSchema.
+func (v *KafkaSchemaCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	schema, ok := obj.(*xov1alpha1.KafkaSchema)
+	if !ok {
+		return nil, fmt.Errorf("expected a KafkaSchema object but got %T", obj)
+	}
+	kafkaschemalog.V(1).Info("Validation for KafkaSchema upon creation", "name", schema.GetName())
+	return v.validate(ctx, schema)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type KafkaSchema.
+func (v *KafkaSchemaCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldSchema, ok := oldObj.(*xov1alpha1.KafkaSchema)
+	if !ok {
+		return nil, fmt.Errorf("expected a KafkaSchema object for the oldObj but got %T", oldObj)
+	}
+	schema, ok := newObj.(*xov1alpha1.KafkaSchema)
+	if !ok {
+		return nil, fmt.Errorf("expected a KafkaSchema object for the newObj but got %T", newObj)
+	}
+	kafkaschemalog.V(1).Info("Validation for KafkaSchema upon update", "name", schema.GetName())
+	// metadata and status updates, i.e. removal of finalizer, must never be blocked
+	if !schema.DeletionTimestamp.IsZero() || reflect.DeepEqual(oldSchema.Spec, schema.Spec) {
+		return nil, nil
+	}
+	return v.validate(ctx, schema)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type KafkaSchema.
+func (v *KafkaSchemaCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate would check spec of KafkaSchema and, if registry is set, compatibility of inline schema
+func (v *KafkaSchemaCustomValidator) validate(ctx context.Context, schema *xov1alpha1.KafkaSchema) (admission.Warnings, error) {
+	spec := &schema.Spec
+	specPath := field.NewPath("spec")
+	errs := field.ErrorList{}
+
+	// schema source
+	switch {
+	case len(spec.Schema) == 0 && spec.SchemaFrom == nil:
+		errs = append(errs, field.Required(specPath.Child("schema"), "either schema or schemaFrom must be set"))
+	case len(spec.Schema) != 0 && spec.SchemaFrom != nil:
+		errs = append(errs, field.Forbidden(specPath.Child("schemaFrom"), "schema and schemaFrom can't be set both"))
+	case spec.SchemaFrom != nil:
+		source := spec.SchemaFrom
+		if source.ConfigMapKeyRef == nil {
+			errs = append(errs, field.Required(specPath.Child("schemaFrom", "configMapKeyRef"), "configMapKeyRef must be set"))
+		}
+		if len(source.Imports) != 0 && schemaregistry.SchemaType(spec) != schemaregistry.SchemaTypeProtobuf {
+			errs = append(errs, field.Forbidden(specPath.Child("schemaFrom", "imports"),
+				fmt.Sprintf("imports are supported only by %s schemas", schemaregistry.SchemaTypeProtobuf)))
+		}
+	}
+
+	// schema itself, schema loaded from ConfigMap is validated during reconcile
+	if len(spec.Schema) != 0 {
+		err := schemaregistry.ValidateSchema(spec)
+		if err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("schema"), field.OmitValueType{}, err.Error()))
+		}
+	}
+	for i, ref := range spec.References {
+		if (len(ref.Subject) == 0) == (ref.SchemaRef == nil) {
+			errs = append(errs, field.Invalid(specPath.Child("references").Index(i), ref.Name,
+				"either subject or schemaRef must be set"))
+		}
+	}
+
+	// subject
+	topicStrategy := spec.SubjectStrategy == schemaregistry.SubjectStrategyTopicName ||
+		spec.SubjectStrategy == schemaregistry.SubjectStrategyTopicRecordName
+	switch {
+	case len(spec.Name) == 0 && len(spec.SubjectStrategy) == 0:
+		errs = append(errs, field.Required(specPath.Child("name"), "either name or subjectStrategy must be set"))
+	case len(spec.Name) != 0 && len(spec.SubjectStrategy) != 0:
+		errs = append(errs, field.Forbidden(specPath.Child("name"), "name and subjectStrategy can't be set both"))
+	case topicStrategy && spec.TopicRef == nil:
+		errs = append(errs, field.Required(specPath.Child("topicRef"),
+			fmt.Sprintf("topicRef must be set for %s subject strategy", spec.SubjectStrategy)))
+	}
+
+	if spec.Version != 0 && spec.ID == 0 {
+		errs = append(errs, field.Required(specPath.Child("id"), "id must be set to pin version"))
+	}
+
+	// Schema Registry rejects rule set with rules of the same name
+	if spec.RuleSet != nil {
+		names := map[string]bool{}
+		checkNames := func(path *field.Path, rules []xov1alpha1.SchemaRule) {
+			for i, rule := range rules {
+				if names[rule.Name] {
+					errs = append(errs, field.Duplicate(path.Index(i).Child("name"), rule.Name))
+				}
+				names[rule.Name] = true
+			}
+		}
+		checkNames(specPath.Child("ruleSet", "domainRules"), spec.RuleSet.DomainRules)
+		checkNames(specPath.Child("ruleSet", "migrationRules"), spec.RuleSet.MigrationRules)
+	}
+
+	if len(errs) != 0 {
+		return nil, kerrors.NewInvalid(kafkaSchemaKind, schema.Name, errs)
+	}
+	return v.checkCompatibility(ctx, schema)
+}
+
+// checkCompatibility would check compatibility of inline schema with latest version registered under its subject.
+// Schema Registry being unavailable doesn't block changes, warning is returned instead.
+func (v *KafkaSchemaCustomValidator) checkCompatibility(ctx context.Context, schema *xov1alpha1.KafkaSchema) (admission.Warnings, error) {
+	spec := schema.Spec.DeepCopy()
+	// references are resolved and schema is loaded during reconcile,
+	// schemas with pinned ID are imported without compatibility check,
+	// Registry is registry operator is configured with, not one of KafkaSchemaRegistry
+	if v.Registry == nil || len(spec.Schema) == 0 || len(spec.References) != 0 || spec.ID != 0 || spec.RegistryRef != nil {
+		return nil, nil
+	}
+	topic := ""
+	if spec.TopicRef != nil {
+		ref := spec.TopicRef
+		key := types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		if len(key.Namespace) == 0 {
+			key.Namespace = schema.Namespace
+		}
+		kafkaTopic := &xov1alpha1.KafkaTopic{}
+		err := v.Reader.Get(ctx, key, kafkaTopic)
+		if err != nil {
+			return admission.Warnings{fmt.Sprintf("compatibility wasn't checked, can't get KafkaTopic %s: %v", key, err)}, nil
+		}
+		topic = kafkaTopic.Spec.Name
+	}
+	subject, err := schemaregistry.Subject(spec, topic)
+	if err != nil {
+		return nil, kerrors.NewInvalid(kafkaSchemaKind, schema.Name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "subjectStrategy"), spec.SubjectStrategy, err.Error()),
+		})
+	}
+	spec.Name = subject
+	compat, err := v.Registry.CheckCompatibility(ctx, spec, nil)
+	if err != nil {
+		return admission.Warnings{fmt.Sprintf("compatibility wasn't checked: %v", err)}, nil
+	}
+	if !compat.IsCompatible {
+		return nil, kerrors.NewInvalid(kafkaSchemaKind, schema.Name, field.ErrorList{
+			field.Invalid(field.NewPath("spec", "schema"), field.OmitValueType{},
+				fmt.Sprintf("schema is incompatible with latest version of subject %s: %s",
+					subject, strings.Join(compat.Messages, "; "))),
+		})
+	}
+	return nil, nil
+}
@@ -0,0 +1,173 @@
+package v1alpha1_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	webhookv1alpha1 "github.com/90poe/kafkaobjects-operator/internal/webhook/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func newSchema(spec xov1alpha1.KafkaSchemaSpec) *xov1alpha1.KafkaSchema {
+	return &xov1alpha1.KafkaSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec:       spec,
+	}
+}
+
+func TestKafkaSchemaCustomValidator_Validate(t *testing.T) {
+	t.Parallel()
+
+	validator := &webhookv1alpha1.KafkaSchemaCustomValidator{}
+	tests := []struct {
+		name    string
+		spec    xov1alpha1.KafkaSchemaSpec
+		wantErr string
+	}{
+		{
+			name: "valid Avro",
+			spec: xov1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "string"}`},
+		},
+		{
+			name:    "empty Avro",
+			spec:    xov1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{}`},
+			wantErr: "spec.schema: Invalid value: invalid Avro schema",
+		},
+		{
+			name:    "no schema",
+			spec:    xov1alpha1.KafkaSchemaSpec{Name: "test-value"},
+			wantErr: "spec.schema: Required value",
+		},
+		{
+			name: "schema loaded from ConfigMap",
+			spec: xov1alpha1.KafkaSchemaSpec{
+				Name:       "test-value",
+				SchemaFrom: &xov1alpha1.SchemaSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "schema.avsc"}},
+			},
+		},
+		{
+			name: "schema source without ConfigMap",
+			spec: xov1alpha1.KafkaSchemaSpec{
+				Name:       "test-value",
+				SchemaFrom: &xov1alpha1.SchemaSource{},
+			},
+			wantErr: "spec.schemaFrom.configMapKeyRef: Required value",
+		},
+		{
+			name: "no subject",
+			spec: xov1alpha1.KafkaSchemaSpec{
+				Schema: `{"type": "string"}`,
+			},
+			wantErr: "spec.name: Required value",
+		},
+		{
+			name: "topic strategy without topic",
+			spec: xov1alpha1.KafkaSchemaSpec{
+				SubjectStrategy: schemaregistry.SubjectStrategyTopicName,
+				Schema:          `{"type": "string"}`,
+			},
+			wantErr: "spec.topicRef: Required value",
+		},
+		{
+			name: "version pinned without ID",
+			spec: xov1alpha1.KafkaSchemaSpec{
+				Name:    "test-value",
+				Schema:  `{"type": "string"}`,
+				Version: 3,
+			},
+			wantErr: "spec.id: Required value",
+		},
+		{
+			name: "invalid Protobuf",
+			spec: xov1alpha1.KafkaSchemaSpec{
+				Name:       "test-value",
+				SchemaType: schemaregistry.SchemaTypeProtobuf,
+				Schema:     "syntax = \"proto3\";\nmessage Test {\n",
+			},
+			wantErr: "unbalanced braces",
+		},
+		{
+			name: "rules of the same name",
+			spec: xov1alpha1.KafkaSchemaSpec{
+				Name:   "test-value",
+				Schema: `{"type": "string"}`,
+				RuleSet: &xov1alpha1.SchemaRuleSet{
+					DomainRules:    []xov1alpha1.SchemaRule{{Name: "check", Kind: "CONDITION", Mode: "WRITE", Type: "CEL"}},
+					MigrationRules: []xov1alpha1.SchemaRule{{Name: "check", Kind: "TRANSFORM", Mode: "UPGRADE", Type: "JSONATA"}},
+				},
+			},
+			wantErr: "Duplicate value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := validator.ValidateCreate(context.Background(), newSchema(tt.spec))
+			if len(tt.wantErr) != 0 {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKafkaSchemaCustomValidator_Compatibility(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", schemaregistry.ContentType)
+		if r.URL.Path != "/compatibility/subjects/test-value/versions/latest" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]any{"error_code": 50001, "message": "Error in the backend data store"})
+			return
+		}
+		body := map[string]any{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["schema"] == `{"type": "string"}` {
+			_ = json.NewEncoder(w).Encode(map[string]any{"is_compatible": true})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"is_compatible": false, "messages": []string{"type changed"}})
+	}))
+	t.Cleanup(server.Close)
+	registry, err := schemaregistry.NewClient(schemaregistry.URL(server.URL))
+	require.NoError(t, err)
+	validator := &webhookv1alpha1.KafkaSchemaCustomValidator{Registry: registry}
+	ctx := context.Background()
+
+	_, err = validator.ValidateCreate(ctx, newSchema(xov1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "string"}`}))
+	require.NoError(t, err)
+
+	_, err = validator.ValidateCreate(ctx, newSchema(xov1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "long"}`}))
+	require.ErrorContains(t, err, "incompatible with latest version of subject test-value: type changed")
+
+	// registry errors don't block changes
+	warnings, err := validator.ValidateCreate(ctx, newSchema(xov1alpha1.KafkaSchemaSpec{Name: "other-value", Schema: `{"type": "long"}`}))
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+
+	// schema of other registry isn't checked by registry operator is configured with
+	warnings, err = validator.ValidateCreate(ctx, newSchema(xov1alpha1.KafkaSchemaSpec{
+		Name:        "other-value",
+		Schema:      `{"type": "long"}`,
+		RegistryRef: &xov1alpha1.KafkaSchemaRegistryRef{Name: "tenant"},
+	}))
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+
+	// unchanged spec isn't validated, so finalizers can be removed from invalid schema
+	old := newSchema(xov1alpha1.KafkaSchemaSpec{Name: "test-value", Schema: `{"type": "long"}`})
+	updated := old.DeepCopy()
+	updated.Finalizers = nil
+	_, err = validator.ValidateUpdate(ctx, old, updated)
+	require.NoError(t, err)
+}
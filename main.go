@@ -34,6 +34,12 @@ import (
 
 	xov1alpha1 "github.com/90poe/kafkaobjects-operator/api/v1alpha1"
 	"github.com/90poe/kafkaobjects-operator/controllers"
+	"github.com/90poe/kafkaobjects-operator/internal/audit"
+	"github.com/90poe/kafkaobjects-operator/internal/env"
+	"github.com/90poe/kafkaobjects-operator/internal/kafka"
+	"github.com/90poe/kafkaobjects-operator/internal/reporter"
+	"github.com/90poe/kafkaobjects-operator/internal/schemaregistry"
+	webhookv1alpha1 "github.com/90poe/kafkaobjects-operator/internal/webhook/v1alpha1"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	//+kubebuilder:scaffold:imports
 )
@@ -95,20 +101,108 @@ func main() {
 		os.Exit(1)
 	}
 
+	config, err := env.NewConfig()
+	if err != nil {
+		setupLog.Error(err, "unable to read config")
+		os.Exit(1)
+	}
+	// Slack messenger is shared by all reconcilers, manager will run it
+	// and stop it on shutdown, flushing all queued messages
+	messenger, err := reporter.New(config.SlackToken,
+		reporter.SlackChannel(config.SlackChannel),
+		reporter.Cluster(config.ClusterName),
+		reporter.NotificationLevel(config.NotificationLevel),
+		reporter.BufferSize(config.SlackBufferSize))
+	if err != nil {
+		setupLog.Error(err, "unable to create reporter")
+		os.Exit(1)
+	}
+	if err = mgr.Add(messenger); err != nil {
+		setupLog.Error(err, "unable to add reporter to manager")
+		os.Exit(1)
+	}
+
+	// Auditor records all changes made to Kafka and Schema Registry
+	auditor, err := newAuditor(config)
+	if err != nil {
+		setupLog.Error(err, "unable to create auditor")
+		os.Exit(1)
+	}
+	defer auditor.Close()
+
 	if err = (&controllers.KafkaTopicReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Messenger: messenger,
+		Auditor:   auditor,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KafkaTopic")
 		os.Exit(1)
 	}
+	// Clients of Schema Registries are shared by KafkaSchema and KafkaSchemaRegistry reconcilers
+	registries, err := controllers.NewSchemaRegistries(mgr, config, auditor)
+	if err != nil {
+		setupLog.Error(err, "unable to create schema registry clients")
+		os.Exit(1)
+	}
 	if err = (&controllers.KafkaSchemaReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Messenger:  messenger,
+		Registries: registries,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "KafkaSchema")
 		os.Exit(1)
 	}
+	if err = (&controllers.KafkaSchemaRegistryReconciler{
+		Client:     mgr.GetClient(),
+		Scheme:     mgr.GetScheme(),
+		Messenger:  messenger,
+		Registries: registries,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KafkaSchemaRegistry")
+		os.Exit(1)
+	}
+	if err = (&controllers.KafkaACLReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Messenger: messenger,
+		Auditor:   auditor,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KafkaACL")
+		os.Exit(1)
+	}
+	if err = (&controllers.KafkaUserReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Messenger: messenger,
+		Auditor:   auditor,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KafkaUser")
+		os.Exit(1)
+	}
+	if err = (&controllers.DigestReporter{
+		Client:    mgr.GetClient(),
+		Messenger: messenger,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to set up inventory digest")
+		os.Exit(1)
+	}
+	// Webhook server needs certificates, so webhooks are enabled only where they are provisioned
+	if config.EnableWebhooks {
+		var registry schemaregistry.Backend
+		if config.WebhookCompatibility {
+			registry, err = schemaregistry.NewBackend(config)
+			if err != nil {
+				setupLog.Error(err, "unable to create schema registry client for webhook")
+				os.Exit(1)
+			}
+		}
+		if err = webhookv1alpha1.SetupKafkaSchemaWebhookWithManager(mgr, registry); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "KafkaSchema")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -123,6 +217,33 @@ func main() {
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
+		auditor.Close()
 		os.Exit(1)
 	}
 }
+
+// newAuditor would make Auditor writing to file and, if configured, to Kafka topic
+func newAuditor(config *env.Config) (*audit.Auditor, error) {
+	fileSink, err := audit.NewFileSink(config.AuditFile)
+	if err != nil {
+		return nil, err
+	}
+	sinks := []audit.Sink{fileSink}
+	if len(config.AuditKafkaTopic) != 0 {
+		kConfig, err := kafka.NewClusterConfig(config.KafkaTopicNameRegexp,
+			kafka.Brokers(config.KafkaBrokers))
+		if err != nil {
+			return nil, err
+		}
+		kCl, err := kConfig.RawClient()
+		if err != nil {
+			return nil, err
+		}
+		kafkaSink, err := audit.NewKafkaSink(kCl, config.AuditKafkaTopic)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, kafkaSink)
+	}
+	return audit.New(sinks...), nil
+}